@@ -0,0 +1,176 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package dashboard
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	alertStateFiring    = "firing"
+	alertInstanceFiring = "alerting"
+	dashboardUIDLabel   = "__dashboardUid__"
+	panelIDLabel        = "__panelId__"
+	healthOK            = "ok"
+)
+
+// AlertRulesResponse is the response of Grafana alerting API in Prometheus format
+type AlertRulesResponse struct {
+	Data struct {
+		Groups []struct {
+			Rules []AlertRuleResponse `json:"rules"`
+		} `json:"groups"`
+	} `json:"data"`
+}
+
+type AlertRuleResponse struct {
+	Name        string            `json:"name"`
+	State       string            `json:"state"`
+	Health      string            `json:"health"`
+	Annotations map[string]string `json:"annotations"`
+	Alerts      []AlertInstance   `json:"alerts"`
+}
+
+type AlertInstance struct {
+	Labels   map[string]string `json:"labels"`
+	State    string            `json:"state"`
+	ActiveAt time.Time         `json:"activeAt"`
+}
+
+// AlertSummary is the summary of alert rules linked to panels of the dashboard
+type AlertSummary struct {
+	// Rules sorted by state, firing rules are the first
+	Rules []AlertRule
+}
+
+// AlertRule is the state of the alert rule in the report time range
+type AlertRule struct {
+	Name    string
+	PanelID int
+	Panel   string
+	// State is the current state of the rule: firing, pending, inactive, nodata or error
+	State           string
+	LastStateChange time.Time
+	// FiringInstances is the number of alert instances firing within the report time range
+	FiringInstances int
+	// location is the timezone of the report the time is printed in
+	location *time.Location
+}
+
+// NewAlertSummary builds the summary of alert rules of the dashboard. The history contains annotations of alert
+// state changes in the time range from-to.
+func NewAlertSummary(response *AlertRulesResponse, dashboardUID string, history []Annotation, from time.Time, to time.Time) *AlertSummary {
+	summary := &AlertSummary{}
+	for _, group := range response.Data.Groups {
+		for _, ruleResponse := range group.Rules {
+			// rules that are not linked to the dashboard are skipped if Grafana ignores the filter of the request
+			uid, ok := ruleResponse.Annotations[dashboardUIDLabel]
+			if !ok || uid != dashboardUID {
+				continue
+			}
+			rule := AlertRule{
+				Name:  ruleResponse.Name,
+				State: strings.ToLower(ruleResponse.State),
+			}
+			if health := strings.ToLower(ruleResponse.Health); health != "" && health != healthOK {
+				rule.State = health
+			}
+			rule.PanelID, _ = strconv.Atoi(ruleResponse.Annotations[panelIDLabel])
+			hasHistory := rule.addHistory(history, from, to)
+			for _, instance := range ruleResponse.Alerts {
+				if instance.ActiveAt.IsZero() {
+					continue
+				}
+				if instance.ActiveAt.After(rule.LastStateChange) {
+					rule.LastStateChange = instance.ActiveAt
+				}
+				// instances started firing in the time range are counted by the history
+				firingBefore := instance.ActiveAt.Before(from) || !hasHistory
+				if strings.HasPrefix(strings.ToLower(instance.State), alertInstanceFiring) && firingBefore && !instance.ActiveAt.After(to) {
+					rule.FiringInstances++
+				}
+			}
+			summary.Rules = append(summary.Rules, rule)
+		}
+	}
+	slices.SortStableFunc(summary.Rules, func(a, b AlertRule) int {
+		if a.IsFiring() != b.IsFiring() {
+			if a.IsFiring() {
+				return -1
+			}
+			return 1
+		}
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return summary
+}
+
+// addHistory counts transitions of the rule to the firing state in the time range. It returns false if there are no
+// state changes of the rule in the history.
+func (r *AlertRule) addHistory(history []Annotation, from time.Time, to time.Time) bool {
+	found := false
+	for _, annotation := range history {
+		// annotations without the name of the rule are matched by the panel of the rule
+		if annotation.AlertName != r.Name && (annotation.AlertName != "" || annotation.PanelID == 0 || annotation.PanelID != r.PanelID) {
+			continue
+		}
+		found = true
+		changedAt := time.UnixMilli(annotation.Time)
+		if changedAt.After(r.LastStateChange) {
+			r.LastStateChange = changedAt
+		}
+		if strings.HasPrefix(strings.ToLower(annotation.NewState), alertInstanceFiring) && !changedAt.Before(from) && !changedAt.After(to) {
+			r.FiringInstances++
+		}
+	}
+	return found
+}
+
+// IsFiring checks if the rule is firing now
+func (r *AlertRule) IsFiring() bool {
+	return r.State == alertStateFiring
+}
+
+// GetLastStateChange returns the time of the last state change or empty string if it is unknown
+func (r *AlertRule) GetLastStateChange() string {
+	if r.LastStateChange.IsZero() {
+		return ""
+	}
+	return formatReportTime(r.LastStateChange, r.location)
+}
+
+// SetAlerts adds the alert summary to the dashboard and flags panels with alerts fired in the report time range
+func (sd *StructuredDashboard) SetAlerts(summary *AlertSummary) {
+	firing := map[int]bool{}
+	for i := range summary.Rules {
+		rule := &summary.Rules[i]
+		rule.Panel = sd.getPanelTitle(rule.PanelID)
+		rule.location = sd.Location
+		if rule.PanelID != 0 && rule.FiringInstances > 0 {
+			firing[rule.PanelID] = true
+		}
+	}
+	for _, row := range sd.Rows {
+		for i := range row.Panels {
+			row.Panels[i].Firing = firing[row.Panels[i].ID]
+		}
+	}
+	sd.Alerts = summary
+}
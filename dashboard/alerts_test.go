@@ -0,0 +1,79 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+const alertRulesFixture = `{"status": "success", "data": {"groups": [{"name": "group", "rules": [
+	{"name": "High CPU", "state": "firing", "health": "ok", "annotations": {"__dashboardUid__": "uid", "__panelId__": "2"},
+		"alerts": [
+			{"labels": {"instance": "a"}, "state": "Alerting", "activeAt": "2024-01-25T12:00:00Z"},
+			{"labels": {"instance": "b"}, "state": "Alerting", "activeAt": "2024-01-25T13:50:00Z"},
+			{"labels": {"instance": "c"}, "state": "Normal", "activeAt": "0001-01-01T00:00:00Z"}
+		]},
+	{"name": "Disk full", "state": "inactive", "health": "ok", "annotations": {"__dashboardUid__": "uid", "__panelId__": "3"}, "alerts": []},
+	{"name": "No data", "state": "inactive", "health": "nodata", "annotations": {"__dashboardUid__": "uid"}, "alerts": []},
+	{"name": "Other dashboard", "state": "firing", "health": "ok", "annotations": {"__dashboardUid__": "other", "__panelId__": "2"}, "alerts": []},
+	{"name": "Unlinked", "state": "firing", "health": "ok", "annotations": {"summary": "not linked to any dashboard"}, "alerts": []}
+]}]}}`
+
+func TestNewAlertSummary(t *testing.T) {
+	var response AlertRulesResponse
+	if err := json.Unmarshal([]byte(alertRulesFixture), &response); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	from := time.Date(2024, 1, 25, 13, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 1, 25, 14, 0, 0, 0, time.UTC)
+	history := []Annotation{
+		{AlertName: "High CPU", PanelID: 2, NewState: "Alerting", Time: time.Date(2024, 1, 25, 13, 50, 0, 0, time.UTC).UnixMilli()},
+		{AlertName: "Disk full", PanelID: 3, NewState: "Alerting", Time: time.Date(2024, 1, 25, 13, 10, 0, 0, time.UTC).UnixMilli()},
+		{AlertName: "Disk full", PanelID: 3, NewState: "Normal", Time: time.Date(2024, 1, 25, 13, 20, 0, 0, time.UTC).UnixMilli()},
+		// the state change without the name of the rule and the panel does not belong to rules without the panel
+		{NewState: "Alerting", Time: time.Date(2024, 1, 25, 13, 30, 0, 0, time.UTC).UnixMilli()},
+	}
+
+	summary := NewAlertSummary(&response, "uid", history, from, to)
+	if len(summary.Rules) != 3 {
+		t.Fatalf("Rules count = %d; want 3", len(summary.Rules))
+	}
+	cpu, disk, noData := summary.Rules[0], summary.Rules[1], summary.Rules[2]
+	if cpu.Name != "High CPU" || disk.Name != "Disk full" || noData.Name != "No data" {
+		t.Errorf("Rules are not sorted by state and name: %+v", summary.Rules)
+	}
+	// instance "a" fires since the time before the time range, instance "b" started firing in the time range
+	if !cpu.IsFiring() || cpu.PanelID != 2 || cpu.FiringInstances != 2 || cpu.GetLastStateChange() != "2024-01-25 13:50:00 UTC" {
+		t.Errorf("High CPU rule = %+v", cpu)
+	}
+	if disk.IsFiring() || disk.State != "inactive" || disk.FiringInstances != 1 || disk.GetLastStateChange() != "2024-01-25 13:20:00 UTC" {
+		t.Errorf("Disk full rule = %+v", disk)
+	}
+	if noData.State != "nodata" || noData.FiringInstances != 0 || noData.GetLastStateChange() != "" {
+		t.Errorf("No data rule = %+v", noData)
+	}
+}
+
+func TestSetAlerts(t *testing.T) {
+	entity := &Entity{Dashboard: Dashboard{Panels: []Panel{
+		{ID: 2, Title: "CPU", GridPos: GridPos{H: 8, W: 12, X: 0, Y: 0}},
+		{ID: 3, Title: "Disk", GridPos: GridPos{H: 8, W: 12, X: 12, Y: 0}},
+	}}}
+	sd, err := entity.GetStructuredDashboard(RowsExpanded)
+	if err != nil {
+		t.Fatalf("GetStructuredDashboard failed: %v", err)
+	}
+	sd.SetAlerts(&AlertSummary{Rules: []AlertRule{
+		{Name: "High CPU", PanelID: 2, State: "firing", FiringInstances: 1},
+		{Name: "Disk full", PanelID: 3, State: "inactive"},
+		{Name: "Unknown panel", PanelID: 9, State: "inactive"},
+	}})
+
+	if sd.Alerts == nil || sd.Alerts.Rules[0].Panel != "CPU" || sd.Alerts.Rules[2].Panel != "Panel 9" {
+		t.Errorf("Alerts = %+v; want rules linked with panel titles", sd.Alerts)
+	}
+	line := sd.Rows[0].Lines[0]
+	if !sd.Rows[0].Panels[0].Firing || !line.Panels[0].Firing || line.Panels[1].Firing {
+		t.Errorf("Only the panel CPU must be flagged as firing: %+v", line.Panels)
+	}
+}
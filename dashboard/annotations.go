@@ -0,0 +1,256 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package dashboard
+
+import (
+	"cmp"
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Types of annotation queries to the Grafana annotations API
+const (
+	AnnotationsOfDashboard = "dashboard"
+	AnnotationsByTags      = "tags"
+	// AnnotationsOfAlerts are state changes of alert rules of the dashboard. They are requested for the alerts summary,
+	// annotation queries of the dashboard are never of this type.
+	AnnotationsOfAlerts = "alerts"
+)
+
+const (
+	grafanaDatasourceUID   = "-- Grafana --"
+	reportTimeFormat       = "2006-01-02 15:04:05 MST"
+	defaultAnnotationLimit = 100
+)
+
+// AnnotationQuery is a definition of annotations stored in Grafana. Annotations of other data sources
+// can not be requested by the reporter and are ignored.
+type AnnotationQuery struct {
+	Name string
+	// Type is AnnotationsOfDashboard or AnnotationsByTags for queries of the dashboard, AnnotationsOfAlerts for the alerts summary
+	Type     string
+	Tags     []string
+	MatchAny bool
+	Limit    int
+}
+
+// Annotation is an event returned by Grafana annotations API
+type Annotation struct {
+	ID      int64    `json:"id"`
+	PanelID int      `json:"panelId"`
+	Time    int64    `json:"time"`
+	TimeEnd int64    `json:"timeEnd"`
+	Tags    []string `json:"tags"`
+	Text    string   `json:"text"`
+	// AlertName and NewState are set for annotations of alert state changes
+	AlertName string `json:"alertName"`
+	NewState  string `json:"newState"`
+	// Panel is the title of the panel the annotation belongs to
+	Panel string `json:"-"`
+	// location is the timezone of the report the time is printed in
+	location *time.Location
+}
+
+// AnnotationsV1 is the list of annotation definitions of the dashboard in schema v1
+type AnnotationsV1 struct {
+	List []AnnotationDefinition `json:"list"`
+}
+
+type AnnotationDefinition struct {
+	Name       string          `json:"name"`
+	BuiltIn    int             `json:"builtIn"`
+	Enable     bool            `json:"enable"`
+	Datasource json.RawMessage `json:"datasource"`
+	// Type, Tags, Limit and MatchAny are set in the target since Grafana 8 and in the definition before
+	Type     string                `json:"type"`
+	Tags     []string              `json:"tags"`
+	Limit    int                   `json:"limit"`
+	MatchAny bool                  `json:"matchAny"`
+	Target   *AnnotationTargetSpec `json:"target"`
+}
+
+type AnnotationTargetSpec struct {
+	Type     string   `json:"type"`
+	Tags     []string `json:"tags"`
+	Limit    int      `json:"limit"`
+	MatchAny bool     `json:"matchAny"`
+}
+
+// AnnotationQueryV2 is an annotation definition of the dashboard in schema v2
+type AnnotationQueryV2 struct {
+	Kind string                `json:"kind"`
+	Spec AnnotationQuerySpecV2 `json:"spec"`
+}
+
+type AnnotationQuerySpecV2 struct {
+	Name       string `json:"name"`
+	BuiltIn    bool   `json:"builtIn"`
+	Enable     bool   `json:"enable"`
+	Datasource struct {
+		Type string `json:"type"`
+		UID  string `json:"uid"`
+	} `json:"datasource"`
+	// Query describes the data source in "group" since v2beta1 and in "kind" in v2alpha1
+	Query struct {
+		Kind  string               `json:"kind"`
+		Group string               `json:"group"`
+		Spec  AnnotationTargetSpec `json:"spec"`
+	} `json:"query"`
+}
+
+// GetQueries returns enabled queries of annotations stored in Grafana
+func (a *AnnotationsV1) GetQueries() []AnnotationQuery {
+	var queries []AnnotationQuery
+	for _, definition := range a.List {
+		if !definition.Enable || (definition.BuiltIn != 1 && !isGrafanaDatasource(definition.Datasource)) {
+			continue
+		}
+		target := AnnotationTargetSpec{Type: definition.Type, Tags: definition.Tags, Limit: definition.Limit, MatchAny: definition.MatchAny}
+		if definition.Target != nil {
+			target = *definition.Target
+		}
+		if definition.BuiltIn == 1 {
+			target.Type = AnnotationsOfDashboard
+		}
+		if query, ok := newAnnotationQuery(definition.Name, target); ok {
+			queries = append(queries, query)
+		}
+	}
+	return queries
+}
+
+func getAnnotationQueriesV2(annotations []AnnotationQueryV2) []AnnotationQuery {
+	var queries []AnnotationQuery
+	for _, annotation := range annotations {
+		spec := annotation.Spec
+		grafana := spec.BuiltIn || strings.EqualFold(spec.Query.Group, "grafana") || strings.EqualFold(spec.Query.Kind, "grafana") ||
+			strings.EqualFold(spec.Datasource.Type, "grafana") || spec.Datasource.UID == grafanaDatasourceUID
+		if !spec.Enable || !grafana {
+			continue
+		}
+		target := spec.Query.Spec
+		if spec.BuiltIn {
+			target.Type = AnnotationsOfDashboard
+		}
+		if query, ok := newAnnotationQuery(spec.Name, target); ok {
+			queries = append(queries, query)
+		}
+	}
+	return queries
+}
+
+func newAnnotationQuery(name string, target AnnotationTargetSpec) (AnnotationQuery, bool) {
+	query := AnnotationQuery{
+		Name:     name,
+		Type:     strings.ToLower(target.Type),
+		Tags:     target.Tags,
+		MatchAny: target.MatchAny,
+		Limit:    target.Limit,
+	}
+	if query.Type == "" {
+		query.Type = AnnotationsOfDashboard
+	}
+	if query.Limit <= 0 {
+		query.Limit = defaultAnnotationLimit
+	}
+	switch query.Type {
+	case AnnotationsOfDashboard:
+		return query, true
+	case AnnotationsByTags:
+		// Grafana does not return annotations for the query by tags without tags
+		return query, len(query.Tags) > 0
+	default:
+		return query, false
+	}
+}
+
+// isGrafanaDatasource checks if the datasource of annotation definition is the built-in Grafana one.
+// The datasource is a name in old dashboards and a reference with type and uid in new ones.
+func isGrafanaDatasource(raw json.RawMessage) bool {
+	var name string
+	if err := json.Unmarshal(raw, &name); err == nil {
+		return name == grafanaDatasourceUID
+	}
+	var reference struct {
+		Type string `json:"type"`
+		UID  string `json:"uid"`
+	}
+	if err := json.Unmarshal(raw, &reference); err != nil {
+		return false
+	}
+	return strings.EqualFold(reference.Type, "grafana") || reference.UID == grafanaDatasourceUID
+}
+
+// AddAnnotations adds annotations to the dashboard. Annotations returned by several queries are added once,
+// all annotations are sorted by time and linked with titles of their panels.
+func (sd *StructuredDashboard) AddAnnotations(annotations []Annotation) {
+	for _, annotation := range annotations {
+		if slices.ContainsFunc(sd.Annotations, func(a Annotation) bool { return a.ID == annotation.ID }) {
+			continue
+		}
+		annotation.Panel = sd.getPanelTitle(annotation.PanelID)
+		annotation.location = sd.Location
+		sd.Annotations = append(sd.Annotations, annotation)
+	}
+	slices.SortStableFunc(sd.Annotations, func(a, b Annotation) int {
+		return cmp.Compare(a.Time, b.Time)
+	})
+}
+
+// GetTime returns the start time of the annotation
+func (a *Annotation) GetTime() string {
+	return formatReportTime(time.UnixMilli(a.Time), a.location)
+}
+
+// GetTimeEnd returns the end time of the region annotation or empty string for the point one
+func (a *Annotation) GetTimeEnd() string {
+	if a.TimeEnd <= a.Time {
+		return ""
+	}
+	return formatReportTime(time.UnixMilli(a.TimeEnd), a.location)
+}
+
+// formatReportTime prints the time in the timezone of the report with the name of the zone, UTC is used if
+// the timezone is not set
+func formatReportTime(t time.Time, location *time.Location) string {
+	if location == nil {
+		location = time.UTC
+	}
+	return t.In(location).Format(reportTimeFormat)
+}
+
+// SetLocation sets the timezone of the report to the dashboard and to its annotations, alerts and version
+func (sd *StructuredDashboard) SetLocation(location *time.Location) {
+	sd.Location = location
+	for i := range sd.Annotations {
+		sd.Annotations[i].location = location
+	}
+	if sd.Alerts != nil {
+		for i := range sd.Alerts.Rules {
+			sd.Alerts.Rules[i].location = location
+		}
+	}
+	if sd.Version != nil {
+		sd.Version.location = location
+	}
+}
+
+// GetTags returns tags of the annotation separated by comma
+func (a *Annotation) GetTags() string {
+	return strings.Join(a.Tags, ", ")
+}
@@ -0,0 +1,94 @@
+package dashboard
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetAnnotationQueries(t *testing.T) {
+	v1 := `{"dashboard": {"uid": "a", "title": "A", "panels": [], "annotations": {"list": [
+		{"builtIn": 1, "datasource": {"type": "grafana", "uid": "-- Grafana --"}, "enable": true, "name": "Annotations & Alerts", "type": "dashboard"},
+		{"datasource": {"type": "grafana", "uid": "-- Grafana --"}, "enable": true, "name": "Deploys", "target": {"type": "tags", "tags": ["deploy"], "limit": 50, "matchAny": true}},
+		{"datasource": "-- Grafana --", "enable": true, "name": "Legacy", "type": "tags", "tags": ["incident"]},
+		{"datasource": {"type": "grafana", "uid": "-- Grafana --"}, "enable": true, "name": "No tags", "target": {"type": "tags"}},
+		{"datasource": {"type": "grafana", "uid": "-- Grafana --"}, "enable": false, "name": "Disabled", "type": "dashboard"},
+		{"datasource": {"type": "prometheus", "uid": "prom"}, "enable": true, "name": "Prometheus", "expr": "up"}
+	]}}}`
+	sd, err := ParseStructuredDashboard([]byte(v1), RowsExpanded, nil)
+	if err != nil {
+		t.Fatalf("ParseStructuredDashboard failed: %v", err)
+	}
+	expected := []AnnotationQuery{
+		{Name: "Annotations & Alerts", Type: AnnotationsOfDashboard, Limit: 100},
+		{Name: "Deploys", Type: AnnotationsByTags, Tags: []string{"deploy"}, MatchAny: true, Limit: 50},
+		{Name: "Legacy", Type: AnnotationsByTags, Tags: []string{"incident"}, Limit: 100},
+	}
+	assertAnnotationQueries(t, sd.AnnotationQueries, expected)
+
+	v2 := `{"apiVersion": "dashboard.grafana.app/v2beta1", "metadata": {"name": "b"}, "spec": {"title": "B", "elements": {},
+		"layout": {"kind": "GridLayout", "spec": {"items": []}}, "annotations": [
+		{"kind": "AnnotationQuery", "spec": {"builtIn": true, "enable": true, "name": "Annotations & Alerts",
+			"query": {"kind": "DataQuery", "group": "grafana", "spec": {}}}},
+		{"kind": "AnnotationQuery", "spec": {"enable": true, "name": "Deploys",
+			"query": {"kind": "DataQuery", "group": "grafana", "spec": {"type": "tags", "tags": ["deploy"]}}}},
+		{"kind": "AnnotationQuery", "spec": {"enable": true, "name": "Loki",
+			"query": {"kind": "DataQuery", "group": "loki", "spec": {"expr": "{app=\"x\"}"}}}}
+	]}}`
+	sd, err = ParseStructuredDashboard([]byte(v2), RowsExpanded, nil)
+	if err != nil {
+		t.Fatalf("ParseStructuredDashboard failed: %v", err)
+	}
+	expected = []AnnotationQuery{
+		{Name: "Annotations & Alerts", Type: AnnotationsOfDashboard, Limit: 100},
+		{Name: "Deploys", Type: AnnotationsByTags, Tags: []string{"deploy"}, Limit: 100},
+	}
+	assertAnnotationQueries(t, sd.AnnotationQueries, expected)
+}
+
+func assertAnnotationQueries(t *testing.T, queries []AnnotationQuery, expected []AnnotationQuery) {
+	t.Helper()
+	if len(queries) != len(expected) {
+		t.Fatalf("AnnotationQueries = %+v; want %+v", queries, expected)
+	}
+	for i := range expected {
+		q, e := queries[i], expected[i]
+		if q.Name != e.Name || q.Type != e.Type || q.MatchAny != e.MatchAny || q.Limit != e.Limit || len(q.Tags) != len(e.Tags) {
+			t.Errorf("AnnotationQueries[%d] = %+v; want %+v", i, q, e)
+		}
+	}
+}
+
+func TestAddAnnotations(t *testing.T) {
+	sd := &StructuredDashboard{Rows: []*Row{{Panels: []Panel{{ID: 2, Title: "CPU"}}}}}
+	sd.AddAnnotations([]Annotation{
+		{ID: 3, Time: 1706193792000, Text: "second"},
+		{ID: 1, Time: 1706190192000, TimeEnd: 1706190252000, PanelID: 2, Tags: []string{"deploy", "v2"}, Text: "first"},
+	})
+	sd.AddAnnotations([]Annotation{
+		{ID: 1, Time: 1706190192000, Text: "first"},
+		{ID: 4, Time: 1706197392000, PanelID: 7, Text: "third"},
+	})
+
+	if len(sd.Annotations) != 3 {
+		t.Fatalf("Annotations count = %d; want 3", len(sd.Annotations))
+	}
+	first, second, third := sd.Annotations[0], sd.Annotations[1], sd.Annotations[2]
+	if first.Text != "first" || second.Text != "second" || third.Text != "third" {
+		t.Errorf("Annotations are not sorted by time: %+v", sd.Annotations)
+	}
+	if first.GetTime() != "2024-01-25 13:43:12 UTC" || first.GetTimeEnd() != "2024-01-25 13:44:12 UTC" || second.GetTimeEnd() != "" {
+		t.Errorf("Times = %s, %s, %s; want 2024-01-25 13:43:12 UTC, 2024-01-25 13:44:12 UTC and empty", first.GetTime(), first.GetTimeEnd(), second.GetTimeEnd())
+	}
+
+	sd.SetLocation(time.FixedZone("MSK", 3*60*60))
+	sd.AddAnnotations([]Annotation{{ID: 5, Time: time.Date(2024, 1, 25, 14, 0, 0, 0, time.UTC).UnixMilli(), Text: "fourth"}})
+	if first, fourth := sd.Annotations[0], sd.Annotations[1]; first.GetTime() != "2024-01-25 16:43:12 MSK" || fourth.GetTime() != "2024-01-25 17:00:00 MSK" {
+		t.Errorf("Times = %s, %s; want 2024-01-25 16:43:12 MSK, 2024-01-25 17:00:00 MSK in the timezone of the report", first.GetTime(), fourth.GetTime())
+	}
+	if first.GetTags() != "deploy, v2" {
+		t.Errorf("GetTags() = %q; want %q", first.GetTags(), "deploy, v2")
+	}
+	if first.Panel != "CPU" || second.Panel != "" || third.Panel != "Panel 7" {
+		t.Errorf("Panels = %q, %q, %q; want CPU, empty, Panel 7", first.Panel, second.Panel, third.Panel)
+	}
+}
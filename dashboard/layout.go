@@ -0,0 +1,171 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package dashboard
+
+import (
+	"fmt"
+	"sort"
+)
+
+// viewPanelHeight is the height of the panel in view mode, the panel fills the screen of 16:9 proportions
+const viewPanelHeight = 13
+
+// Line is a horizontal band of the dashboard grid. Panels are placed on the same line when their vertical extents
+// overlap, so a tall panel and the panels stacked next to it are laid out together.
+// X and W of the line always cover the whole grid width, Y and H are the top and the height of the band.
+type Line struct {
+	GridPos
+	// GapBefore is the height of the empty space between the previous line of the row and this one in grid units
+	GapBefore int
+	// Panels point to the panels of the row, so changes of the panels are seen in lines
+	Panels []*Panel
+}
+
+// SortPanels orders panels as they are shown in Grafana: from top to bottom and from left to right
+func SortPanels(panels []Panel) {
+	sort.SliceStable(panels, func(i, j int) bool {
+		if panels[i].Y != panels[j].Y {
+			return panels[i].Y < panels[j].Y
+		}
+		return panels[i].X < panels[j].X
+	})
+}
+
+// BuildLines splits panels to visual lines, lines point to the panels of the slice. Panels must be sorted by SortPanels.
+func BuildLines(panels []Panel) []*Line {
+	var lines []*Line
+	var current *Line
+	for i := range panels {
+		panel := &panels[i]
+		if current != nil && panel.Y < current.Y+current.H {
+			current.Panels = append(current.Panels, panel)
+			if bottom := panel.Y + panel.H; bottom > current.Y+current.H {
+				current.H = bottom - current.Y
+			}
+			continue
+		}
+		line := &Line{
+			GridPos: GridPos{X: 0, Y: panel.Y, W: grafanaResolutionWidth, H: panel.H},
+			Panels:  []*Panel{panel},
+		}
+		if current != nil {
+			line.GapBefore = panel.Y - (current.Y + current.H)
+		}
+		lines = append(lines, line)
+		current = line
+	}
+	return lines
+}
+
+// GetOffsetY returns the distance between the top of the line and the top of the panel in grid units
+func (l *Line) GetOffsetY(p *Panel) int {
+	return p.Y - l.Y
+}
+
+// GetBottomY returns the distance between the bottom of the line and the bottom of the panel in grid units.
+// It is useful for TeX picture environment where the origin is in the lower left corner.
+func (l *Line) GetBottomY(p *Panel) int {
+	return l.H - l.GetOffsetY(p) - p.H
+}
+
+// GetPxHeight returns the height of the line in pixels
+func (l *Line) GetPxHeight(screenResolutionWidth int) int {
+	return l.H * (screenResolutionWidth / grafanaResolutionWidth)
+}
+
+// GetPxX returns the absolute horizontal position of the panel on the dashboard in pixels
+func (p *Panel) GetPxX(screenResolutionWidth int) int {
+	return p.X * (screenResolutionWidth / grafanaResolutionWidth)
+}
+
+// GetPxY returns the absolute vertical position of the panel on the dashboard in pixels
+func (p *Panel) GetPxY(screenResolutionWidth int) int {
+	return p.Y * (screenResolutionWidth / grafanaResolutionWidth)
+}
+
+// GetRelativeX returns the horizontal position of the panel as a share of the dashboard width
+func (p *Panel) GetRelativeX() float64 {
+	return roundFloat(float64(p.X)/float64(grafanaResolutionWidth), 3)
+}
+
+// GetPanel returns the panel of the dashboard by ID
+func (sd *StructuredDashboard) GetPanel(id int) (Panel, bool) {
+	for _, row := range sd.Rows {
+		for _, panel := range row.Panels {
+			if panel.ID == id {
+				return panel, true
+			}
+		}
+	}
+	return Panel{}, false
+}
+
+// ViewPanel leaves only the panel in the dashboard and stretches it to the whole width like Grafana panel view mode
+func (sd *StructuredDashboard) ViewPanel(id int) error {
+	panel, ok := sd.GetPanel(id)
+	if !ok {
+		return fmt.Errorf("panel %d is not found on the dashboard %s", id, sd.UID)
+	}
+	panel.GridPos = GridPos{X: 0, Y: 0, W: grafanaResolutionWidth, H: viewPanelHeight}
+	view := &Row{GridPos: panel.GridPos, Panels: []Panel{panel}}
+	view.arrange()
+	sd.Rows = []*Row{view}
+	return nil
+}
+
+// RemovePanels removes the panels from the dashboard, rows left without panels are removed too.
+// Removed panels do not leave vertical gaps between lines of the row.
+func (sd *StructuredDashboard) RemovePanels(ids ...int) {
+	removed := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		removed[id] = true
+	}
+	sd.filterPanels(func(_ *Row, panel Panel) bool {
+		return !removed[panel.ID]
+	})
+}
+
+// filterPanels leaves the panels for which keep returns true, rows left without panels are removed.
+// Rows that lost panels are arranged again without vertical gaps between lines.
+func (sd *StructuredDashboard) filterPanels(keep func(row *Row, panel Panel) bool) {
+	var rows []*Row
+	for _, row := range sd.Rows {
+		var panels []Panel
+		for _, panel := range row.Panels {
+			if keep(row, panel) {
+				panels = append(panels, panel)
+			}
+		}
+		if len(panels) == 0 {
+			continue
+		}
+		if len(panels) < len(row.Panels) {
+			row.Panels = panels
+			row.arrange()
+			for _, line := range row.Lines {
+				line.GapBefore = 0
+			}
+		}
+		rows = append(rows, row)
+	}
+	sd.Rows = rows
+}
+
+// arrange sorts panels of the row and splits them to lines
+func (r *Row) arrange() {
+	SortPanels(r.Panels)
+	r.Lines = BuildLines(r.Panels)
+}
@@ -0,0 +1,169 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package dashboard
+
+import (
+	"testing"
+)
+
+func panelIDs(panels []Panel) []int {
+	ids := make([]int, 0, len(panels))
+	for _, p := range panels {
+		ids = append(ids, p.ID)
+	}
+	return ids
+}
+
+func TestSortPanels(t *testing.T) {
+	panels := []Panel{
+		{ID: 3, GridPos: GridPos{X: 0, Y: 8}},
+		{ID: 2, GridPos: GridPos{X: 12, Y: 0}},
+		{ID: 1, GridPos: GridPos{X: 0, Y: 0}},
+	}
+	SortPanels(panels)
+	ids := panelIDs(panels)
+	if ids[0] != 1 || ids[1] != 2 || ids[2] != 3 {
+		t.Errorf("SortPanels() order = %v; want [1 2 3]", ids)
+	}
+}
+
+func TestBuildLinesVerticalStacking(t *testing.T) {
+	// tall panel on the left and two short panels stacked on the right
+	panels := []Panel{
+		{ID: 1, GridPos: GridPos{X: 0, Y: 0, W: 12, H: 16}},
+		{ID: 2, GridPos: GridPos{X: 12, Y: 0, W: 12, H: 8}},
+		{ID: 3, GridPos: GridPos{X: 12, Y: 8, W: 12, H: 8}},
+		{ID: 4, GridPos: GridPos{X: 0, Y: 16, W: 24, H: 6}},
+	}
+	lines := BuildLines(panels)
+	if len(lines) != 2 {
+		t.Fatalf("BuildLines() lines = %d; want 2", len(lines))
+	}
+	if len(lines[0].Panels) != 3 || lines[0].H != 16 {
+		t.Errorf("First line has %d panels and height %d; want 3 panels and height 16", len(lines[0].Panels), lines[0].H)
+	}
+	if got := lines[0].GetBottomY(&panels[1]); got != 8 {
+		t.Errorf("GetBottomY() of the upper short panel = %d; want 8", got)
+	}
+	if got := lines[0].GetBottomY(&panels[2]); got != 0 {
+		t.Errorf("GetBottomY() of the lower short panel = %d; want 0", got)
+	}
+	if got := lines[0].GetOffsetY(&panels[2]); got != 8 {
+		t.Errorf("GetOffsetY() of the lower short panel = %d; want 8", got)
+	}
+}
+
+func TestBuildLinesSharesPanels(t *testing.T) {
+	panels := []Panel{{ID: 1, GridPos: GridPos{W: 12, H: 8}}, {ID: 2, GridPos: GridPos{X: 12, W: 12, H: 8}}}
+	lines := BuildLines(panels)
+	panels[1].Firing = true
+	if !lines[0].Panels[1].Firing {
+		t.Errorf("Line does not see changes of the panel; want lines to point to the panels")
+	}
+}
+
+func TestBuildLinesGaps(t *testing.T) {
+	panels := []Panel{
+		{ID: 1, GridPos: GridPos{X: 6, Y: 0, W: 6, H: 4}},
+		{ID: 2, GridPos: GridPos{X: 0, Y: 4, W: 8, H: 4}},
+		{ID: 3, GridPos: GridPos{X: 0, Y: 10, W: 8, H: 4}},
+	}
+	lines := BuildLines(panels)
+	if len(lines) != 3 {
+		t.Fatalf("BuildLines() lines = %d; want 3", len(lines))
+	}
+	if lines[1].GapBefore != 0 || lines[2].GapBefore != 2 {
+		t.Errorf("GapBefore = %d, %d; want 0, 2", lines[1].GapBefore, lines[2].GapBefore)
+	}
+	if lines[0].Panels[0].GetRelativeX() != 0.25 {
+		t.Errorf("GetRelativeX() = %f; want 0.25", lines[0].Panels[0].GetRelativeX())
+	}
+}
+
+func TestGetStructuredDashboardUsesPositions(t *testing.T) {
+	// panels are listed in JSON not in the order of their positions
+	entity := &Entity{
+		Dashboard: Dashboard{
+			Panels: []Panel{
+				{ID: 5, Type: "graph", GridPos: GridPos{H: 8, W: 12, X: 12, Y: 9}},
+				{ID: 1, Type: "row", Title: "Second", GridPos: GridPos{H: 1, W: 24, X: 0, Y: 8}},
+				{ID: 2, Type: "graph", GridPos: GridPos{H: 8, W: 6, X: 0, Y: 0}},
+				{ID: 3, Type: "graph", GridPos: GridPos{H: 8, W: 6, X: 6, Y: 0}},
+				{ID: 4, Type: "graph", GridPos: GridPos{H: 8, W: 12, X: 0, Y: 9}},
+			},
+		},
+	}
+	sd, err := entity.GetStructuredDashboard(RowsExpanded)
+	if err != nil {
+		t.Fatalf("GetStructuredDashboard failed: %v", err)
+	}
+	if len(sd.Rows) != 2 {
+		t.Fatalf("Rows count = %d; want 2", len(sd.Rows))
+	}
+	if ids := panelIDs(sd.Rows[0].Panels); len(ids) != 2 || ids[0] != 2 || ids[1] != 3 {
+		t.Errorf("Panels of the first row = %v; want [2 3]", ids)
+	}
+	if sd.Rows[1].Title != "Second" || len(sd.Rows[1].Lines) != 1 {
+		t.Errorf("Second row = %q with %d lines; want %q with 1 line", sd.Rows[1].Title, len(sd.Rows[1].Lines), "Second")
+	}
+	if ids := panelIDs(sd.Rows[1].Panels); ids[0] != 4 || ids[1] != 5 {
+		t.Errorf("Panels of the second row = %v; want [4 5]", ids)
+	}
+}
+
+func TestViewPanel(t *testing.T) {
+	sd := &StructuredDashboard{UID: "uid", Rows: []*Row{
+		{Panels: []Panel{{ID: 1, GridPos: GridPos{X: 0, Y: 0, W: 12, H: 8}}}},
+		{Title: "Row", Panels: []Panel{{ID: 2, GridPos: GridPos{X: 12, Y: 9, W: 6, H: 4}}}},
+	}}
+	if err := sd.ViewPanel(2); err != nil {
+		t.Fatalf("ViewPanel failed: %v", err)
+	}
+	if len(sd.Rows) != 1 || len(sd.Rows[0].Lines) != 1 || len(sd.Rows[0].Panels) != 1 {
+		t.Fatalf("ViewPanel() rows = %+v; want one row with one panel", sd.Rows)
+	}
+	panel := sd.Rows[0].Panels[0]
+	if panel.ID != 2 || panel.GridPos != (GridPos{X: 0, Y: 0, W: 24, H: viewPanelHeight}) {
+		t.Errorf("ViewPanel() panel = %+v; want panel 2 of the full width", panel)
+	}
+	if err := sd.ViewPanel(3); err == nil {
+		t.Error("Expected error for the panel not on the dashboard, got nil")
+	}
+}
+
+func TestRemovePanels(t *testing.T) {
+	sd := &StructuredDashboard{UID: "uid", Rows: []*Row{
+		{Panels: []Panel{
+			{ID: 1, GridPos: GridPos{X: 0, Y: 0, W: 12, H: 8}},
+			{ID: 2, GridPos: GridPos{X: 0, Y: 8, W: 12, H: 8}},
+			{ID: 3, GridPos: GridPos{X: 0, Y: 16, W: 12, H: 8}},
+		}},
+		{Title: "Row", Panels: []Panel{{ID: 4, GridPos: GridPos{X: 0, Y: 25, W: 24, H: 8}}}},
+	}}
+	for _, row := range sd.Rows {
+		row.arrange()
+	}
+	sd.RemovePanels(2, 4)
+	if len(sd.Rows) != 1 {
+		t.Fatalf("RemovePanels() rows = %d; want 1, the row without panels is removed", len(sd.Rows))
+	}
+	if ids := panelIDs(sd.Rows[0].Panels); len(ids) != 2 || ids[0] != 1 || ids[1] != 3 {
+		t.Errorf("RemovePanels() panels = %v; want [1 3]", ids)
+	}
+	if len(sd.Rows[0].Lines) != 2 || sd.Rows[0].Lines[1].GapBefore != 0 {
+		t.Errorf("RemovePanels() lines = %+v; want 2 lines without gaps", sd.Rows[0].Lines)
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"math"
 	"strings"
+	"time"
 )
 
 const (
@@ -27,6 +28,18 @@ const (
 	panelsLimit            = 1000
 )
 
+// RowsMode defines which rows of the dashboard are included to the report
+type RowsMode string
+
+const (
+	// RowsExpanded includes only rows expanded on the dashboard
+	RowsExpanded RowsMode = "expanded"
+	// RowsCollapsed includes only rows collapsed on the dashboard
+	RowsCollapsed RowsMode = "collapsed"
+	// RowsAll includes all rows in the dashboard order
+	RowsAll RowsMode = "all"
+)
+
 type Entity struct {
 	Dashboard `json:"dashboard"`
 	Meta      `json:"meta"`
@@ -36,17 +49,66 @@ type Meta struct {
 	Slug string `json:"slug"`
 }
 type Dashboard struct {
-	Title  string  `json:"title"`
-	Panels []Panel `json:"panels"`
-	UID    string  `json:"uid"`
+	Title       string        `json:"title"`
+	Panels      []Panel       `json:"panels"`
+	UID         string        `json:"uid"`
+	Annotations AnnotationsV1 `json:"annotations"`
+	Time        TimeRangeV1   `json:"time"`
+	Timezone    string        `json:"timezone"`
+	WeekStart   string        `json:"weekStart"`
+	TimePicker  TimePickerV1  `json:"timepicker"`
+	// FiscalYearStartMonth is the first month of the fiscal year from 0 (January) to 11
+	FiscalYearStartMonth int `json:"fiscalYearStartMonth"`
+}
+
+// TimeRangeV1 is the default time range saved in the dashboard
+type TimeRangeV1 struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// TimePickerV1 contains settings of the dashboard time picker used by the reporter
+type TimePickerV1 struct {
+	NowDelay string `json:"nowDelay"`
+}
+
+// TimeSettings are the time range and the time settings the dashboard is opened with in Grafana
+type TimeSettings struct {
+	From     string
+	To       string
+	Timezone string
+	// WeekStart is the first day of the week: monday, saturday, sunday or empty for the browser default
+	WeekStart string
+	// NowDelay excludes recent data that may be incomplete, for example 5m
+	NowDelay string
+	// FiscalYearStartMonth is the first month of the fiscal year for now/fy from 0 (January) to 11
+	FiscalYearStartMonth int
 }
 type Panel struct {
 	ID        int  `json:"id"`
 	Collapsed bool `json:"collapsed"`
 	GridPos   `json:"gridPos"`
-	Title     string  `json:"title"`
-	Type      string  `json:"type"`
-	Panels    []Panel `json:"panels"`
+	Title     string       `json:"title"`
+	Type      string       `json:"type"`
+	Panels    []Panel      `json:"panels"`
+	Options   PanelOptions `json:"options"`
+	// Firing is true if alerts linked to the panel fired within the report time range
+	Firing bool `json:"-"`
+	// Content and Mode of text panels created in old Grafana versions
+	Content string `json:"content"`
+	Mode    string `json:"mode"`
+	// TimeFrom (relative time, for example 7d) and TimeShift (for example 1w) override the time range of the dashboard
+	TimeFrom  string `json:"timeFrom"`
+	TimeShift string `json:"timeShift"`
+	// Time is the time range of the panel if it overrides the time range of the dashboard, nil otherwise
+	Time *PanelTime `json:"-"`
+}
+
+// PanelOptions contains options of the panel visualization used by the reporter
+type PanelOptions struct {
+	// Content and Mode of text panels
+	Content string `json:"content"`
+	Mode    string `json:"mode"`
 }
 type GridPos struct {
 	H int `json:"h"`
@@ -62,12 +124,40 @@ type StructuredDashboard struct {
 	Rows      []*Row
 	Panels    []Panel
 	RequestID string
+	// Selection of rows and panels if the report is partial
+	Selection *Selection
+	// AnnotationQueries are definitions of annotations stored in Grafana
+	AnnotationQueries []AnnotationQuery
+	// Annotations in the report time range sorted by time
+	Annotations []Annotation
+	// Alerts is the summary of alert rules linked to the dashboard, it is nil if the summary is not requested
+	Alerts *AlertSummary
+	// Version of the dashboard if the report is rendered from a historical version
+	Version *DashboardVersion
+	// Time settings saved in the dashboard
+	Time TimeSettings
+	// Location is the timezone of the report, times of annotations, alerts and the version are printed in it
+	Location *time.Location
+	// RenderIssues are panels that could not be rendered, they are replaced with placeholders, skipped or kept blank
+	RenderIssues []RenderIssue
+}
+
+// RenderIssue is a panel that could not be rendered and the reason of the failure
+type RenderIssue struct {
+	PanelID int
+	Title   string
+	Reason  string
 }
 
 type Row struct {
 	Title string
 	GridPos
+	// Collapsed is true if the row is collapsed on the dashboard
+	Collapsed bool
+	// Panels of the row sorted by position
 	Panels []Panel
+	// Lines are visual lines of the row panels
+	Lines []*Line
 }
 type PanelStructured struct {
 	ID    string
@@ -76,29 +166,43 @@ type PanelStructured struct {
 	Type string
 }
 
-func (de *Entity) GetStructuredDashboard(renderCollapsed bool) (*StructuredDashboard, error) {
+func (de *Entity) GetStructuredDashboard(rowsMode RowsMode) (*StructuredDashboard, error) {
 	var rows []*Row
+	// current is the row that panels without own row header are added to
+	var current *Row
+	// panels above the first row header are shown as expanded
+	skipped := !rowsMode.Includes(false)
 	panelsCount := 0
-	for _, rowOrPanel := range de.Panels {
+	panels := make([]Panel, len(de.Panels))
+	copy(panels, de.Panels)
+	SortPanels(panels)
+	for _, rowOrPanel := range panels {
 		switch {
 		case strings.EqualFold(rowOrPanel.Type, "row"):
-			if rowOrPanel.Collapsed == renderCollapsed {
-				rows = append(rows, &Row{
-					Title:   rowOrPanel.Title,
-					GridPos: rowOrPanel.GridPos,
-					Panels:  rowOrPanel.Panels,
-				})
+			current = nil
+			skipped = !rowsMode.Includes(rowOrPanel.Collapsed)
+			if !skipped {
+				current = &Row{
+					Title:     rowOrPanel.Title,
+					GridPos:   rowOrPanel.GridPos,
+					Collapsed: rowOrPanel.Collapsed,
+					Panels:    append([]Panel{}, rowOrPanel.Panels...),
+				}
+				rows = append(rows, current)
 				panelsCount += len(rowOrPanel.Panels)
 			}
-		case !strings.EqualFold(rowOrPanel.Type, "row") && (len(rows) == 0 || !rowOrPanel.IsAddedToPreviousRow(*rows[len(rows)-1])):
-			rows = append(rows, &Row{
+		case skipped:
+			continue
+		case current == nil:
+			current = &Row{
 				Title:   "",
 				GridPos: rowOrPanel.GridPos,
 				Panels:  []Panel{rowOrPanel},
-			})
+			}
+			rows = append(rows, current)
 			panelsCount++
 		default:
-			rows[len(rows)-1].Panels = append(rows[len(rows)-1].Panels, rowOrPanel)
+			current.Panels = append(current.Panels, rowOrPanel)
 			panelsCount++
 		}
 	}
@@ -106,16 +210,89 @@ func (de *Entity) GetStructuredDashboard(renderCollapsed bool) (*StructuredDashb
 	if len(rows) > rowsLimit || panelsCount > panelsLimit {
 		return nil, fmt.Errorf("grafana dashboard contains too many rows/panels: rows=%d (limit=%d); panels=%d (limit=%d)", len(rows), rowsLimit, panelsCount, panelsLimit)
 	}
+	for _, row := range rows {
+		row.arrange()
+	}
 
 	dsh := &StructuredDashboard{
-		UID:   de.UID,
-		Title: de.Title,
-		Slug:  de.Slug,
-		Rows:  rows,
+		UID:               de.UID,
+		Title:             de.Title,
+		Slug:              de.Slug,
+		Rows:              rows,
+		AnnotationQueries: de.Annotations.GetQueries(),
+		Time: TimeSettings{
+			From:                 de.Time.From,
+			To:                   de.Time.To,
+			Timezone:             de.Timezone,
+			WeekStart:            de.WeekStart,
+			NowDelay:             de.TimePicker.NowDelay,
+			FiscalYearStartMonth: de.FiscalYearStartMonth,
+		},
 	}
 	return dsh, nil
 }
 
+// ParseRowsMode converts string to rows mode. Empty string means RowsExpanded.
+func ParseRowsMode(mode string) (RowsMode, error) {
+	switch RowsMode(strings.ToLower(mode)) {
+	case "", RowsExpanded:
+		return RowsExpanded, nil
+	case RowsCollapsed:
+		return RowsCollapsed, nil
+	case RowsAll:
+		return RowsAll, nil
+	default:
+		return "", fmt.Errorf("rows mode %q is not valid, it must be one of: %s, %s, %s", mode, RowsExpanded, RowsCollapsed, RowsAll)
+	}
+}
+
+// Includes checks if the row with the collapsed state is included to the report
+func (m RowsMode) Includes(collapsed bool) bool {
+	switch m {
+	case RowsAll:
+		return true
+	case RowsCollapsed:
+		return collapsed
+	default:
+		return !collapsed
+	}
+}
+
+// IsText checks if the panel is a text panel that is rendered natively instead of a screenshot
+func (p *Panel) IsText() bool {
+	return strings.EqualFold(p.Type, "text")
+}
+
+// HasRelativeTime checks if the panel overrides the time range of the dashboard with relative time. Such panel shows
+// the same time range in the comparison, its baseline image is not rendered.
+func (p *Panel) HasRelativeTime() bool {
+	return p.TimeFrom != ""
+}
+
+// GetTextContent returns the content and the mode of the text panel
+func (p *Panel) GetTextContent() (string, string) {
+	if p.Options.Content != "" || p.Content == "" {
+		return p.Options.Content, p.Options.Mode
+	}
+	return p.Content, p.Mode
+}
+
+// getPanelTitle returns the title of the dashboard panel, the ID is used for panels without title or not included
+// to the report. It returns empty string for zero ID.
+func (sd *StructuredDashboard) getPanelTitle(id int) string {
+	if id == 0 {
+		return ""
+	}
+	for _, row := range sd.Rows {
+		for _, panel := range row.Panels {
+			if panel.ID == id && panel.Title != "" {
+				return panel.Title
+			}
+		}
+	}
+	return fmt.Sprintf("Panel %d", id)
+}
+
 func (p *Panel) IsTheFirst() bool {
 	return p.X == 0
 }
@@ -136,6 +313,9 @@ func (p *Panel) GetRelativeWidth(screenResolutionWidth int) float64 {
 	return roundFloat(float64(p.GetPxWidth(screenResolutionWidth))/float64(screenResolutionWidth), 3) - 0.005
 }
 
+// IsAddedToPreviousRow checks if the panel fits the width of the row.
+//
+// Deprecated: panels are laid out by their positions with BuildLines.
 func (p *Panel) IsAddedToPreviousRow(row Row) bool {
 	rowWidth := p.W
 	for _, panel := range row.Panels {
@@ -138,7 +138,7 @@ func TestEntityGetStructuredDashboard(t *testing.T) {
 		Meta: Meta{Slug: "test-slug"},
 	}
 
-	sd, err := entity.GetStructuredDashboard(false)
+	sd, err := entity.GetStructuredDashboard(RowsExpanded)
 	if err != nil {
 		t.Errorf("GetStructuredDashboard failed: %v", err)
 	}
@@ -149,3 +149,355 @@ func TestEntityGetStructuredDashboard(t *testing.T) {
 		t.Errorf("Rows count = %d; want 1", len(sd.Rows))
 	}
 }
+
+const dashboardV1Fixture = `{
+  "dashboard": {
+    "uid": "v1-uid",
+    "title": "Schema v1",
+    "schemaVersion": 39,
+    "panels": [
+      {"id": 1, "type": "timeseries", "title": "CPU", "gridPos": {"h": 8, "w": 12, "x": 0, "y": 0}},
+      {"id": 2, "type": "timeseries", "title": "Memory", "gridPos": {"h": 8, "w": 12, "x": 12, "y": 0}}
+    ]
+  },
+  "meta": {"slug": "schema-v1"}
+}`
+
+const dashboardV2ResourceFixture = `{
+  "apiVersion": "dashboard.grafana.app/v2beta1",
+  "kind": "Dashboard",
+  "metadata": {"name": "v2-uid"},
+  "spec": {
+    "title": "Schema v2 Tabs",
+    "elements": {
+      "panel-1": {"kind": "Panel", "spec": {"id": 1, "title": "CPU", "vizConfig": {"kind": "VizConfig", "group": "timeseries"}}},
+      "panel-2": {"kind": "Panel", "spec": {"id": 2, "title": "Memory", "vizConfig": {"kind": "VizConfig", "group": "timeseries"}}},
+      "panel-3": {"kind": "Panel", "spec": {"id": 3, "title": "Errors", "vizConfig": {"kind": "VizConfig", "group": "stat"}}},
+      "panel-4": {"kind": "Panel", "spec": {"id": 4, "title": "Hidden", "vizConfig": {"kind": "VizConfig", "group": "table"}}}
+    },
+    "layout": {
+      "kind": "TabsLayout",
+      "spec": {
+        "tabs": [
+          {"kind": "TabsLayoutTab", "spec": {"title": "Resources", "layout": {"kind": "GridLayout", "spec": {"items": [
+            {"kind": "GridLayoutItem", "spec": {"x": 12, "y": 0, "width": 12, "height": 8, "element": {"kind": "ElementReference", "name": "panel-2"}}},
+            {"kind": "GridLayoutItem", "spec": {"x": 0, "y": 0, "width": 12, "height": 8, "element": {"kind": "ElementReference", "name": "panel-1"}}}
+          ]}}}},
+          {"kind": "TabsLayoutTab", "spec": {"title": "Errors", "layout": {"kind": "RowsLayout", "spec": {"rows": [
+            {"kind": "RowsLayoutRow", "spec": {"title": "Rates", "collapse": false, "layout": {"kind": "GridLayout", "spec": {"items": [
+              {"kind": "GridLayoutItem", "spec": {"x": 0, "y": 0, "width": 24, "height": 6, "element": {"kind": "ElementReference", "name": "panel-3"}}}
+            ]}}}},
+            {"kind": "RowsLayoutRow", "spec": {"title": "Details", "collapse": true, "layout": {"kind": "GridLayout", "spec": {"items": [
+              {"kind": "GridLayoutItem", "spec": {"x": 0, "y": 0, "width": 24, "height": 6, "element": {"kind": "ElementReference", "name": "panel-4"}}}
+            ]}}}}
+          ]}}}}
+        ]
+      }
+    }
+  }
+}`
+
+const dashboardV2WrappedAutoGridFixture = `{
+  "dashboard": {
+    "uid": "v2-auto",
+    "title": "Schema v2 Auto Grid",
+    "elements": {
+      "a": {"kind": "Panel", "spec": {"id": 10, "title": "A", "vizConfig": {"kind": "timeseries"}}},
+      "b": {"kind": "Panel", "spec": {"id": 11, "title": "B", "vizConfig": {"kind": "timeseries"}}},
+      "c": {"kind": "Panel", "spec": {"id": 12, "title": "C", "vizConfig": {"kind": "gauge"}}}
+    },
+    "layout": {
+      "kind": "AutoGridLayout",
+      "spec": {
+        "maxColumnCount": 2,
+        "rowHeightMode": "standard",
+        "items": [
+          {"kind": "AutoGridLayoutItem", "spec": {"element": {"kind": "ElementReference", "name": "a"}}},
+          {"kind": "AutoGridLayoutItem", "spec": {"element": {"kind": "ElementReference", "name": "b"}}},
+          {"kind": "AutoGridLayoutItem", "spec": {"element": {"kind": "ElementReference", "name": "c"}}}
+        ]
+      }
+    }
+  },
+  "meta": {"slug": "schema-v2-auto-grid"}
+}`
+
+const dashboardV2GridRowsFixture = `{
+  "apiVersion": "dashboard.grafana.app/v2alpha1",
+  "kind": "Dashboard",
+  "metadata": {"name": "v2-rows"},
+  "spec": {
+    "title": "Schema v2alpha1 Grid Rows",
+    "elements": {
+      "panel-1": {"kind": "Panel", "spec": {"id": 1, "title": "Overview", "vizConfig": {"kind": "stat"}}},
+      "panel-2": {"kind": "Panel", "spec": {"id": 2, "title": "CPU", "vizConfig": {"kind": "timeseries"}}},
+      "panel-3": {"kind": "Panel", "spec": {"id": 3, "title": "Logs", "vizConfig": {"kind": "logs"}}}
+    },
+    "layout": {
+      "kind": "GridLayout",
+      "spec": {
+        "items": [
+          {"kind": "GridLayoutItem", "spec": {"x": 0, "y": 0, "width": 24, "height": 4, "element": {"kind": "ElementReference", "name": "panel-1"}}},
+          {"kind": "GridLayoutRow", "spec": {"y": 4, "title": "Resources", "collapsed": false, "elements": [
+            {"kind": "GridLayoutItem", "spec": {"x": 0, "y": 5, "width": 12, "height": 8, "element": {"kind": "ElementReference", "name": "panel-2"}}}
+          ]}},
+          {"kind": "GridLayoutRow", "spec": {"y": 13, "title": "Logs", "collapsed": true, "elements": [
+            {"kind": "GridLayoutItem", "spec": {"x": 0, "y": 14, "width": 24, "height": 8, "element": {"kind": "ElementReference", "name": "panel-3"}}}
+          ]}}
+        ]
+      }
+    }
+  }
+}`
+
+func TestIsSchemaV2(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected bool
+	}{
+		{"schema v1", dashboardV1Fixture, false},
+		{"schema v2 resource", dashboardV2ResourceFixture, true},
+		{"schema v2 wrapped", dashboardV2WrappedAutoGridFixture, true},
+		{"schema v2alpha1 grid rows", dashboardV2GridRowsFixture, true},
+		{"invalid json", "{", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := IsSchemaV2([]byte(tt.body))
+			if result != tt.expected {
+				t.Errorf("IsSchemaV2() = %v; want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseStructuredDashboardV1(t *testing.T) {
+	sd, err := ParseStructuredDashboard([]byte(dashboardV1Fixture), RowsExpanded, nil)
+	if err != nil {
+		t.Fatalf("ParseStructuredDashboard failed: %v", err)
+	}
+	if sd.UID != "v1-uid" || sd.Slug != "schema-v1" {
+		t.Errorf("UID, Slug = %s, %s; want v1-uid, schema-v1", sd.UID, sd.Slug)
+	}
+	if len(sd.Rows) != 1 || len(sd.Rows[0].Panels) != 2 {
+		t.Errorf("Rows = %d; want 1 row with 2 panels", len(sd.Rows))
+	}
+}
+
+func TestParseStructuredDashboardV2Tabs(t *testing.T) {
+	sd, err := ParseStructuredDashboard([]byte(dashboardV2ResourceFixture), RowsExpanded, nil)
+	if err != nil {
+		t.Fatalf("ParseStructuredDashboard failed: %v", err)
+	}
+	if sd.UID != "v2-uid" || sd.Title != "Schema v2 Tabs" || sd.Slug != "schema-v2-tabs" {
+		t.Errorf("UID, Title, Slug = %s, %s, %s; want v2-uid, Schema v2 Tabs, schema-v2-tabs", sd.UID, sd.Title, sd.Slug)
+	}
+	if len(sd.Rows) != 2 {
+		t.Fatalf("Rows count = %d; want 2", len(sd.Rows))
+	}
+	if sd.Rows[0].Title != "Resources" || sd.Rows[1].Title != "Errors / Rates" {
+		t.Errorf("Row titles = %q, %q; want %q, %q", sd.Rows[0].Title, sd.Rows[1].Title, "Resources", "Errors / Rates")
+	}
+	if len(sd.Rows[0].Panels) != 2 || sd.Rows[0].Panels[0].ID != 1 || sd.Rows[0].Panels[1].ID != 2 {
+		t.Errorf("Panels of the first row are not ordered by position: %+v", sd.Rows[0].Panels)
+	}
+	if sd.Rows[1].Panels[0].Type != "stat" {
+		t.Errorf("Panel type = %s; want stat", sd.Rows[1].Panels[0].Type)
+	}
+
+	sd, err = ParseStructuredDashboard([]byte(dashboardV2ResourceFixture), RowsAll, nil)
+	if err != nil {
+		t.Fatalf("ParseStructuredDashboard failed: %v", err)
+	}
+	if len(sd.Rows) != 3 || sd.Rows[2].Title != "Errors / Details" {
+		t.Errorf("Rows count = %d; want 3 rows including collapsed one", len(sd.Rows))
+	}
+}
+
+func TestParseStructuredDashboardV2AutoGrid(t *testing.T) {
+	sd, err := ParseStructuredDashboard([]byte(dashboardV2WrappedAutoGridFixture), RowsExpanded, nil)
+	if err != nil {
+		t.Fatalf("ParseStructuredDashboard failed: %v", err)
+	}
+	if sd.UID != "v2-auto" || sd.Slug != "schema-v2-auto-grid" {
+		t.Errorf("UID, Slug = %s, %s; want v2-auto, schema-v2-auto-grid", sd.UID, sd.Slug)
+	}
+	if len(sd.Rows) != 1 || len(sd.Rows[0].Lines) != 2 {
+		t.Fatalf("Rows count = %d; want 1 row with 2 lines", len(sd.Rows))
+	}
+	expected := []GridPos{{X: 0, Y: 0, W: 12, H: 9}, {X: 12, Y: 0, W: 12, H: 9}}
+	for i, panel := range sd.Rows[0].Lines[0].Panels {
+		if panel.GridPos != expected[i] {
+			t.Errorf("GridPos of panel %d = %+v; want %+v", panel.ID, panel.GridPos, expected[i])
+		}
+	}
+	if c := sd.Rows[0].Lines[1].Panels[0]; c.ID != 12 || c.Type != "gauge" || c.GridPos != (GridPos{X: 0, Y: 9, W: 12, H: 9}) {
+		t.Errorf("Third panel = %+v; want id 12 of type gauge on the second line", c)
+	}
+}
+
+func TestParseStructuredDashboardV2GridRows(t *testing.T) {
+	sd, err := ParseStructuredDashboard([]byte(dashboardV2GridRowsFixture), RowsAll, nil)
+	if err != nil {
+		t.Fatalf("ParseStructuredDashboard failed: %v", err)
+	}
+	if len(sd.Rows) != 3 {
+		t.Fatalf("Rows count = %d; want 3", len(sd.Rows))
+	}
+	for i, expected := range []struct {
+		title     string
+		collapsed bool
+		panelID   int
+	}{{"", false, 1}, {"Resources", false, 2}, {"Logs", true, 3}} {
+		row := sd.Rows[i]
+		if row.Title != expected.title || row.Collapsed != expected.collapsed || len(row.Panels) != 1 || row.Panels[0].ID != expected.panelID {
+			t.Errorf("Row %d = %q collapsed=%t panels=%+v; want %q collapsed=%t with panel %d", i, row.Title, row.Collapsed, row.Panels,
+				expected.title, expected.collapsed, expected.panelID)
+		}
+	}
+
+	sd, err = ParseStructuredDashboard([]byte(dashboardV2GridRowsFixture), RowsExpanded, nil)
+	if err != nil {
+		t.Fatalf("ParseStructuredDashboard failed: %v", err)
+	}
+	if len(sd.Rows) != 2 || sd.Rows[1].Title != "Resources" {
+		t.Errorf("Rows count = %d; want 2 rows without the collapsed one", len(sd.Rows))
+	}
+}
+
+func TestParseStructuredDashboardV2UnknownElement(t *testing.T) {
+	body := `{"apiVersion": "dashboard.grafana.app/v2beta1", "spec": {"elements": {}, "layout": {"kind": "GridLayout", "spec": {"items": [
+		{"kind": "GridLayoutItem", "spec": {"element": {"kind": "ElementReference", "name": "missing"}}}
+	]}}}}`
+	if _, err := ParseStructuredDashboard([]byte(body), RowsExpanded, nil); err == nil {
+		t.Error("Expected error for the reference to unknown element, got nil")
+	}
+}
+
+func TestParseStructuredDashboardTimeSettings(t *testing.T) {
+	v1 := `{"dashboard": {"uid": "time", "title": "Time", "panels": [], "time": {"from": "now-7d", "to": "now"},
+		"timezone": "Europe/Berlin", "weekStart": "monday", "timepicker": {"nowDelay": "5m"}, "fiscalYearStartMonth": 3}, "meta": {"slug": "time"}}`
+	v2 := `{"apiVersion": "dashboard.grafana.app/v2beta1", "metadata": {"name": "time"}, "spec": {"title": "Time",
+		"elements": {}, "layout": {"kind": "GridLayout", "spec": {"items": []}},
+		"timeSettings": {"from": "now-7d", "to": "now", "timezone": "Europe/Berlin", "weekStart": "monday", "nowDelay": "5m", "fiscalYearStartMonth": 3}}}`
+	expected := TimeSettings{From: "now-7d", To: "now", Timezone: "Europe/Berlin", WeekStart: "monday", NowDelay: "5m", FiscalYearStartMonth: 3}
+	for name, body := range map[string]string{"v1": v1, "v2": v2} {
+		sd, err := ParseStructuredDashboard([]byte(body), RowsExpanded, nil)
+		if err != nil {
+			t.Fatalf("ParseStructuredDashboard(%s) failed: %v", name, err)
+		}
+		if sd.Time != expected {
+			t.Errorf("Time of %s = %+v; want %+v", name, sd.Time, expected)
+		}
+	}
+}
+
+func TestGetStructuredDashboardRowsMode(t *testing.T) {
+	entity := &Entity{
+		Dashboard: Dashboard{
+			Panels: []Panel{
+				{ID: 1, Type: "graph", GridPos: GridPos{H: 8, W: 24, X: 0, Y: 0}},
+				{ID: 2, Type: "row", Title: "Expanded", GridPos: GridPos{H: 1, W: 24, X: 0, Y: 8}},
+				{ID: 3, Type: "graph", GridPos: GridPos{H: 8, W: 24, X: 0, Y: 9}},
+				{ID: 4, Type: "row", Title: "Collapsed", Collapsed: true, GridPos: GridPos{H: 1, W: 24, X: 0, Y: 17}, Panels: []Panel{
+					{ID: 5, Type: "graph", GridPos: GridPos{H: 8, W: 24, X: 0, Y: 18}},
+				}},
+				{ID: 6, Type: "row", Title: "Last", GridPos: GridPos{H: 1, W: 24, X: 0, Y: 18}},
+				{ID: 7, Type: "graph", GridPos: GridPos{H: 8, W: 24, X: 0, Y: 19}},
+			},
+		},
+	}
+
+	tests := []struct {
+		mode      RowsMode
+		titles    []string
+		collapsed []bool
+		panels    int
+	}{
+		{RowsExpanded, []string{"", "Expanded", "Last"}, []bool{false, false, false}, 3},
+		{RowsCollapsed, []string{"Collapsed"}, []bool{true}, 1},
+		{RowsAll, []string{"", "Expanded", "Collapsed", "Last"}, []bool{false, false, true, false}, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			sd, err := entity.GetStructuredDashboard(tt.mode)
+			if err != nil {
+				t.Fatalf("GetStructuredDashboard failed: %v", err)
+			}
+			if len(sd.Rows) != len(tt.titles) {
+				t.Fatalf("Rows count = %d; want %d", len(sd.Rows), len(tt.titles))
+			}
+			panels := 0
+			for i, row := range sd.Rows {
+				if row.Title != tt.titles[i] || row.Collapsed != tt.collapsed[i] {
+					t.Errorf("Row %d = %q (collapsed=%v); want %q (collapsed=%v)", i, row.Title, row.Collapsed, tt.titles[i], tt.collapsed[i])
+				}
+				panels += len(row.Panels)
+			}
+			if panels != tt.panels {
+				t.Errorf("Panels count = %d; want %d", panels, tt.panels)
+			}
+		})
+	}
+}
+
+func TestParseRowsMode(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected RowsMode
+		hasError bool
+	}{
+		{"", RowsExpanded, false},
+		{"expanded", RowsExpanded, false},
+		{"Collapsed", RowsCollapsed, false},
+		{"all", RowsAll, false},
+		{"none", "", true},
+	}
+
+	for _, tt := range tests {
+		result, err := ParseRowsMode(tt.value)
+		if (err != nil) != tt.hasError {
+			t.Errorf("ParseRowsMode(%q) error = %v; want error %v", tt.value, err, tt.hasError)
+		}
+		if result != tt.expected {
+			t.Errorf("ParseRowsMode(%q) = %q; want %q", tt.value, result, tt.expected)
+		}
+	}
+}
+
+func TestPanelGetTextContent(t *testing.T) {
+	v1 := `{"dashboard": {"uid": "text", "title": "Text", "panels": [
+		{"id": 1, "type": "text", "gridPos": {"h": 4, "w": 24, "x": 0, "y": 0}, "options": {"mode": "html", "content": "<b>new</b>"}},
+		{"id": 2, "type": "text", "gridPos": {"h": 4, "w": 24, "x": 0, "y": 4}, "mode": "markdown", "content": "**old**"}
+	]}, "meta": {"slug": "text"}}`
+	v2 := `{"apiVersion": "dashboard.grafana.app/v2beta1", "metadata": {"name": "text"}, "spec": {"title": "Text",
+		"elements": {"panel-1": {"kind": "Panel", "spec": {"id": 1, "vizConfig": {"kind": "VizConfig", "group": "text",
+			"spec": {"options": {"mode": "markdown", "content": "# v2"}}}}}},
+		"layout": {"kind": "GridLayout", "spec": {"items": [
+			{"kind": "GridLayoutItem", "spec": {"x": 0, "y": 0, "width": 24, "height": 4, "element": {"kind": "ElementReference", "name": "panel-1"}}}
+		]}}}}`
+
+	sd, err := ParseStructuredDashboard([]byte(v1), RowsExpanded, nil)
+	if err != nil {
+		t.Fatalf("ParseStructuredDashboard failed: %v", err)
+	}
+	panels := sd.Rows[0].Panels
+	if content, mode := panels[0].GetTextContent(); !panels[0].IsText() || content != "<b>new</b>" || mode != "html" {
+		t.Errorf("GetTextContent() = %q, %q; want <b>new</b>, html", content, mode)
+	}
+	if content, mode := panels[1].GetTextContent(); content != "**old**" || mode != "markdown" {
+		t.Errorf("GetTextContent() of legacy panel = %q, %q; want **old**, markdown", content, mode)
+	}
+
+	sd, err = ParseStructuredDashboard([]byte(v2), RowsExpanded, nil)
+	if err != nil {
+		t.Fatalf("ParseStructuredDashboard failed: %v", err)
+	}
+	panel := sd.Rows[0].Panels[0]
+	if content, mode := panel.GetTextContent(); !panel.IsText() || content != "# v2" || mode != "markdown" {
+		t.Errorf("GetTextContent() of v2 panel = %q, %q; want # v2, markdown", content, mode)
+	}
+}
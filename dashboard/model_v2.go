@@ -0,0 +1,411 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package dashboard
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Layout kinds of the dashboard schema v2
+const (
+	layoutGrid     = "GridLayout"
+	layoutRows     = "RowsLayout"
+	layoutTabs     = "TabsLayout"
+	layoutAutoGrid = "AutoGridLayout"
+	// layoutGridRow is an item of GridLayout of schema v2alpha1 that is a row with its own items
+	layoutGridRow = "GridLayoutRow"
+)
+
+const (
+	autoGridDefaultColumns = 3
+	autoGridCellHeightPx   = 30
+	autoGridCellMarginPx   = 8
+)
+
+// autoGridRowHeightsPx are heights of the predefined row height modes of AutoGridLayout in pixels
+var autoGridRowHeightsPx = map[string]int{
+	"short":    168,
+	"standard": 320,
+	"tall":     512,
+}
+
+// EntityV2 is a dashboard in schema v2. Grafana returns it either as a resource
+// (apiVersion, kind, metadata, spec) or wrapped in "dashboard" like the schema v1 one.
+type EntityV2 struct {
+	APIVersion string      `json:"apiVersion"`
+	Kind       string      `json:"kind"`
+	Metadata   MetadataV2  `json:"metadata"`
+	Spec       DashboardV2 `json:"spec"`
+	Meta       `json:"meta"`
+}
+
+type MetadataV2 struct {
+	Name string `json:"name"`
+}
+
+type DashboardV2 struct {
+	Title        string               `json:"title"`
+	Elements     map[string]ElementV2 `json:"elements"`
+	Layout       LayoutV2             `json:"layout"`
+	Annotations  []AnnotationQueryV2  `json:"annotations"`
+	TimeSettings TimeSettingsV2       `json:"timeSettings"`
+}
+
+// TimeSettingsV2 contains the time range and the time settings of the dashboard used by the reporter
+type TimeSettingsV2 struct {
+	From                 string `json:"from"`
+	To                   string `json:"to"`
+	Timezone             string `json:"timezone"`
+	WeekStart            string `json:"weekStart"`
+	NowDelay             string `json:"nowDelay"`
+	FiscalYearStartMonth int    `json:"fiscalYearStartMonth"`
+}
+
+type ElementV2 struct {
+	Kind string        `json:"kind"`
+	Spec ElementSpecV2 `json:"spec"`
+}
+
+type ElementSpecV2 struct {
+	ID           int          `json:"id"`
+	Title        string       `json:"title"`
+	VizConfig    VizConfigV2  `json:"vizConfig"`
+	LibraryPanel LibraryPanel `json:"libraryPanel"`
+	Data         QueryGroupV2 `json:"data"`
+}
+
+// QueryGroupV2 contains queries of the panel, only options of the time range are used by the reporter
+type QueryGroupV2 struct {
+	Spec struct {
+		QueryOptions struct {
+			TimeFrom  string `json:"timeFrom"`
+			TimeShift string `json:"timeShift"`
+		} `json:"queryOptions"`
+	} `json:"spec"`
+}
+
+// VizConfigV2 describes the visualization of the panel. The plugin ID is stored in "group" since v2beta1
+// and in "kind" in v2alpha1.
+type VizConfigV2 struct {
+	Kind  string `json:"kind"`
+	Group string `json:"group"`
+	Spec  struct {
+		Options PanelOptions `json:"options"`
+	} `json:"spec"`
+}
+
+type LibraryPanel struct {
+	UID  string `json:"uid"`
+	Name string `json:"name"`
+}
+
+type LayoutV2 struct {
+	Kind string       `json:"kind"`
+	Spec LayoutSpecV2 `json:"spec"`
+}
+
+type LayoutSpecV2 struct {
+	Items []LayoutItemV2 `json:"items"`
+	Rows  []SectionV2    `json:"rows"`
+	Tabs  []SectionV2    `json:"tabs"`
+	// AutoGridLayout options
+	MaxColumnCount int    `json:"maxColumnCount"`
+	RowHeightMode  string `json:"rowHeightMode"`
+	RowHeight      int    `json:"rowHeight"`
+}
+
+type LayoutItemV2 struct {
+	Kind string           `json:"kind"`
+	Spec LayoutItemSpecV2 `json:"spec"`
+}
+
+type LayoutItemSpecV2 struct {
+	X       int              `json:"x"`
+	Y       int              `json:"y"`
+	Width   int              `json:"width"`
+	Height  int              `json:"height"`
+	Element ElementReference `json:"element"`
+	// Title, Collapsed and Elements of GridLayoutRow
+	Title     string         `json:"title"`
+	Collapsed bool           `json:"collapsed"`
+	Elements  []LayoutItemV2 `json:"elements"`
+}
+
+type ElementReference struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+// SectionV2 is a row of RowsLayout or a tab of TabsLayout
+type SectionV2 struct {
+	Kind string        `json:"kind"`
+	Spec SectionSpecV2 `json:"spec"`
+}
+
+type SectionSpecV2 struct {
+	Title    string   `json:"title"`
+	Collapse bool     `json:"collapse"`
+	Layout   LayoutV2 `json:"layout"`
+}
+
+// IsSchemaV2 checks if the Grafana response contains a dashboard in schema v2
+func IsSchemaV2(body []byte) bool {
+	var probe struct {
+		APIVersion string `json:"apiVersion"`
+		Dashboard  struct {
+			Elements json.RawMessage `json:"elements"`
+			Layout   json.RawMessage `json:"layout"`
+		} `json:"dashboard"`
+	}
+	if err := json.Unmarshal(body, &probe); err != nil {
+		return false
+	}
+	if strings.Contains(probe.APIVersion, "/v2") {
+		return true
+	}
+	return len(probe.Dashboard.Elements) > 0 && len(probe.Dashboard.Layout) > 0
+}
+
+// ParseStructuredDashboard detects the schema version of the Grafana response and builds structured dashboard from it
+// with rows and panels of the selection
+func ParseStructuredDashboard(body []byte, rowsMode RowsMode, selection *Selection) (*StructuredDashboard, error) {
+	var structured *StructuredDashboard
+	if IsSchemaV2(body) {
+		var entity *EntityV2
+		if err := json.Unmarshal(body, &entity); err != nil {
+			return nil, err
+		}
+		if entity.APIVersion == "" {
+			// dashboard is wrapped in "dashboard" field
+			var wrapped struct {
+				Dashboard struct {
+					DashboardV2
+					UID string `json:"uid"`
+				} `json:"dashboard"`
+			}
+			if err := json.Unmarshal(body, &wrapped); err != nil {
+				return nil, err
+			}
+			entity.Spec = wrapped.Dashboard.DashboardV2
+			entity.Metadata.Name = wrapped.Dashboard.UID
+		}
+		sd, err := entity.GetStructuredDashboard(rowsMode)
+		if err != nil {
+			return nil, err
+		}
+		structured = sd
+	} else {
+		var entity *Entity
+		if err := json.Unmarshal(body, &entity); err != nil {
+			return nil, err
+		}
+		sd, err := entity.GetStructuredDashboard(rowsMode)
+		if err != nil {
+			return nil, err
+		}
+		structured = sd
+	}
+	structured.ApplySelection(selection)
+	return structured, nil
+}
+
+func (de *EntityV2) GetStructuredDashboard(rowsMode RowsMode) (*StructuredDashboard, error) {
+	rows, err := de.Spec.layoutToRows(de.Spec.Layout, "", false, rowsMode)
+	if err != nil {
+		return nil, err
+	}
+	panelsCount := 0
+	for _, row := range rows {
+		panelsCount += len(row.Panels)
+	}
+	if len(rows) > rowsLimit || panelsCount > panelsLimit {
+		return nil, fmt.Errorf("grafana dashboard contains too many rows/panels: rows=%d (limit=%d); panels=%d (limit=%d)", len(rows), rowsLimit, panelsCount, panelsLimit)
+	}
+	slug := de.Slug
+	if slug == "" {
+		slug = slugify(de.Spec.Title)
+	}
+	return &StructuredDashboard{
+		UID:               de.Metadata.Name,
+		Title:             de.Spec.Title,
+		Slug:              slug,
+		Rows:              rows,
+		AnnotationQueries: getAnnotationQueriesV2(de.Spec.Annotations),
+		Time:              TimeSettings(de.Spec.TimeSettings),
+	}, nil
+}
+
+// layoutToRows converts layout to the list of rows. Tabs and rows of the layout become separate rows of the report,
+// the title of the nested section is prefixed with the title of the parent one. Panels of the section are collapsed
+// if the section or any of its parents is collapsed.
+func (d *DashboardV2) layoutToRows(layout LayoutV2, title string, collapsed bool, rowsMode RowsMode) ([]*Row, error) {
+	switch layout.Kind {
+	case layoutGrid:
+		return d.gridToRows(layout, title, collapsed, rowsMode)
+	case layoutAutoGrid:
+		return d.panelsToRow(layout, title, collapsed, rowsMode)
+	case layoutRows, layoutTabs:
+		sections := layout.Spec.Rows
+		if layout.Kind == layoutTabs {
+			sections = layout.Spec.Tabs
+		}
+		var rows []*Row
+		for _, section := range sections {
+			sectionRows, err := d.layoutToRows(section.Spec.Layout, joinTitles(title, section.Spec.Title), collapsed || section.Spec.Collapse, rowsMode)
+			if err != nil {
+				return nil, err
+			}
+			rows = append(rows, sectionRows...)
+		}
+		return rows, nil
+	case "":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unsupported dashboard layout kind %q", layout.Kind)
+	}
+}
+
+// gridToRows converts GridLayout to rows. Items above the first GridLayoutRow make the row with the title
+// of the parent section, every GridLayoutRow makes its own row with its items.
+func (d *DashboardV2) gridToRows(layout LayoutV2, title string, collapsed bool, rowsMode RowsMode) ([]*Row, error) {
+	var rows []*Row
+	items := LayoutV2{Kind: layoutGrid}
+	for _, item := range layout.Spec.Items {
+		if item.Kind != layoutGridRow {
+			items.Spec.Items = append(items.Spec.Items, item)
+			continue
+		}
+		gridRow := LayoutV2{Kind: layoutGrid, Spec: LayoutSpecV2{Items: item.Spec.Elements}}
+		rowRows, err := d.panelsToRow(gridRow, joinTitles(title, item.Spec.Title), collapsed || item.Spec.Collapsed, rowsMode)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, rowRows...)
+	}
+	top, err := d.panelsToRow(items, title, collapsed, rowsMode)
+	if err != nil {
+		return nil, err
+	}
+	return append(top, rows...), nil
+}
+
+// panelsToRow converts items of the grid or auto grid layout to the row, it returns no rows if the layout has no
+// panels or the row is not included by the rows mode
+func (d *DashboardV2) panelsToRow(layout LayoutV2, title string, collapsed bool, rowsMode RowsMode) ([]*Row, error) {
+	if !rowsMode.Includes(collapsed) {
+		return nil, nil
+	}
+	panels, err := d.layoutPanels(layout)
+	if err != nil {
+		return nil, err
+	}
+	if len(panels) == 0 {
+		return nil, nil
+	}
+	row := &Row{
+		Title:     title,
+		GridPos:   panels[0].GridPos,
+		Collapsed: collapsed,
+		Panels:    panels,
+	}
+	row.arrange()
+	return []*Row{row}, nil
+}
+
+// layoutPanels returns panels of the grid or auto grid layout sorted by position
+func (d *DashboardV2) layoutPanels(layout LayoutV2) ([]Panel, error) {
+	panels := make([]Panel, 0, len(layout.Spec.Items))
+	columns := layout.Spec.MaxColumnCount
+	if columns <= 0 {
+		columns = autoGridDefaultColumns
+	}
+	autoWidth := grafanaResolutionWidth / columns
+	autoHeight := getAutoGridRowHeight(layout.Spec.RowHeightMode, layout.Spec.RowHeight)
+	for i, item := range layout.Spec.Items {
+		element, ok := d.Elements[item.Spec.Element.Name]
+		if !ok {
+			return nil, fmt.Errorf("layout refers to element %q that does not exist", item.Spec.Element.Name)
+		}
+		gridPos := GridPos{X: item.Spec.X, Y: item.Spec.Y, W: item.Spec.Width, H: item.Spec.Height}
+		if layout.Kind == layoutAutoGrid {
+			gridPos = GridPos{X: (i % columns) * autoWidth, Y: (i / columns) * autoHeight, W: autoWidth, H: autoHeight}
+		}
+		panels = append(panels, element.toPanel(gridPos))
+	}
+	SortPanels(panels)
+	return panels, nil
+}
+
+func (e *ElementV2) toPanel(gridPos GridPos) Panel {
+	panelType := e.Spec.VizConfig.Group
+	if panelType == "" {
+		panelType = e.Spec.VizConfig.Kind
+	}
+	title := e.Spec.Title
+	if title == "" {
+		title = e.Spec.LibraryPanel.Name
+	}
+	return Panel{
+		ID:        e.Spec.ID,
+		GridPos:   gridPos,
+		Title:     title,
+		Type:      panelType,
+		Options:   e.Spec.VizConfig.Spec.Options,
+		TimeFrom:  e.Spec.Data.Spec.QueryOptions.TimeFrom,
+		TimeShift: e.Spec.Data.Spec.QueryOptions.TimeShift,
+	}
+}
+
+// getAutoGridRowHeight converts row height of AutoGridLayout in pixels to the height in grid units
+func getAutoGridRowHeight(mode string, customHeight int) int {
+	heightPx, ok := autoGridRowHeightsPx[mode]
+	if mode == "custom" && customHeight > 0 {
+		heightPx, ok = customHeight, true
+	}
+	if !ok {
+		heightPx = autoGridRowHeightsPx["standard"]
+	}
+	return int(math.Ceil(float64(heightPx+autoGridCellMarginPx) / float64(autoGridCellHeightPx+autoGridCellMarginPx)))
+}
+
+func joinTitles(parent, title string) string {
+	if parent == "" {
+		return title
+	}
+	if title == "" {
+		return parent
+	}
+	return fmt.Sprintf("%s / %s", parent, title)
+}
+
+// slugify makes slug from dashboard title in the same way as Grafana does
+func slugify(title string) string {
+	var sb strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(title) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			sb.WriteRune(r)
+			dash = false
+		} else if !dash && sb.Len() > 0 {
+			sb.WriteRune('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "-")
+}
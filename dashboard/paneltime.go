@@ -0,0 +1,63 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package dashboard
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Netcracker/grafana-reporter/timerange"
+)
+
+// PanelTime is the time range of the panel with relative time or time shift
+type PanelTime struct {
+	// Caption describes the override, for example "Last 7 days, shifted 1w"
+	Caption string
+	// From and To are the start and the end of the time range of the panel
+	From string
+	To   string
+}
+
+// SetPanelTimes calculates the time range of panels overriding the time range of the dashboard.
+// Panels with invalid overrides are left with the time range of the dashboard and returned in the error.
+func (sd *StructuredDashboard) SetPanelTimes(timerangeData *timerange.TimerangeData, now time.Time, options timerange.Options) error {
+	times := map[int]*PanelTime{}
+	var errs []error
+	for _, row := range sd.Rows {
+		for _, panel := range row.Panels {
+			if panel.TimeFrom == "" && panel.TimeShift == "" {
+				continue
+			}
+			panelTimerange, err := timerange.PanelTimerange(timerangeData, panel.TimeFrom, panel.TimeShift, now, options)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("panel %d :%w", panel.ID, err))
+				continue
+			}
+			times[panel.ID] = &PanelTime{
+				Caption: timerange.DescribePanelTime(panel.TimeFrom, panel.TimeShift),
+				From:    panelTimerange.DateFrom.Format(timerange.Format),
+				To:      panelTimerange.DateTo.Format(timerange.Format),
+			}
+		}
+	}
+	for _, row := range sd.Rows {
+		for i := range row.Panels {
+			row.Panels[i].Time = times[row.Panels[i].ID]
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,48 @@
+package dashboard
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Netcracker/grafana-reporter/timerange"
+)
+
+func TestSetPanelTimes(t *testing.T) {
+	v1 := `{"dashboard": {"uid": "time", "title": "Time", "panels": [
+		{"id": 1, "type": "graph", "gridPos": {"h": 8, "w": 12, "x": 0, "y": 0}, "timeFrom": "7d", "timeShift": "1w"},
+		{"id": 2, "type": "graph", "gridPos": {"h": 8, "w": 12, "x": 12, "y": 0}},
+		{"id": 3, "type": "graph", "gridPos": {"h": 8, "w": 12, "x": 0, "y": 8}, "timeShift": "one week"}
+	]}, "meta": {"slug": "time"}}`
+	v2 := `{"apiVersion": "dashboard.grafana.app/v2beta1", "metadata": {"name": "time"}, "spec": {"title": "Time",
+		"elements": {
+			"panel-1": {"kind": "Panel", "spec": {"id": 1, "data": {"kind": "QueryGroup", "spec": {"queryOptions": {"timeFrom": "7d", "timeShift": "1w"}}}}},
+			"panel-2": {"kind": "Panel", "spec": {"id": 2}}
+		},
+		"layout": {"kind": "GridLayout", "spec": {"items": [
+			{"kind": "GridLayoutItem", "spec": {"x": 0, "y": 0, "width": 12, "height": 8, "element": {"kind": "ElementReference", "name": "panel-1"}}},
+			{"kind": "GridLayoutItem", "spec": {"x": 12, "y": 0, "width": 12, "height": 8, "element": {"kind": "ElementReference", "name": "panel-2"}}}
+		]}}}}`
+	now := time.Date(2024, 1, 25, 14, 43, 12, 0, time.UTC)
+	dashboardRange := &timerange.TimerangeData{From: "now-1h", To: "now", DateFrom: now.Add(-time.Hour), DateTo: now}
+	expected := PanelTime{Caption: "Last 7 days, shifted 1w", From: "2024-01-11 14:43:12 +0000 UTC", To: "2024-01-18 14:43:12 +0000 UTC"}
+	for name, body := range map[string]string{"v1": v1, "v2": v2} {
+		sd, err := ParseStructuredDashboard([]byte(body), RowsExpanded, nil)
+		if err != nil {
+			t.Fatalf("ParseStructuredDashboard(%s) failed: %v", name, err)
+		}
+		err = sd.SetPanelTimes(dashboardRange, now, timerange.Options{})
+		if (err != nil) != (name == "v1") {
+			t.Errorf("SetPanelTimes(%s) error = %v; want error only for the invalid time shift", name, err)
+		}
+		first, second := sd.Rows[0].Lines[0].Panels[0], sd.Rows[0].Lines[0].Panels[1]
+		if first.Time == nil || *first.Time != expected {
+			t.Errorf("Time of the panel 1 of %s = %+v; want %+v", name, first.Time, expected)
+		}
+		if second.Time != nil {
+			t.Errorf("Time of the panel 2 of %s = %+v; want nil", name, second.Time)
+		}
+		if panel, _ := sd.GetPanel(1); panel.Time == nil {
+			t.Errorf("Time of the panel 1 of %s is not set in the row", name)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package dashboard
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Names of the parameters to search dashboards for the report of several dashboards
+const (
+	ParamFolderUID = "folderUID"
+	ParamTag       = "tag"
+)
+
+// Search defines dashboards of the report found by Grafana search API: dashboards in the folders
+// which have all the tags
+type Search struct {
+	FolderUIDs []string
+	Tags       []string
+}
+
+// SearchResult is a dashboard returned by Grafana search API
+type SearchResult struct {
+	UID         string   `json:"uid"`
+	Title       string   `json:"title"`
+	Type        string   `json:"type"`
+	Tags        []string `json:"tags"`
+	FolderUID   string   `json:"folderUid"`
+	FolderTitle string   `json:"folderTitle"`
+}
+
+// ParseSearch reads search of dashboards from the parameters. Lists of folders and tags are separated by comma.
+// It returns nil if no search parameters are set.
+func ParseSearch(params url.Values) *Search {
+	s := &Search{
+		FolderUIDs: parseList(params, ParamFolderUID),
+		Tags:       parseList(params, ParamTag),
+	}
+	if s.IsEmpty() {
+		return nil
+	}
+	return s
+}
+
+// IsEmpty checks if the search does not set any folder or tag
+func (s *Search) IsEmpty() bool {
+	return s == nil || (len(s.FolderUIDs) == 0 && len(s.Tags) == 0)
+}
+
+// GetQuery returns parameters of the request to Grafana search API
+func (s *Search) GetQuery(limit int) url.Values {
+	query := url.Values{}
+	query.Set("type", "dash-db")
+	query.Set("limit", strconv.Itoa(limit))
+	for _, folder := range s.FolderUIDs {
+		query.Add("folderUIDs", folder)
+	}
+	for _, tag := range s.Tags {
+		query.Add("tag", tag)
+	}
+	return query
+}
+
+// String returns the search in the form of request parameters
+func (s *Search) String() string {
+	if s.IsEmpty() {
+		return ""
+	}
+	var parts []string
+	if len(s.FolderUIDs) > 0 {
+		parts = append(parts, fmt.Sprintf("%s=%s", ParamFolderUID, strings.Join(s.FolderUIDs, ",")))
+	}
+	if len(s.Tags) > 0 {
+		parts = append(parts, fmt.Sprintf("%s=%s", ParamTag, strings.Join(s.Tags, ",")))
+	}
+	return strings.Join(parts, " ")
+}
+
+// GetTitle returns the title of the report of found dashboards, for example "Folder: Kubernetes, tags: sla"
+func (s *Search) GetTitle(results []SearchResult) string {
+	var parts []string
+	if len(s.FolderUIDs) > 0 {
+		var folders []string
+		for _, uid := range s.FolderUIDs {
+			title := uid
+			for _, result := range results {
+				if result.FolderUID == uid && result.FolderTitle != "" {
+					title = result.FolderTitle
+					break
+				}
+			}
+			folders = append(folders, title)
+		}
+		parts = append(parts, fmt.Sprintf("Folder: %s", strings.Join(folders, ", ")))
+	}
+	if len(s.Tags) > 0 {
+		parts = append(parts, fmt.Sprintf("tags: %s", strings.Join(s.Tags, ", ")))
+	}
+	title := strings.Join(parts, ", ")
+	if title == "" {
+		return ""
+	}
+	return strings.ToUpper(title[:1]) + title[1:]
+}
+
+// SortSearchResults orders dashboards by folders and titles, so the report has the same order as Grafana browse page
+func SortSearchResults(results []SearchResult) {
+	slices.SortStableFunc(results, func(a, b SearchResult) int {
+		if c := strings.Compare(strings.ToLower(a.FolderTitle), strings.ToLower(b.FolderTitle)); c != 0 {
+			return c
+		}
+		return strings.Compare(strings.ToLower(a.Title), strings.ToLower(b.Title))
+	})
+}
@@ -0,0 +1,56 @@
+package dashboard
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestParseSearch(t *testing.T) {
+	if s := ParseSearch(url.Values{}); s != nil {
+		t.Errorf("ParseSearch() = %+v; want nil", s)
+	}
+	s := ParseSearch(url.Values{ParamFolderUID: {"folder1, folder2"}, ParamTag: {"sla"}})
+	if s == nil || len(s.FolderUIDs) != 2 || s.FolderUIDs[1] != "folder2" || len(s.Tags) != 1 {
+		t.Fatalf("ParseSearch() = %+v; want 2 folders and 1 tag", s)
+	}
+	query := s.GetQuery(10)
+	if query.Get("type") != "dash-db" || query.Get("limit") != "10" || len(query["folderUIDs"]) != 2 || query.Get("tag") != "sla" {
+		t.Errorf("GetQuery() = %s", query.Encode())
+	}
+	if s.String() != "folderUID=folder1,folder2 tag=sla" {
+		t.Errorf("String() = %q", s.String())
+	}
+}
+
+func TestSearchGetTitle(t *testing.T) {
+	results := []SearchResult{{UID: "a", Title: "A", FolderUID: "folder1", FolderTitle: "Kubernetes"}}
+	tests := []struct {
+		name   string
+		search *Search
+		title  string
+	}{
+		{"folder", &Search{FolderUIDs: []string{"folder1"}}, "Folder: Kubernetes"},
+		{"unknown folder", &Search{FolderUIDs: []string{"folder2"}}, "Folder: folder2"},
+		{"tags", &Search{Tags: []string{"sla", "prod"}}, "Tags: sla, prod"},
+		{"folder and tags", &Search{FolderUIDs: []string{"folder1"}, Tags: []string{"sla"}}, "Folder: Kubernetes, tags: sla"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if title := tt.search.GetTitle(results); title != tt.title {
+				t.Errorf("GetTitle() = %q; want %q", title, tt.title)
+			}
+		})
+	}
+}
+
+func TestSortSearchResults(t *testing.T) {
+	results := []SearchResult{
+		{UID: "c", Title: "b", FolderTitle: "Kubernetes"},
+		{UID: "b", Title: "A", FolderTitle: "Kubernetes"},
+		{UID: "a", Title: "Z", FolderTitle: "Apps"},
+	}
+	SortSearchResults(results)
+	if results[0].UID != "a" || results[1].UID != "b" || results[2].UID != "c" {
+		t.Errorf("SortSearchResults() = %+v", results)
+	}
+}
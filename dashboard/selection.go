@@ -0,0 +1,214 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package dashboard
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Names of the parameters to select panels and rows of the report
+const (
+	ParamIncludePanels     = "includePanels"
+	ParamExcludePanels     = "excludePanels"
+	ParamIncludePanelTitle = "includePanelTitle"
+	ParamExcludePanelTitle = "excludePanelTitle"
+	ParamIncludeRows       = "includeRows"
+	ParamExcludeRows       = "excludeRows"
+	ParamIncludePanelTypes = "includePanelTypes"
+	ParamExcludePanelTypes = "excludePanelTypes"
+)
+
+// Selection defines panels and rows to include to the report or to exclude from it.
+// The panel is included if it matches all include filters and does not match any exclude filter.
+type Selection struct {
+	IncludePanelIDs   []int
+	ExcludePanelIDs   []int
+	IncludePanelTitle *regexp.Regexp
+	ExcludePanelTitle *regexp.Regexp
+	IncludeRowTitle   *regexp.Regexp
+	ExcludeRowTitle   *regexp.Regexp
+	IncludePanelTypes []string
+	ExcludePanelTypes []string
+}
+
+// ParseSelection reads selection from the parameters. Lists of panel IDs and types are separated by comma,
+// titles are matched by regular expressions. It returns nil if no selection parameters are set.
+func ParseSelection(params url.Values) (*Selection, error) {
+	var err error
+	s := &Selection{}
+	if s.IncludePanelIDs, err = parseIDs(params, ParamIncludePanels); err != nil {
+		return nil, err
+	}
+	if s.ExcludePanelIDs, err = parseIDs(params, ParamExcludePanels); err != nil {
+		return nil, err
+	}
+	if s.IncludePanelTitle, err = parseRegexp(params, ParamIncludePanelTitle); err != nil {
+		return nil, err
+	}
+	if s.ExcludePanelTitle, err = parseRegexp(params, ParamExcludePanelTitle); err != nil {
+		return nil, err
+	}
+	if s.IncludeRowTitle, err = parseRegexp(params, ParamIncludeRows); err != nil {
+		return nil, err
+	}
+	if s.ExcludeRowTitle, err = parseRegexp(params, ParamExcludeRows); err != nil {
+		return nil, err
+	}
+	s.IncludePanelTypes = parseList(params, ParamIncludePanelTypes)
+	s.ExcludePanelTypes = parseList(params, ParamExcludePanelTypes)
+	if s.IsEmpty() {
+		return nil, nil
+	}
+	return s, nil
+}
+
+// IsEmpty checks if the selection does not filter anything
+func (s *Selection) IsEmpty() bool {
+	return s == nil || (len(s.IncludePanelIDs) == 0 && len(s.ExcludePanelIDs) == 0 &&
+		s.IncludePanelTitle == nil && s.ExcludePanelTitle == nil &&
+		s.IncludeRowTitle == nil && s.ExcludeRowTitle == nil &&
+		len(s.IncludePanelTypes) == 0 && len(s.ExcludePanelTypes) == 0)
+}
+
+// IncludesRow checks if the row with the title is included to the report
+func (s *Selection) IncludesRow(title string) bool {
+	if s.IsEmpty() {
+		return true
+	}
+	if s.IncludeRowTitle != nil && !s.IncludeRowTitle.MatchString(title) {
+		return false
+	}
+	return s.ExcludeRowTitle == nil || !s.ExcludeRowTitle.MatchString(title)
+}
+
+// IncludesPanel checks if the panel is included to the report
+func (s *Selection) IncludesPanel(p Panel) bool {
+	if s.IsEmpty() {
+		return true
+	}
+	if len(s.IncludePanelIDs) > 0 && !slices.Contains(s.IncludePanelIDs, p.ID) {
+		return false
+	}
+	if slices.Contains(s.ExcludePanelIDs, p.ID) {
+		return false
+	}
+	if s.IncludePanelTitle != nil && !s.IncludePanelTitle.MatchString(p.Title) {
+		return false
+	}
+	if s.ExcludePanelTitle != nil && s.ExcludePanelTitle.MatchString(p.Title) {
+		return false
+	}
+	if len(s.IncludePanelTypes) > 0 && !containsFold(s.IncludePanelTypes, p.Type) {
+		return false
+	}
+	return !containsFold(s.ExcludePanelTypes, p.Type)
+}
+
+// String returns the selection in the form of request parameters to show it in the report
+func (s *Selection) String() string {
+	if s.IsEmpty() {
+		return ""
+	}
+	var parts []string
+	addPart := func(name string, value string) {
+		if value != "" {
+			parts = append(parts, fmt.Sprintf("%s=%s", name, value))
+		}
+	}
+	addPart(ParamIncludePanels, joinIDs(s.IncludePanelIDs))
+	addPart(ParamExcludePanels, joinIDs(s.ExcludePanelIDs))
+	addPart(ParamIncludePanelTitle, regexpString(s.IncludePanelTitle))
+	addPart(ParamExcludePanelTitle, regexpString(s.ExcludePanelTitle))
+	addPart(ParamIncludeRows, regexpString(s.IncludeRowTitle))
+	addPart(ParamExcludeRows, regexpString(s.ExcludeRowTitle))
+	addPart(ParamIncludePanelTypes, strings.Join(s.IncludePanelTypes, ","))
+	addPart(ParamExcludePanelTypes, strings.Join(s.ExcludePanelTypes, ","))
+	return strings.Join(parts, " ")
+}
+
+// ApplySelection removes rows and panels that are not selected. Rows left without panels are removed too.
+// Filtered panels do not leave vertical gaps between lines of the row.
+func (sd *StructuredDashboard) ApplySelection(s *Selection) {
+	if s.IsEmpty() {
+		return
+	}
+	sd.filterPanels(func(row *Row, panel Panel) bool {
+		return s.IncludesRow(row.Title) && s.IncludesPanel(panel)
+	})
+	sd.Selection = s
+}
+
+func parseIDs(params url.Values, name string) ([]int, error) {
+	var ids []int
+	for _, value := range parseList(params, name) {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("parameter %q must contain panel IDs separated by comma, got %q", name, value)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func parseRegexp(params url.Values, name string) (*regexp.Regexp, error) {
+	value := params.Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(value)
+	if err != nil {
+		return nil, fmt.Errorf("parameter %q is not a valid regular expression: %w", name, err)
+	}
+	return re, nil
+}
+
+func parseList(params url.Values, name string) []string {
+	var list []string
+	for _, value := range params[name] {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+	}
+	return list
+}
+
+func joinIDs(ids []int) string {
+	values := make([]string, 0, len(ids))
+	for _, id := range ids {
+		values = append(values, strconv.Itoa(id))
+	}
+	return strings.Join(values, ",")
+}
+
+func regexpString(re *regexp.Regexp) string {
+	if re == nil {
+		return ""
+	}
+	return re.String()
+}
+
+func containsFold(list []string, value string) bool {
+	return slices.ContainsFunc(list, func(item string) bool {
+		return strings.EqualFold(item, value)
+	})
+}
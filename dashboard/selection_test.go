@@ -0,0 +1,133 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package dashboard
+
+import (
+	"net/url"
+	"testing"
+)
+
+func selectionTestDashboard(t *testing.T) *StructuredDashboard {
+	entity := &Entity{
+		Dashboard: Dashboard{
+			Panels: []Panel{
+				{ID: 1, Type: "text", Title: "About", GridPos: GridPos{H: 4, W: 24, X: 0, Y: 0}},
+				{ID: 2, Type: "row", Title: "CPU", GridPos: GridPos{H: 1, W: 24, X: 0, Y: 4}},
+				{ID: 3, Type: "timeseries", Title: "CPU usage", GridPos: GridPos{H: 8, W: 12, X: 0, Y: 5}},
+				{ID: 4, Type: "stat", Title: "CPU cores", GridPos: GridPos{H: 8, W: 12, X: 12, Y: 5}},
+				{ID: 5, Type: "timeseries", Title: "CPU throttling", GridPos: GridPos{H: 8, W: 24, X: 0, Y: 13}},
+				{ID: 6, Type: "row", Title: "Memory", GridPos: GridPos{H: 1, W: 24, X: 0, Y: 21}},
+				{ID: 7, Type: "timeseries", Title: "Memory usage", GridPos: GridPos{H: 8, W: 24, X: 0, Y: 22}},
+			},
+		},
+	}
+	sd, err := entity.GetStructuredDashboard(RowsExpanded)
+	if err != nil {
+		t.Fatalf("GetStructuredDashboard failed: %v", err)
+	}
+	return sd
+}
+
+func TestParseSelection(t *testing.T) {
+	s, err := ParseSelection(url.Values{})
+	if err != nil || s != nil {
+		t.Errorf("ParseSelection() of empty parameters = %v, %v; want nil, nil", s, err)
+	}
+
+	s, err = ParseSelection(url.Values{
+		ParamIncludePanels:     {"1, 2", "3"},
+		ParamExcludePanelTypes: {"text,news"},
+		ParamIncludeRows:       {"^CPU$"},
+	})
+	if err != nil {
+		t.Fatalf("ParseSelection failed: %v", err)
+	}
+	if len(s.IncludePanelIDs) != 3 || len(s.ExcludePanelTypes) != 2 || s.IncludeRowTitle == nil {
+		t.Errorf("ParseSelection() = %+v; want 3 panel IDs, 2 types and row regexp", s)
+	}
+	expected := "includePanels=1,2,3 includeRows=^CPU$ excludePanelTypes=text,news"
+	if s.String() != expected {
+		t.Errorf("String() = %q; want %q", s.String(), expected)
+	}
+
+	if _, err = ParseSelection(url.Values{ParamExcludePanels: {"a"}}); err == nil {
+		t.Error("Expected error for invalid panel ID, got nil")
+	}
+	if _, err = ParseSelection(url.Values{ParamIncludePanelTitle: {"("}}); err == nil {
+		t.Error("Expected error for invalid regular expression, got nil")
+	}
+}
+
+func TestApplySelection(t *testing.T) {
+	tests := []struct {
+		name   string
+		params url.Values
+		rows   []string
+		panels []int
+	}{
+		{"include panel IDs", url.Values{ParamIncludePanels: {"4,7"}}, []string{"CPU", "Memory"}, []int{4, 7}},
+		{"exclude panel IDs", url.Values{ParamExcludePanels: {"1,3,4,5"}}, []string{"Memory"}, []int{7}},
+		{"include rows", url.Values{ParamIncludeRows: {"^CPU$"}}, []string{"CPU"}, []int{3, 4, 5}},
+		{"exclude rows", url.Values{ParamExcludeRows: {"CPU|Memory"}}, []string{""}, []int{1}},
+		{"include panel title", url.Values{ParamIncludePanelTitle: {"usage$"}}, []string{"CPU", "Memory"}, []int{3, 7}},
+		{"exclude panel title", url.Values{ParamExcludePanelTitle: {"^CPU"}}, []string{"", "Memory"}, []int{1, 7}},
+		{"exclude panel types", url.Values{ParamExcludePanelTypes: {"text,stat"}}, []string{"CPU", "Memory"}, []int{3, 5, 7}},
+		{"include panel types in row", url.Values{ParamIncludePanelTypes: {"TimeSeries"}, ParamIncludeRows: {"CPU"}}, []string{"CPU"}, []int{3, 5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selection, err := ParseSelection(tt.params)
+			if err != nil {
+				t.Fatalf("ParseSelection failed: %v", err)
+			}
+			sd := selectionTestDashboard(t)
+			sd.ApplySelection(selection)
+			if sd.Selection != selection {
+				t.Error("Selection is not saved to the structured dashboard")
+			}
+			var rows []string
+			var panels []int
+			for _, row := range sd.Rows {
+				rows = append(rows, row.Title)
+				for _, line := range row.Lines {
+					if line.GapBefore != 0 {
+						t.Errorf("GapBefore = %d; want 0", line.GapBefore)
+					}
+					for _, panel := range line.Panels {
+						panels = append(panels, panel.ID)
+					}
+				}
+			}
+			if len(rows) != len(tt.rows) {
+				t.Fatalf("Rows = %q; want %q", rows, tt.rows)
+			}
+			for i := range rows {
+				if rows[i] != tt.rows[i] {
+					t.Errorf("Rows = %q; want %q", rows, tt.rows)
+				}
+			}
+			if len(panels) != len(tt.panels) {
+				t.Fatalf("Panels = %v; want %v", panels, tt.panels)
+			}
+			for i := range panels {
+				if panels[i] != tt.panels[i] {
+					t.Errorf("Panels = %v; want %v", panels, tt.panels)
+				}
+			}
+		})
+	}
+}
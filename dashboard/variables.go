@@ -0,0 +1,57 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package dashboard
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const varPrefix = "var-"
+
+// regExpressionVariable matches $var, ${var}, ${var:format} and [[var]]
+var regExpressionVariable = regexp.MustCompile(`\$(\w+)|\$\{(\w+)(?::(\w+))?\}|\[\[(\w+)\]\]`)
+
+// InterpolateVariables replaces dashboard variables in the text by values from the report variables
+// passed as "var-<name>" parameters. Unknown variables are left as is.
+func InterpolateVariables(text string, vars url.Values) string {
+	return regExpressionVariable.ReplaceAllStringFunc(text, func(match string) string {
+		groups := regExpressionVariable.FindStringSubmatch(match)
+		name := groups[1] + groups[2] + groups[4]
+		values, ok := vars[varPrefix+name]
+		if !ok || len(values) == 0 {
+			return match
+		}
+		return formatVariable(values, groups[3])
+	})
+}
+
+func formatVariable(values []string, format string) string {
+	switch format {
+	case "csv", "raw":
+		return strings.Join(values, ",")
+	case "pipe":
+		return strings.Join(values, "|")
+	case "glob":
+		if len(values) == 1 {
+			return values[0]
+		}
+		return "{" + strings.Join(values, ",") + "}"
+	default:
+		return strings.Join(values, ", ")
+	}
+}
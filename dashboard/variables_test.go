@@ -0,0 +1,33 @@
+package dashboard
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestInterpolateVariables(t *testing.T) {
+	vars := url.Values{
+		"var-env":  {"prod"},
+		"var-host": {"a", "b"},
+	}
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"Environment: $env", "Environment: prod"},
+		{"${env}-1 and [[env]]", "prod-1 and prod"},
+		{"Hosts: $host", "Hosts: a, b"},
+		{"${host:csv} ${host:pipe} ${host:glob}", "a,b a|b {a,b}"},
+		{"Unknown $cluster and ${cluster}", "Unknown $cluster and ${cluster}"},
+		{"Costs 5$", "Costs 5$"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result := InterpolateVariables(tt.input, vars)
+			if result != tt.expected {
+				t.Errorf("InterpolateVariables(%q) = %q; want %q", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package dashboard
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// DashboardVersion is a saved version of the dashboard returned by Grafana dashboard versions API
+type DashboardVersion struct {
+	Version   int       `json:"version"`
+	Created   time.Time `json:"created"`
+	CreatedBy string    `json:"createdBy"`
+	Message   string    `json:"message"`
+	// Data is the dashboard model of the version, it is returned only for the request of one version
+	Data json.RawMessage `json:"data,omitempty"`
+	// location is the timezone of the report the time is printed in
+	location *time.Location
+}
+
+// DashboardVersionsPage is a page of the dashboard versions list. Grafana 11 returns the list in "versions"
+// with the token of the next page, older versions return the list as array.
+type DashboardVersionsPage struct {
+	Versions      []DashboardVersion `json:"versions"`
+	ContinueToken string             `json:"continueToken"`
+}
+
+// UnmarshalJSON reads both formats of the versions list
+func (p *DashboardVersionsPage) UnmarshalJSON(data []byte) error {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		p.ContinueToken = ""
+		return json.Unmarshal(trimmed, &p.Versions)
+	}
+	type page DashboardVersionsPage
+	return json.Unmarshal(data, (*page)(p))
+}
+
+// FindVersionAsOf returns the latest of versions saved at the time or before it
+func FindVersionAsOf(versions []DashboardVersion, asOf time.Time) (DashboardVersion, bool) {
+	var found DashboardVersion
+	ok := false
+	for _, version := range versions {
+		if !version.Created.After(asOf) && (!ok || version.Version > found.Version) {
+			found = version
+			ok = true
+		}
+	}
+	return found, ok
+}
+
+// ParseDashboardVersion builds structured dashboard from the response of the dashboard version API
+func ParseDashboardVersion(body []byte, rowsMode RowsMode, selection *Selection) (*StructuredDashboard, error) {
+	var version DashboardVersion
+	if err := json.Unmarshal(body, &version); err != nil {
+		return nil, err
+	}
+	if len(version.Data) == 0 {
+		return nil, fmt.Errorf("dashboard version %d does not contain dashboard model", version.Version)
+	}
+	// the model of the version is the same as the dashboard returned by dashboards API without meta
+	wrapped, err := json.Marshal(struct {
+		Dashboard json.RawMessage `json:"dashboard"`
+	}{version.Data})
+	if err != nil {
+		return nil, err
+	}
+	structured, err := ParseStructuredDashboard(wrapped, rowsMode, selection)
+	if err != nil {
+		return nil, err
+	}
+	if structured.Slug == "" {
+		structured.Slug = slugify(structured.Title)
+	}
+	version.Data = nil
+	structured.Version = &version
+	return structured, nil
+}
+
+// GetCreated returns the time when the version was saved
+func (v *DashboardVersion) GetCreated() string {
+	return formatReportTime(v.Created, v.location)
+}
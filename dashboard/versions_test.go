@@ -0,0 +1,78 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestDashboardVersionsPageUnmarshal(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		versions      int
+		continueToken string
+	}{
+		{"array", `[{"version": 3, "created": "2024-01-25T13:00:00Z"}, {"version": 2, "created": "2024-01-20T13:00:00Z"}]`, 2, ""},
+		{"object", `{"versions": [{"version": 3, "created": "2024-01-25T13:00:00Z"}], "continueToken": "next"}`, 1, "next"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var page DashboardVersionsPage
+			if err := json.Unmarshal([]byte(tt.body), &page); err != nil {
+				t.Fatalf("Unmarshal failed: %v", err)
+			}
+			if len(page.Versions) != tt.versions || page.ContinueToken != tt.continueToken || page.Versions[0].Version != 3 {
+				t.Errorf("Page = %+v; want %d versions and token %q", page, tt.versions, tt.continueToken)
+			}
+		})
+	}
+}
+
+func TestFindVersionAsOf(t *testing.T) {
+	versions := []DashboardVersion{
+		{Version: 3, Created: time.Date(2024, 1, 25, 13, 0, 0, 0, time.UTC)},
+		{Version: 2, Created: time.Date(2024, 1, 20, 13, 0, 0, 0, time.UTC)},
+		{Version: 1, Created: time.Date(2024, 1, 10, 13, 0, 0, 0, time.UTC)},
+	}
+	tests := []struct {
+		asOf     time.Time
+		expected int
+		ok       bool
+	}{
+		{time.Date(2024, 1, 26, 0, 0, 0, 0, time.UTC), 3, true},
+		{time.Date(2024, 1, 20, 13, 0, 0, 0, time.UTC), 2, true},
+		{time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), 1, true},
+		{time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 0, false},
+	}
+	for _, tt := range tests {
+		version, ok := FindVersionAsOf(versions, tt.asOf)
+		if ok != tt.ok || version.Version != tt.expected {
+			t.Errorf("FindVersionAsOf(%s) = %d, %t; want %d, %t", tt.asOf, version.Version, ok, tt.expected, tt.ok)
+		}
+	}
+}
+
+func TestParseDashboardVersion(t *testing.T) {
+	body := `{"id": 10, "uid": "v1-uid", "version": 2, "created": "2024-01-20T13:00:00Z", "createdBy": "admin", "message": "Add CPU",
+		"data": {"uid": "v1-uid", "title": "Old Title", "panels": [
+			{"id": 1, "type": "graph", "title": "CPU", "gridPos": {"h": 8, "w": 24, "x": 0, "y": 0}}
+		]}}`
+	sd, err := ParseDashboardVersion([]byte(body), RowsExpanded, nil)
+	if err != nil {
+		t.Fatalf("ParseDashboardVersion failed: %v", err)
+	}
+	if sd.UID != "v1-uid" || sd.Title != "Old Title" || sd.Slug != "old-title" {
+		t.Errorf("UID, Title, Slug = %s, %s, %s; want v1-uid, Old Title, old-title", sd.UID, sd.Title, sd.Slug)
+	}
+	if len(sd.Rows) != 1 || sd.Rows[0].Panels[0].Title != "CPU" {
+		t.Errorf("Rows = %+v; want one row with the panel CPU", sd.Rows)
+	}
+	if sd.Version == nil || sd.Version.Version != 2 || sd.Version.CreatedBy != "admin" || sd.Version.GetCreated() != "2024-01-20 13:00:00 UTC" || sd.Version.Data != nil {
+		t.Errorf("Version = %+v; want version 2 without data", sd.Version)
+	}
+
+	if _, err = ParseDashboardVersion([]byte(`{"version": 2}`), RowsExpanded, nil); err == nil {
+		t.Error("Expected error for the version without data, got nil")
+	}
+}
@@ -40,6 +40,99 @@ const docTemplate = `{
                 }
             }
         },
+        "/api/v1/report": {
+            "get": {
+                "description": "Generate report of the dashboard URL copied from the browser with the same time range, variables, organization, time zone and the panel in view mode. Other parameters are the same as for the report of the dashboard UID",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "Generate"
+                ],
+                "summary": "Generate report of Grafana dashboard URL",
+                "operationId": "generateReportFromURL",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authentication header",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Grafana dashboard URL, for example https://grafana/d/uid/slug?orgId=1\u0026from=now-6h\u0026to=now\u0026var-env=prod",
+                        "name": "url",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "PDF tex template name",
+                        "name": "template",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "expanded",
+                            "collapsed",
+                            "all"
+                        ],
+                        "type": "string",
+                        "description": "Rows to include: expanded, collapsed or all",
+                        "name": "rows",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "If true, the summary of alert rules linked to the dashboard panels is included to the report",
+                        "name": "alerts",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "fail",
+                            "placeholder",
+                            "skip"
+                        ],
+                        "type": "string",
+                        "description": "Policy of panels that are not rendered: fail the report, replace them with placeholders or skip them",
+                        "name": "onPanelError",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        },
+                        "headers": {
+                            "Report-Failed-Panels": {
+                                "type": "int",
+                                "description": "Number of panels that are not rendered"
+                            },
+                            "Report-Status": {
+                                "type": "string",
+                                "description": "complete or partial if some panels are not rendered"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
         "/api/v1/report/{dashboard_uid}": {
             "get": {
                 "description": "Generate Grafana dashboard report in PDF file. You can set time range, tex template and other parameters ` + "`" + `var-` + "`" + ` from Grafana",
@@ -83,6 +176,162 @@ const docTemplate = `{
                         "description": "The end of time range",
                         "name": "to",
                         "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "expanded",
+                            "collapsed",
+                            "all"
+                        ],
+                        "type": "string",
+                        "description": "Rows to include: expanded, collapsed or all",
+                        "name": "rows",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Deprecated: use rows. If true, only collapsed rows are rendered",
+                        "name": "renderCollapsed",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "IDs of panels to include separated by comma",
+                        "name": "includePanels",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "IDs of panels to exclude separated by comma",
+                        "name": "excludePanels",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Regular expression of titles of panels to include",
+                        "name": "includePanelTitle",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Regular expression of titles of panels to exclude",
+                        "name": "excludePanelTitle",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Regular expression of titles of rows to include",
+                        "name": "includeRows",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Regular expression of titles of rows to exclude",
+                        "name": "excludeRows",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Types of panels to include separated by comma",
+                        "name": "includePanelTypes",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Types of panels to exclude separated by comma, for example text,news,dashlist",
+                        "name": "excludePanelTypes",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "If true, the summary of alert rules linked to the dashboard panels is included to the report",
+                        "name": "alerts",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Grafana organization of the dashboard",
+                        "name": "orgId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Timezone of the time range: IANA name, utc or offset like +03:00, by default the timezone of the dashboard",
+                        "name": "timezone",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Interval like 1w to compare the time range with the time range shifted back by the interval",
+                        "name": "compare",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "The start of the baseline time range to compare the time range with",
+                        "name": "compareFrom",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "The end of the baseline time range to compare the time range with",
+                        "name": "compareTo",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "side",
+                            "stacked"
+                        ],
+                        "type": "string",
+                        "description": "Layout of panels of the comparison report",
+                        "name": "compareLayout",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Width of the screen in pixels the panels are rendered on, by default the width of the template or 1920",
+                        "name": "width",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Device scale factor of panel images from 1 to 4 for high resolution prints",
+                        "name": "scale",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "light",
+                            "dark"
+                        ],
+                        "type": "string",
+                        "description": "Theme of panels, by default the theme of the template or light",
+                        "name": "theme",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "fail",
+                            "placeholder",
+                            "skip"
+                        ],
+                        "type": "string",
+                        "description": "Policy of panels that are not rendered: fail the report, replace them with placeholders or skip them",
+                        "name": "onPanelError",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Version of the dashboard to render the report from",
+                        "name": "version",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Time to render the report from the dashboard version saved at that time: RFC 3339 date, timestamp or Grafana relative time",
+                        "name": "asOf",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -90,6 +339,16 @@ const docTemplate = `{
                         "description": "OK",
                         "schema": {
                             "type": "string"
+                        },
+                        "headers": {
+                            "Report-Failed-Panels": {
+                                "type": "int",
+                                "description": "Number of panels that are not rendered"
+                            },
+                            "Report-Status": {
+                                "type": "string",
+                                "description": "complete or partial if some panels are not rendered"
+                            }
                         }
                     },
                     "400": {
@@ -148,6 +407,162 @@ const docTemplate = `{
                         "description": "The end of time range",
                         "name": "to",
                         "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "expanded",
+                            "collapsed",
+                            "all"
+                        ],
+                        "type": "string",
+                        "description": "Rows to include: expanded, collapsed or all",
+                        "name": "rows",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Deprecated: use rows. If true, only collapsed rows are rendered",
+                        "name": "renderCollapsed",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "IDs of panels to include separated by comma",
+                        "name": "includePanels",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "IDs of panels to exclude separated by comma",
+                        "name": "excludePanels",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Regular expression of titles of panels to include",
+                        "name": "includePanelTitle",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Regular expression of titles of panels to exclude",
+                        "name": "excludePanelTitle",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Regular expression of titles of rows to include",
+                        "name": "includeRows",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Regular expression of titles of rows to exclude",
+                        "name": "excludeRows",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Types of panels to include separated by comma",
+                        "name": "includePanelTypes",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Types of panels to exclude separated by comma, for example text,news,dashlist",
+                        "name": "excludePanelTypes",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "If true, the summary of alert rules linked to the dashboard panels is included to the report",
+                        "name": "alerts",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Grafana organization of the dashboard",
+                        "name": "orgId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Timezone of the time range: IANA name, utc or offset like +03:00, by default the timezone of the dashboard",
+                        "name": "timezone",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Interval like 1w to compare the time range with the time range shifted back by the interval",
+                        "name": "compare",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "The start of the baseline time range to compare the time range with",
+                        "name": "compareFrom",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "The end of the baseline time range to compare the time range with",
+                        "name": "compareTo",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "side",
+                            "stacked"
+                        ],
+                        "type": "string",
+                        "description": "Layout of panels of the comparison report",
+                        "name": "compareLayout",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Width of the screen in pixels the panels are rendered on, by default the width of the template or 1920",
+                        "name": "width",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Device scale factor of panel images from 1 to 4 for high resolution prints",
+                        "name": "scale",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "light",
+                            "dark"
+                        ],
+                        "type": "string",
+                        "description": "Theme of panels, by default the theme of the template or light",
+                        "name": "theme",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "fail",
+                            "placeholder",
+                            "skip"
+                        ],
+                        "type": "string",
+                        "description": "Policy of panels that are not rendered: fail the report, replace them with placeholders or skip them",
+                        "name": "onPanelError",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Version of the dashboard to render the report from",
+                        "name": "version",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Time to render the report from the dashboard version saved at that time: RFC 3339 date, timestamp or Grafana relative time",
+                        "name": "asOf",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -155,6 +570,16 @@ const docTemplate = `{
                         "description": "OK",
                         "schema": {
                             "type": "string"
+                        },
+                        "headers": {
+                            "Report-Failed-Panels": {
+                                "type": "int",
+                                "description": "Number of panels that are not rendered"
+                            },
+                            "Report-Status": {
+                                "type": "string",
+                                "description": "complete or partial if some panels are not rendered"
+                            }
                         }
                     },
                     "400": {
@@ -172,27 +597,169 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/report/{template}": {
+        "/api/v1/report/{dashboard_uid}/panel/{panel_id}": {
             "get": {
-                "description": "Get tex template by name",
+                "description": "Render one panel of the dashboard to PNG image or to one page PDF report with the panel title, time range and variables",
                 "produces": [
-                    "application/json"
+                    "application/octet-stream"
                 ],
                 "tags": [
-                    "General"
+                    "Generate"
                 ],
-                "summary": "Get tex template by name",
-                "operationId": "getTexTemplate",
+                "summary": "Generate report of one panel",
+                "operationId": "generatePanelReport",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "PDF tex template name",
-                        "name": "template",
+                        "description": "Authentication header",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Dashboard UID",
+                        "name": "dashboard_uid",
                         "in": "path",
                         "required": true
-                    }
-                ],
-                "responses": {
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Panel ID",
+                        "name": "panel_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "pdf",
+                            "png"
+                        ],
+                        "type": "string",
+                        "description": "Format of the result",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Width of the panel image in pixels, by default the width of the panel on the dashboard",
+                        "name": "width",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Height of the panel image in pixels, by default the height of the panel on the dashboard",
+                        "name": "height",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Device scale factor of the panel image from 1 to 4, width*height*scale² must not exceed 50000000 pixels",
+                        "name": "scale",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "light",
+                            "dark"
+                        ],
+                        "type": "string",
+                        "description": "Theme of the panel, by default the theme of the template or light",
+                        "name": "theme",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "PDF tex template name",
+                        "name": "template",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "The start of time range",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "The end of time range",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Grafana organization of the dashboard",
+                        "name": "orgId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Timezone of the time range: IANA name, utc or offset like +03:00, by default the timezone of the dashboard",
+                        "name": "timezone",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Version of the dashboard to take the panel from",
+                        "name": "version",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Time to take the panel from the dashboard version saved at that time",
+                        "name": "asOf",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/report/{template}": {
+            "get": {
+                "description": "Get tex template by name",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "General"
+                ],
+                "summary": "Get tex template by name",
+                "operationId": "getTexTemplate",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "PDF tex template name",
+                        "name": "template",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
@@ -217,6 +784,331 @@ const docTemplate = `{
                 }
             }
         },
+        "/api/v1/reports": {
+            "get": {
+                "description": "Generate one PDF file for all dashboards found in the folders or by the tags. Every dashboard is a section of the report with the same time range and variables ` + "`" + `var-` + "`" + `",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "Generate"
+                ],
+                "summary": "Generate report of several Grafana dashboards",
+                "operationId": "generateMultiReport",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authentication header",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "UIDs of folders to search dashboards in separated by comma",
+                        "name": "folderUID",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Tags of dashboards separated by comma, dashboards must have all the tags",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "multiTemplate",
+                        "description": "PDF tex template name",
+                        "name": "template",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "The start of time range",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "The end of time range",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "expanded",
+                            "collapsed",
+                            "all"
+                        ],
+                        "type": "string",
+                        "description": "Rows to include: expanded, collapsed or all",
+                        "name": "rows",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Regular expression of titles of panels to include",
+                        "name": "includePanelTitle",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Regular expression of titles of panels to exclude",
+                        "name": "excludePanelTitle",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Regular expression of titles of rows to include",
+                        "name": "includeRows",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Regular expression of titles of rows to exclude",
+                        "name": "excludeRows",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Types of panels to include separated by comma",
+                        "name": "includePanelTypes",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Types of panels to exclude separated by comma, for example text,news,dashlist",
+                        "name": "excludePanelTypes",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "If true, the summary of alert rules linked to the dashboard panels is included to the report",
+                        "name": "alerts",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Grafana organization of the dashboards",
+                        "name": "orgId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Timezone of the time range: IANA name, utc or offset like +03:00, by default the timezone of the dashboard",
+                        "name": "timezone",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Width of the screen in pixels the panels are rendered on, by default the width of the template or 1920",
+                        "name": "width",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Device scale factor of panel images from 1 to 4 for high resolution prints",
+                        "name": "scale",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "light",
+                            "dark"
+                        ],
+                        "type": "string",
+                        "description": "Theme of panels, by default the theme of the template or light",
+                        "name": "theme",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "fail",
+                            "placeholder",
+                            "skip"
+                        ],
+                        "type": "string",
+                        "description": "Policy of panels that are not rendered: fail the report, replace them with placeholders or skip them",
+                        "name": "onPanelError",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        },
+                        "headers": {
+                            "Report-Failed-Panels": {
+                                "type": "int",
+                                "description": "Number of panels that are not rendered"
+                            },
+                            "Report-Status": {
+                                "type": "string",
+                                "description": "complete or partial if some panels are not rendered"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Generate one PDF file from the report definition in YAML or JSON format. The definition lists dashboards and optionally their panels and rows with time range and variables of each section. Query parameters are used if they are not set in the definition",
+                "consumes": [
+                    "application/json",
+                    "application/x-yaml"
+                ],
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "Generate"
+                ],
+                "summary": "Generate report of panels from several Grafana dashboards",
+                "operationId": "generateCompositeReport",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authentication header",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Report definition in YAML or JSON format",
+                        "name": "definition",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "default": "multiTemplate",
+                        "description": "PDF tex template name",
+                        "name": "template",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "The start of time range",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "The end of time range",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "expanded",
+                            "collapsed",
+                            "all"
+                        ],
+                        "type": "string",
+                        "description": "Rows to include: expanded, collapsed or all",
+                        "name": "rows",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "If true, the summary of alert rules linked to the dashboard panels is included to the report",
+                        "name": "alerts",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Grafana organization of the dashboards",
+                        "name": "orgId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Timezone of the time range: IANA name, utc or offset like +03:00, by default the timezone of the dashboard",
+                        "name": "timezone",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Width of the screen in pixels the panels are rendered on, by default the width of the template or 1920",
+                        "name": "width",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Device scale factor of panel images from 1 to 4 for high resolution prints",
+                        "name": "scale",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "light",
+                            "dark"
+                        ],
+                        "type": "string",
+                        "description": "Theme of panels, by default the theme of the template or light",
+                        "name": "theme",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "fail",
+                            "placeholder",
+                            "skip"
+                        ],
+                        "type": "string",
+                        "description": "Policy of panels that are not rendered: fail the report, replace them with placeholders or skip them",
+                        "name": "onPanelError",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        },
+                        "headers": {
+                            "Report-Failed-Panels": {
+                                "type": "int",
+                                "description": "Number of panels that are not rendered"
+                            },
+                            "Report-Status": {
+                                "type": "string",
+                                "description": "complete or partial if some panels are not rendered"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
         "/api/v1/templates": {
             "get": {
                 "description": "Get names of all available tex templates",
@@ -17,12 +17,15 @@ package handle
 
 import (
 	"crypto/tls"
+	"github.com/Netcracker/grafana-reporter/dashboard"
 	"github.com/Netcracker/grafana-reporter/report"
+	"github.com/Netcracker/grafana-reporter/timerange"
 	"log/slog"
 	"net/http"
+	"strings"
 )
 
-func RegisterEndpoints(addr, credentialsFile string, templates map[string][]byte, defaultTemplate, defaultFrom, defaultTo string, renderCollapsed bool, tlsConfig *tls.Config) http.Handler {
+func RegisterEndpoints(addr, credentialsFile string, templates map[string][]byte, defaultTemplate, defaultFrom, defaultTo string, rowsMode dashboard.RowsMode, orgID int, calendar *timerange.Calendar, tlsConfig *tls.Config) http.Handler {
 	slog.Debug("Registering handlers...")
 	mux := http.NewServeMux()
 
@@ -35,15 +38,31 @@ func RegisterEndpoints(addr, credentialsFile string, templates map[string][]byte
 		DefaultTo:       defaultTo,
 		Endpoint:        addr,
 		Credentials:     credentialsFile,
-		RenderCollapsed: renderCollapsed,
+		RowsMode:        rowsMode,
+		OrgID:           orgID,
+		Calendar:        calendar,
 		Client: http.Client{
 			Timeout:   0,
 			Transport: transportConf,
 		},
 	}
+	mux.HandleFunc("/api/v1/report", func(writer http.ResponseWriter, request *http.Request) {
+		GrafanaInstance.HandleGenerateReportFromURL(writer, request)
+	})
 	mux.HandleFunc("/api/v1/report/", func(writer http.ResponseWriter, request *http.Request) {
+		if strings.Contains(strings.TrimPrefix(request.URL.Path, "/api/v1/report/"), "/") {
+			GrafanaInstance.HandleGeneratePanelReport(writer, request)
+			return
+		}
 		GrafanaInstance.HandleGenerateReport(writer, request)
 	})
+	mux.HandleFunc("/api/v1/reports", func(writer http.ResponseWriter, request *http.Request) {
+		if request.Method == http.MethodPost {
+			GrafanaInstance.HandleGenerateCompositeReport(writer, request)
+			return
+		}
+		GrafanaInstance.HandleGenerateMultiReport(writer, request)
+	})
 	mux.HandleFunc("/api/v1/templates", func(writer http.ResponseWriter, request *http.Request) {
 		GrafanaInstance.HandleGetTemplatesList(writer)
 	})
@@ -53,6 +72,7 @@ func RegisterEndpoints(addr, credentialsFile string, templates map[string][]byte
 	mux.HandleFunc("/api/v1/defaults", func(writer http.ResponseWriter, request *http.Request) {
 		GrafanaInstance.HandleGetDefaultParameters(writer)
 	})
+	mux.HandleFunc("/metrics", report.HandleRenderMetrics)
 
 	return mux
 }
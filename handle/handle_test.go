@@ -5,6 +5,8 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	"github.com/Netcracker/grafana-reporter/dashboard"
 )
 
 func TestRegisterEndpoints(t *testing.T) {
@@ -16,10 +18,10 @@ func TestRegisterEndpoints(t *testing.T) {
 	defaultTemplate := "template1"
 	defaultFrom := "now-1h"
 	defaultTo := "now"
-	renderCollapsed := false
+	rowsMode := dashboard.RowsExpanded
 	tlsConfig := &tls.Config{}
 
-	handler := RegisterEndpoints(addr, credentialsFile, templates, defaultTemplate, defaultFrom, defaultTo, renderCollapsed, tlsConfig)
+	handler := RegisterEndpoints(addr, credentialsFile, templates, defaultTemplate, defaultFrom, defaultTo, rowsMode, 0, nil, tlsConfig)
 
 	if handler == nil {
 		t.Error("RegisterEndpoints returned nil handler")
@@ -25,15 +25,20 @@ import (
 	"log/slog"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
 	"path"
 	"path/filepath"
 	"syscall"
 	"time"
+	// timezones are embedded as the image may have no timezone database
+	_ "time/tzdata"
 
+	"github.com/Netcracker/grafana-reporter/dashboard"
 	"github.com/Netcracker/grafana-reporter/handle"
 	"github.com/Netcracker/grafana-reporter/report"
+	"github.com/Netcracker/grafana-reporter/timerange"
 )
 
 // @title				Grafana Reporter REST API
@@ -51,10 +56,12 @@ func main() {
 	port := flag.String("port", ":8881", "Application port")
 	grafanaAddress := flag.String("grafana", "http://grafana-service:3000", "Grafana endpoint to get dashboard information from")
 	credentialsFile := flag.String("credentials", "/grafana/auth/credentials.yaml", "Path to yaml file that contains credentials for Grafana (for basic or token authentication)")
-	renderCollapsed := flag.Bool("renderCollapsed", false, "Enable rendering collapsed panels. If true, all collapsed panels will be expanded and rendered")
+	renderCollapsed := flag.Bool("renderCollapsed", false, "Deprecated: use rows. If true, only collapsed rows are rendered")
+	rows := flag.String("rows", "", "Rows of the dashboard to render by default: expanded, collapsed or all")
+	orgID := flag.Int("orgId", 0, "Grafana organization of dashboards by default. The organization of the user is used if it is not set")
 	defaultTemplate := flag.String("template", "gridTemplate", "Tex Template name to layout panels by default")
-	defaultFrom := flag.String("defaultFrom", "now-30m", "Default time range will be used if the parameter is not set in request parameters")
-	defaultTo := flag.String("defaultTo", "now", "Default time range will be used if the parameter is not set in request parameters")
+	defaultFrom := flag.String("defaultFrom", "now-30m", "Default time range will be used if the parameter is not set in request parameters and the dashboard has no time range")
+	defaultTo := flag.String("defaultTo", "now", "Default time range will be used if the parameter is not set in request parameters and the dashboard has no time range")
 	templatesPath := flag.String("templates", "templates", "Default templates path")
 	customTemplatesPath := flag.String("customTemplates", "templates/custom", "Custom templates path")
 	insecureSkipVerify := flag.Bool("insecureSkipVerify", true, "Verify Grafana certificates or not")
@@ -62,11 +69,46 @@ func main() {
 	crt := flag.String("cert", "/grafana/certificates/cert.crt", "Name of public Certificate file. It should be mounted in /grafana/certificates/ directory")
 	pKey := flag.String("pKey", "/grafana/certificates/cert.key", "Name of private key file. It should be mounted in /grafana/certificates/ directory")
 	dashboardUID := flag.String("dashboard", "", "Dashboard UID to generate report.")
+	dashboardURL := flag.String("url", "", "Grafana dashboard URL copied from the browser to generate report with its time range, variables and panel in view mode")
 	// parameters only for command line execution
 	vars := flag.String("vars", "", "All variables separated by `&`")
+	periods := flag.String("periods", "", "Path to YAML file with custom named periods of the time range")
+	holidays := flag.String("holidays", "", "Path to YAML file with the holiday calendar of business-day periods")
+	// parameters of the report named as parameters of the request
+	reportFlags := map[string]*string{
+		"from":          flag.String("from", "", "The start of time range. The time range of the dashboard or defaultFrom is used if it is not set"),
+		"to":            flag.String("to", "", "The end of time range. The time range of the dashboard or defaultTo is used if it is not set"),
+		"compare":       flag.String("compare", "", "Interval like 1w to compare the time range with the time range shifted back by the interval"),
+		"compareFrom":   flag.String("compareFrom", "", "The start of the baseline time range to compare the time range with"),
+		"compareTo":     flag.String("compareTo", "", "The end of the baseline time range to compare the time range with"),
+		"compareLayout": flag.String("compareLayout", "", "Layout of panels of the comparison report: side or stacked"),
+		"width":         flag.String("width", "", "Width of the screen in pixels the panels are rendered on. The width of the template or 1920 is used if it is not set"),
+		"scale":         flag.String("scale", "", "Device scale factor of panel images from 1 to 4 for high resolution prints"),
+		"theme":         flag.String("theme", "", "Theme of panels: light or dark. The theme of the template or light is used if it is not set"),
+		"onPanelError":  flag.String("onPanelError", "", "Policy of panels that are not rendered: fail, placeholder or skip. The report fails if it is not set"),
+		"timezone":      flag.String("timezone", "", "Timezone of the time range: IANA name, utc or offset like +03:00. The timezone of the dashboard is used if it is not set"),
+		"version":       flag.String("version", "", "Version of the dashboard to render the report from"),
+		"asOf":          flag.String("asOf", "", "Time to render the report from the dashboard version saved at that time: RFC 3339 date, timestamp or Grafana relative time"),
+	}
 	user := flag.String("user", "", "Credentials for Grafana user")
 	password := flag.String("password", "", "Credentials for Grafana user")
 	token := flag.String("token", "", "Credentials for Grafana user")
+	selectionFlags := map[string]*string{
+		dashboard.ParamIncludePanels:     flag.String(dashboard.ParamIncludePanels, "", "IDs of panels to include separated by comma"),
+		dashboard.ParamExcludePanels:     flag.String(dashboard.ParamExcludePanels, "", "IDs of panels to exclude separated by comma"),
+		dashboard.ParamIncludePanelTitle: flag.String(dashboard.ParamIncludePanelTitle, "", "Regular expression of titles of panels to include"),
+		dashboard.ParamExcludePanelTitle: flag.String(dashboard.ParamExcludePanelTitle, "", "Regular expression of titles of panels to exclude"),
+		dashboard.ParamIncludeRows:       flag.String(dashboard.ParamIncludeRows, "", "Regular expression of titles of rows to include"),
+		dashboard.ParamExcludeRows:       flag.String(dashboard.ParamExcludeRows, "", "Regular expression of titles of rows to exclude"),
+		dashboard.ParamIncludePanelTypes: flag.String(dashboard.ParamIncludePanelTypes, "", "Types of panels to include separated by comma"),
+		dashboard.ParamExcludePanelTypes: flag.String(dashboard.ParamExcludePanelTypes, "", "Types of panels to exclude separated by comma, for example text,news,dashlist"),
+	}
+	searchFlags := map[string]*string{
+		dashboard.ParamFolderUID: flag.String(dashboard.ParamFolderUID, "", "UIDs of folders separated by comma to make one report of their dashboards if dashboard is not set"),
+		dashboard.ParamTag:       flag.String(dashboard.ParamTag, "", "Tags separated by comma to make one report of dashboards with all the tags if dashboard is not set"),
+	}
+	definition := flag.String("definition", "", "Path to YAML or JSON file with the report definition: dashboards, their panels and rows")
+	alerts := flag.Bool("alerts", false, "If true, the summary of alert rules linked to the dashboard panels is included to the report")
 
 	httpServiceMode := flag.Bool("httpServiceMode", false, "Mode of the application. It can be run as HTTP service or make one report and return")
 	flag.Parse()
@@ -76,7 +118,12 @@ func main() {
 	slog.SetDefault(logger)
 
 	slog.Info(fmt.Sprintf("Grafana address: %s", *grafanaAddress))
-	slog.Debug(fmt.Sprintf("The parameter renderCollapsed is %t", *renderCollapsed))
+	rowsMode, err := getRowsMode(*rows, *renderCollapsed)
+	if err != nil {
+		slog.Error(fmt.Sprintf("Error happened when parsing parameter rows: %s", err))
+		os.Exit(1)
+	}
+	slog.Debug(fmt.Sprintf("The parameter rows is %s", rowsMode))
 
 	templates, err := readTemplates(*defaultTemplate, *templatesPath, *customTemplatesPath)
 	if err != nil {
@@ -84,6 +131,12 @@ func main() {
 		os.Exit(1)
 	}
 
+	calendar, err := readCalendar(*periods, *holidays)
+	if err != nil {
+		slog.Error(fmt.Sprintf("Error happened when reading periods and holiday calendar: %s", err))
+		os.Exit(1)
+	}
+
 	tlsConfig, err := getTLSConfig(*insecureSkipVerify, *ca, *crt, *pKey)
 	if err != nil {
 		slog.Error(fmt.Sprintf("Error happened when getting TLS certificates. Error: %s", err))
@@ -94,7 +147,7 @@ func main() {
 		srvBaseCtx := context.WithValue(baseCtx, ContextKey, ContextMain)
 		srv := &http.Server{
 			Addr:              *port,
-			Handler:           handle.RegisterEndpoints(*grafanaAddress, *credentialsFile, templates, *defaultTemplate, *defaultFrom, *defaultTo, *renderCollapsed, tlsConfig),
+			Handler:           handle.RegisterEndpoints(*grafanaAddress, *credentialsFile, templates, *defaultTemplate, *defaultFrom, *defaultTo, rowsMode, *orgID, calendar, tlsConfig),
 			TLSConfig:         nil,
 			ReadHeaderTimeout: time.Second * 15,
 			WriteTimeout:      time.Minute * 15,
@@ -121,7 +174,9 @@ func main() {
 		<-stop
 		slog.Info("Stopping application")
 
-		if err = Shutdown(srvBaseCtx, 30*time.Second,
+		// reports in progress are cancelled, so the server does not wait for their panels and pdflatex
+		cancel()
+		if err = Shutdown(context.WithoutCancel(srvBaseCtx), 30*time.Second,
 			func(ctx context.Context) {
 				if err = srv.Shutdown(ctx); err != nil {
 					slog.Error("Failed to shut down HTTP server gracefully in time", "error", err)
@@ -133,7 +188,41 @@ func main() {
 			slog.Error("Failed to shutdown gracefully", "error", err)
 		}
 	} else {
-		err = report.RunGenerateReport(*grafanaAddress, *credentialsFile, *dashboardUID, *vars, templates, *defaultTemplate, *defaultFrom, *defaultTo, *renderCollapsed, tlsConfig, *user, *password, *token)
+		query := url.Values{}
+		for _, flags := range []map[string]*string{reportFlags, selectionFlags, searchFlags} {
+			for name, value := range flags {
+				if *value != "" {
+					query.Set(name, *value)
+				}
+			}
+		}
+		if *alerts {
+			query.Set("alerts", "true")
+		}
+		options := &report.CommandLineOptions{
+			Endpoint:        *grafanaAddress,
+			Credentials:     *credentialsFile,
+			DefaultTemplate: *defaultTemplate,
+			Templates:       templates,
+			DefaultFrom:     *defaultFrom,
+			DefaultTo:       *defaultTo,
+			RowsMode:        rowsMode,
+			OrgID:           *orgID,
+			Calendar:        calendar,
+			TLSConfig:       tlsConfig,
+			DashboardUID:    *dashboardUID,
+			DashboardURL:    *dashboardURL,
+			Variables:       *vars,
+			DefinitionFile:  *definition,
+			Query:           query,
+			User:            *user,
+			Password:        *password,
+			Token:           *token,
+		}
+		// the report is cancelled and pdflatex is killed on interrupt
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		err = report.RunGenerateReport(ctx, options)
+		stop()
 		if err != nil {
 			slog.Error(fmt.Sprintf("Error occurred while generating report: %s", err))
 			os.Exit(1)
@@ -192,6 +281,14 @@ func getTLSConfig(insecureSkipVerify bool, ca string, crt string, pKey string) (
 	return tlsConf, nil
 }
 
+// getRowsMode returns rows mode set by the flag rows or by the deprecated flag renderCollapsed
+func getRowsMode(rows string, renderCollapsed bool) (dashboard.RowsMode, error) {
+	if rows == "" && renderCollapsed {
+		return dashboard.RowsCollapsed, nil
+	}
+	return dashboard.ParseRowsMode(rows)
+}
+
 func getLogLevel(logLevel string) slog.Level {
 	var lvl slog.LevelVar
 	if err := lvl.UnmarshalText([]byte(logLevel)); err != nil {
@@ -200,6 +297,23 @@ func getLogLevel(logLevel string) slog.Level {
 	return lvl.Level()
 }
 
+// readCalendar reads custom named periods and the holiday calendar, the files are optional
+func readCalendar(periodsFile string, holidaysFile string) (*timerange.Calendar, error) {
+	var periods, holidays []byte
+	var err error
+	if periodsFile != "" {
+		if periods, err = os.ReadFile(periodsFile); err != nil {
+			return nil, err
+		}
+	}
+	if holidaysFile != "" {
+		if holidays, err = os.ReadFile(holidaysFile); err != nil {
+			return nil, err
+		}
+	}
+	return timerange.NewCalendar(periods, holidays)
+}
+
 // readTemplate reads all templates from directories to map
 func readTemplates(defaultTemplate string, templatesPath string, customTemplatesPath string) (map[string][]byte, error) {
 	var templates = map[string][]byte{}
@@ -0,0 +1,58 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package report
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+
+	"github.com/Netcracker/grafana-reporter/dashboard"
+)
+
+const alertHistoryLimit = 1000
+
+// addAlerts requests alert rules of the dashboard and their state changes in the report time range
+// and adds the alert summary to the dashboard. Errors are logged and do not fail the report.
+func (g *GrafanaInstance) addAlerts(ctx context.Context, structuredDashboard *dashboard.StructuredDashboard, params *reportParameters) {
+	rulesURL, err := url.JoinPath(g.Endpoint, "/api/prometheus/grafana/api/v1/rules")
+	if err != nil {
+		slog.Warn(fmt.Sprintf("Could not create URL for alert rules: %v", err))
+		return
+	}
+	rulesURL = fmt.Sprintf("%s?%s", rulesURL, url.Values{"dashboard_uid": {structuredDashboard.UID}}.Encode())
+	var rules dashboard.AlertRulesResponse
+	if err = g.requestJSON(ctx, rulesURL, params.AuthHeader, params.OrgID, &rules); err != nil {
+		slog.Warn(fmt.Sprintf("Could not get alert rules, the alerts summary is not included to the report: %v", err))
+		return
+	}
+
+	var history []dashboard.Annotation
+	historyURL, err := getAnnotationsURL(g.Endpoint, structuredDashboard.UID,
+		dashboard.AnnotationQuery{Type: dashboard.AnnotationsOfAlerts, Limit: alertHistoryLimit}, params.Timerange)
+	if err == nil {
+		err = g.requestJSON(ctx, historyURL, params.AuthHeader, params.OrgID, &history)
+	}
+	if err != nil {
+		slog.Warn(fmt.Sprintf("Could not get history of alert states, only current states are included to the report: %v", err))
+		history = nil
+	}
+
+	summary := dashboard.NewAlertSummary(&rules, structuredDashboard.UID, history, params.Timerange.DateFrom, params.Timerange.DateTo)
+	slog.Debug(fmt.Sprintf("Received %d alert rules and %d alert state changes", len(summary.Rules), len(history)))
+	structuredDashboard.SetAlerts(summary)
+}
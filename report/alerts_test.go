@@ -0,0 +1,57 @@
+package report
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Netcracker/grafana-reporter/dashboard"
+	"github.com/Netcracker/grafana-reporter/timerange"
+)
+
+func TestAddAlerts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/prometheus/grafana/api/v1/rules":
+			if r.URL.Query().Get("dashboard_uid") != "uid" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_, _ = w.Write([]byte(`{"data": {"groups": [{"rules": [{"name": "High CPU", "state": "inactive", "health": "ok",
+				"annotations": {"__dashboardUid__": "uid", "__panelId__": "1"}}]}]}}`))
+		case "/api/annotations":
+			if r.URL.Query().Get("type") != "alert" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_, _ = w.Write([]byte(`[{"id": 1, "alertName": "High CPU", "panelId": 1, "newState": "Alerting", "time": ` +
+				`1706190192000}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	g := &GrafanaInstance{Endpoint: server.URL}
+	entity := &dashboard.Entity{Dashboard: dashboard.Dashboard{UID: "uid", Panels: []dashboard.Panel{
+		{ID: 1, Title: "CPU", GridPos: dashboard.GridPos{H: 8, W: 24}},
+	}}}
+	sd, err := entity.GetStructuredDashboard(dashboard.RowsExpanded)
+	if err != nil {
+		t.Fatalf("GetStructuredDashboard failed: %v", err)
+	}
+	params := &reportParameters{Timerange: &timerange.TimerangeData{
+		DateFrom: time.UnixMilli(1706190192000).Add(-time.Hour),
+		DateTo:   time.UnixMilli(1706190192000).Add(time.Hour),
+	}}
+	g.addAlerts(context.Background(), sd, params)
+
+	if sd.Alerts == nil || len(sd.Alerts.Rules) != 1 {
+		t.Fatalf("Alerts = %+v; want one rule", sd.Alerts)
+	}
+	if rule := sd.Alerts.Rules[0]; rule.Panel != "CPU" || rule.FiringInstances != 1 || !sd.Rows[0].Panels[0].Firing {
+		t.Errorf("Rule = %+v; want rule of the panel CPU fired once", rule)
+	}
+}
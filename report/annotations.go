@@ -0,0 +1,99 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/Netcracker/grafana-reporter/dashboard"
+	"github.com/Netcracker/grafana-reporter/timerange"
+)
+
+// addAnnotations requests annotations of the dashboard in the report time range and adds them to the dashboard.
+// Annotations are an appendix of the report, so errors are logged and do not fail the report.
+func (g *GrafanaInstance) addAnnotations(ctx context.Context, structuredDashboard *dashboard.StructuredDashboard, params *reportParameters) {
+	for _, query := range structuredDashboard.AnnotationQueries {
+		urlString, err := getAnnotationsURL(g.Endpoint, structuredDashboard.UID, query, params.Timerange)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Could not create URL for annotations %q: %v", query.Name, err))
+			continue
+		}
+		var annotations []dashboard.Annotation
+		if err = g.requestJSON(ctx, urlString, params.AuthHeader, params.OrgID, &annotations); err != nil {
+			slog.Warn(fmt.Sprintf("Could not get annotations %q, they are not included to the report: %v", query.Name, err))
+			continue
+		}
+		slog.Debug(fmt.Sprintf("Received %d annotations %q", len(annotations), query.Name))
+		structuredDashboard.AddAnnotations(annotations)
+	}
+}
+
+func getAnnotationsURL(grafanaEndpoint string, dashboardUID string, query dashboard.AnnotationQuery, timerangeData *timerange.TimerangeData) (string, error) {
+	urlString, err := url.JoinPath(grafanaEndpoint, "/api/annotations")
+	if err != nil {
+		return "", err
+	}
+	params := url.Values{}
+	params.Add("from", strconv.FormatInt(timerangeData.DateFrom.UnixMilli(), 10))
+	params.Add("to", strconv.FormatInt(timerangeData.DateTo.UnixMilli(), 10))
+	params.Add("limit", strconv.Itoa(query.Limit))
+	switch query.Type {
+	case dashboard.AnnotationsByTags:
+		params.Add("type", "annotation")
+		params.Add("matchAny", strconv.FormatBool(query.MatchAny))
+		for _, tag := range query.Tags {
+			params.Add("tags", tag)
+		}
+	case dashboard.AnnotationsOfAlerts:
+		params.Add("type", "alert")
+		params.Add("dashboardUID", dashboardUID)
+	default:
+		params.Add("dashboardUID", dashboardUID)
+	}
+	return fmt.Sprintf("%s?%s", urlString, params.Encode()), nil
+}
+
+// requestJSON sends GET request to Grafana and decodes JSON response to the result
+func (g *GrafanaInstance) requestJSON(ctx context.Context, urlString string, authHeader string, orgID int, result any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, urlString, nil)
+	if err != nil {
+		return fmt.Errorf("could not create request to Grafana :%w", err)
+	}
+	setGrafanaHeaders(req, authHeader, orgID)
+	res, err := g.Do(req)
+	if err != nil {
+		return fmt.Errorf("request to Grafana failed: %w", err)
+	}
+	defer func() {
+		if err := res.Body.Close(); err != nil {
+			slog.Error("Could not close body response", "error", err)
+		}
+	}()
+	slog.Debug(fmt.Sprintf("Response %s %q received", http.MethodGet, urlString), "status", res.Status)
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to get response from Grafana, status code = %v", res.Status)
+	}
+	if err = json.NewDecoder(res.Body).Decode(result); err != nil {
+		return fmt.Errorf("could not decode Grafana response :%w", err)
+	}
+	return nil
+}
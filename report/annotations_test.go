@@ -0,0 +1,76 @@
+package report
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Netcracker/grafana-reporter/dashboard"
+	"github.com/Netcracker/grafana-reporter/timerange"
+)
+
+func TestGetAnnotationsURL(t *testing.T) {
+	timerangeData := &timerange.TimerangeData{
+		DateFrom: time.UnixMilli(1706190192000),
+		DateTo:   time.UnixMilli(1706193792000),
+	}
+	tests := []struct {
+		name     string
+		query    dashboard.AnnotationQuery
+		expected string
+	}{
+		{
+			"dashboard",
+			dashboard.AnnotationQuery{Type: dashboard.AnnotationsOfDashboard, Limit: 100},
+			"http://grafana:3000/api/annotations?dashboardUID=uid&from=1706190192000&limit=100&to=1706193792000",
+		},
+		{
+			"tags",
+			dashboard.AnnotationQuery{Type: dashboard.AnnotationsByTags, Tags: []string{"deploy", "prod"}, MatchAny: true, Limit: 10},
+			"http://grafana:3000/api/annotations?from=1706190192000&limit=10&matchAny=true&tags=deploy&tags=prod&to=1706193792000&type=annotation",
+		},
+		{
+			"alerts",
+			dashboard.AnnotationQuery{Type: dashboard.AnnotationsOfAlerts, Limit: 1000},
+			"http://grafana:3000/api/annotations?dashboardUID=uid&from=1706190192000&limit=1000&to=1706193792000&type=alert",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := getAnnotationsURL("http://grafana:3000", "uid", tt.query, timerangeData)
+			if err != nil {
+				t.Fatalf("getAnnotationsURL failed: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("getAnnotationsURL() = %s; want %s", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAddAnnotationsFromGrafana(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("type") == "annotation" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte(`[{"id": 1, "panelId": 0, "time": 1706190192000, "timeEnd": 1706190192000, "tags": ["deploy"], "text": "Deployed"}]`))
+	}))
+	defer server.Close()
+
+	g := &GrafanaInstance{Endpoint: server.URL}
+	sd := &dashboard.StructuredDashboard{
+		UID: "uid",
+		AnnotationQueries: []dashboard.AnnotationQuery{
+			{Name: "Annotations", Type: dashboard.AnnotationsOfDashboard, Limit: 100},
+			{Name: "Failing", Type: dashboard.AnnotationsByTags, Tags: []string{"x"}, Limit: 100},
+		},
+	}
+	params := &reportParameters{Timerange: &timerange.TimerangeData{DateFrom: time.Now().Add(-time.Hour), DateTo: time.Now()}}
+	g.addAnnotations(context.Background(), sd, params)
+	if len(sd.Annotations) != 1 || sd.Annotations[0].Text != "Deployed" {
+		t.Errorf("Annotations = %+v; want one annotation from the successful query", sd.Annotations)
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package report
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/Netcracker/grafana-reporter/timerange"
+)
+
+const (
+	// defaultCompareTemplate is the template of the comparison report if the template is not requested
+	defaultCompareTemplate = "compareTemplate"
+	// compareLayoutSide puts the panel of the time range and the panel of the baseline side by side
+	compareLayoutSide = "side"
+	// compareLayoutStacked puts the panel of the baseline below the panel of the time range
+	compareLayoutStacked = "stacked"
+	// compareImageSuffix is added to names of images of panels rendered for the baseline
+	compareImageSuffix = "_baseline"
+	// templateComparePrefix starts the comment line of the template that shows the comparison
+	templateComparePrefix = "% compare:"
+)
+
+var errComparisonNotSupported = errors.New("comparison is supported only for the report of one dashboard")
+
+// comparison is the baseline time range of the report comparing the time range with the shifted time range
+// (Shift) or with the explicit one (From and To)
+type comparison struct {
+	Shift  string
+	From   string
+	To     string
+	Layout string
+	// Timerange is the baseline time range calculated with the time settings of the dashboard
+	Timerange *timerange.TimerangeData
+}
+
+// comparisonData is the baseline time range passed to the template
+type comparisonData struct {
+	From          string
+	To            string
+	TimestampFrom string
+	TimestampTo   string
+	// Layout is side or stacked
+	Layout string
+}
+
+// newComparison validates parameters of the comparison, it returns nil if the comparison is not requested
+func newComparison(shift, from, to, layout string, calendar *timerange.Calendar) (*comparison, error) {
+	if shift == "" && from == "" && to == "" {
+		if layout != "" {
+			return nil, fmt.Errorf("parameter %q requires %q or %q", "compareLayout", "compare", "compareFrom")
+		}
+		return nil, nil
+	}
+	if shift != "" && (from != "" || to != "") {
+		return nil, fmt.Errorf("parameter %q can not be set together with %q and %q", "compare", "compareFrom", "compareTo")
+	}
+	to = periodTimerangeTo(from, to, calendar)
+	if shift == "" && (from == "" || to == "") {
+		return nil, fmt.Errorf("parameters %q and %q must be set together", "compareFrom", "compareTo")
+	}
+	switch layout {
+	case "":
+		layout = compareLayoutSide
+	case compareLayoutSide, compareLayoutStacked:
+	default:
+		return nil, fmt.Errorf("parameter %q must be %s or %s, got %q", "compareLayout", compareLayoutSide, compareLayoutStacked, layout)
+	}
+	return &comparison{Shift: shift, From: from, To: to, Layout: layout}, nil
+}
+
+// checkComparisonTemplate returns the error if the comparison is requested with the template that does not show
+// the baseline time range, panels of the baseline would be rendered for nothing. The template shows the comparison
+// if it has the comment "% compare: true" at its beginning.
+func checkComparisonTemplate(c *comparison, templateName string, templates map[string][]byte) error {
+	texTemplate, ok := templates[templateName]
+	if c == nil || !ok {
+		return nil
+	}
+	value, _ := getTemplateComment(texTemplate, templateComparePrefix)
+	if compare, err := strconv.ParseBool(value); err != nil || !compare {
+		return fmt.Errorf("template %q does not show the comparison, use %s or the template with the comment %q", templateName, defaultCompareTemplate, templateComparePrefix+" true")
+	}
+	return nil
+}
+
+// applyComparison calculates the baseline time range with the time options of the dashboard
+func (p *reportParameters) applyComparison(options timerange.Options) error {
+	if p.Compare == nil {
+		return nil
+	}
+	if p.Compare.Shift != "" {
+		result, err := timerange.ShiftTimerange(p.Timerange, p.Compare.Shift)
+		if err != nil {
+			return err
+		}
+		p.Compare.Timerange = result
+		return nil
+	}
+	dateFrom, err := timerange.RelativeTimeToTimestampWithOptions(p.Now, p.Compare.From, "from", options)
+	if err != nil {
+		return fmt.Errorf("parameter %q is not valid :%w", "compareFrom", err)
+	}
+	dateTo, err := timerange.RelativeTimeToTimestampWithOptions(p.Now, p.Compare.To, "to", options)
+	if err != nil {
+		return fmt.Errorf("parameter %q is not valid :%w", "compareTo", err)
+	}
+	p.Compare.Timerange = &timerange.TimerangeData{From: p.Compare.From, To: p.Compare.To, DateFrom: dateFrom, DateTo: dateTo}
+	return nil
+}
+
+// getComparisonData returns the baseline time range for the template, it is nil if the comparison is not requested
+func (c *comparison) getComparisonData() *comparisonData {
+	if c == nil || c.Timerange == nil {
+		return nil
+	}
+	return &comparisonData{
+		From:          c.Timerange.From,
+		To:            c.Timerange.To,
+		TimestampFrom: c.Timerange.DateFrom.Format(timerange.Format),
+		TimestampTo:   c.Timerange.DateTo.Format(timerange.Format),
+		Layout:        c.Layout,
+	}
+}
+
+// getRequestIDPart returns the part of the request ID describing the comparison
+func (c *comparison) getRequestIDPart() string {
+	switch {
+	case c == nil:
+		return ""
+	case c.Shift != "":
+		return fmt.Sprintf("_vs-%s_%s", getRequestIDTime(c.Shift), c.Layout)
+	default:
+		return fmt.Sprintf("_vs-%s-%s_%s", getRequestIDTime(c.From), getRequestIDTime(c.To), c.Layout)
+	}
+}
@@ -0,0 +1,146 @@
+package report
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Netcracker/grafana-reporter/dashboard"
+	"github.com/Netcracker/grafana-reporter/timerange"
+)
+
+func TestNewComparison(t *testing.T) {
+	tests := []struct {
+		name                       string
+		shift, from, to, layout    string
+		isNil, isError             bool
+		expectedTo, expectedLayout string
+	}{
+		{name: "not requested", isNil: true},
+		{name: "shift", shift: "1w", expectedLayout: compareLayoutSide},
+		{name: "explicit range", from: "now-14d", to: "now-7d", layout: compareLayoutStacked, expectedTo: "now-7d", expectedLayout: compareLayoutStacked},
+		{name: "period", from: "previous-month", expectedTo: "previous-month", expectedLayout: compareLayoutSide},
+		{name: "layout without comparison", layout: compareLayoutSide, isError: true},
+		{name: "shift and range", shift: "1w", from: "now-14d", to: "now-7d", isError: true},
+		{name: "start without end", from: "now-14d", isError: true},
+		{name: "invalid layout", shift: "1w", layout: "grid", isError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := newComparison(tt.shift, tt.from, tt.to, tt.layout, nil)
+			if (err != nil) != tt.isError {
+				t.Fatalf("newComparison() error = %v; want error %t", err, tt.isError)
+			}
+			if tt.isError || tt.isNil {
+				if result != nil {
+					t.Errorf("newComparison() = %+v; want nil", result)
+				}
+				return
+			}
+			if result.To != tt.expectedTo || result.Layout != tt.expectedLayout {
+				t.Errorf("newComparison() = %+v; want end %q and layout %q", result, tt.expectedTo, tt.expectedLayout)
+			}
+		})
+	}
+}
+
+func TestApplyComparison(t *testing.T) {
+	now := time.Date(2024, 1, 25, 14, 43, 12, 0, time.UTC)
+	params := &reportParameters{
+		DashboardUID: "uid",
+		Timerange:    &timerange.TimerangeData{From: "now-1h", To: "now", DateFrom: now.Add(-time.Hour), DateTo: now},
+		Now:          now,
+		Compare:      &comparison{Shift: "1w", Layout: compareLayoutSide},
+	}
+	if err := params.applyComparison(timerange.Options{}); err != nil {
+		t.Fatalf("applyComparison failed: %v", err)
+	}
+	baseline := params.Compare.Timerange
+	if baseline.From != "now-1h-1w" || baseline.To != "now-1w" || !baseline.DateTo.Equal(now.AddDate(0, 0, -7)) {
+		t.Errorf("Baseline time range = %+v; want the time range shifted by 1w", baseline)
+	}
+	params.RequestID = generateUniqueRequestID(params)
+	if params.RequestID != "uid_report_now-1h-now_vs-1w_side" {
+		t.Errorf("generateUniqueRequestID() = %q", params.RequestID)
+	}
+
+	params.Compare = &comparison{From: "now-1d/d", To: "now-1d/d", Layout: compareLayoutStacked}
+	if err := params.applyComparison(timerange.Options{}); err != nil {
+		t.Fatalf("applyComparison failed: %v", err)
+	}
+	data := params.Compare.getComparisonData()
+	if data.TimestampFrom != "2024-01-24 00:00:00 +0000 UTC" || data.TimestampTo != "2024-01-24 23:59:59 +0000 UTC" || data.Layout != compareLayoutStacked {
+		t.Errorf("getComparisonData() = %+v; want yesterday", data)
+	}
+	if id := generateUniqueRequestID(params); id != "uid_report_now-1h-now_vs-now-1d-d-now-1d-d_stacked" {
+		t.Errorf("generateUniqueRequestID() = %q", id)
+	}
+
+	params.Compare = &comparison{From: "now-1x", To: "now", Layout: compareLayoutSide}
+	if err := params.applyComparison(timerange.Options{}); err == nil {
+		t.Errorf("applyComparison() must fail for invalid time")
+	}
+}
+
+func TestCheckComparisonTemplate(t *testing.T) {
+	templates := map[string][]byte{
+		"compare":   []byte("% render: width=2560\n% compare: true\n\\documentclass{article}"),
+		"disabled":  []byte("% compare: false\n\\documentclass{article}"),
+		"mentioned": []byte("\\documentclass{article}\n% compare: true\n% [[.Compare]]"),
+	}
+	c := &comparison{Shift: "1w"}
+	if err := checkComparisonTemplate(c, "compare", templates); err != nil {
+		t.Errorf("checkComparisonTemplate() error = %v; want nil for the template with the comment", err)
+	}
+	for _, name := range []string{"disabled", "mentioned"} {
+		if err := checkComparisonTemplate(c, name, templates); err == nil {
+			t.Errorf("checkComparisonTemplate(%q) error = nil; want error", name)
+		}
+	}
+	if err := checkComparisonTemplate(nil, "disabled", templates); err != nil {
+		t.Errorf("checkComparisonTemplate() error = %v; want nil without comparison", err)
+	}
+}
+
+func TestGetReportParametersComparison(t *testing.T) {
+	g := &GrafanaInstance{DefaultFrom: "now-1h", DefaultTo: "now", DefaultTemplate: "gridTemplate", RowsMode: dashboard.RowsExpanded}
+	startTime := time.Date(2024, 1, 25, 14, 43, 12, 0, time.UTC)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/report/uid?compare=1d", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	params, _, err := g.getReportParametersFromRequest(req, "uid", g.DefaultTemplate, startTime)
+	if err != nil {
+		t.Fatalf("getReportParametersFromRequest failed: %v", err)
+	}
+	if params.Template != defaultCompareTemplate || params.Compare == nil || !params.Compare.Timerange.DateTo.Equal(startTime.AddDate(0, 0, -1)) {
+		t.Errorf("Template = %s, comparison = %+v; want comparison with the previous day", params.Template, params.Compare)
+	}
+
+	for _, query := range []string{"compare=1week", "compareFrom=now-2d&compareTo=now-1x"} {
+		req = httptest.NewRequest(http.MethodGet, "/api/v1/report/uid?"+query, nil)
+		req.Header.Set("Authorization", "Bearer token")
+		if _, status, err := g.getReportParametersFromRequest(req, "uid", g.DefaultTemplate, startTime); err == nil || status != http.StatusBadRequest {
+			t.Errorf("getReportParametersFromRequest(%s) = %d, %v; want 400", query, status, err)
+		}
+	}
+
+	g.Templates = map[string][]byte{"gridTemplate": []byte("[[.From]]"), defaultCompareTemplate: []byte("% compare: true\n[[with .Compare]][[.From]][[end]]")}
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/report/uid?compare=1d&template=gridTemplate", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	if _, status, err := g.getReportParametersFromRequest(req, "uid", g.DefaultTemplate, startTime); err == nil || status != http.StatusBadRequest {
+		t.Errorf("getReportParametersFromRequest() = %d, %v; want 400 for the template without comparison", status, err)
+	}
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/report/uid?compare=1d&template="+defaultCompareTemplate, nil)
+	req.Header.Set("Authorization", "Bearer token")
+	if _, _, err := g.getReportParametersFromRequest(req, "uid", g.DefaultTemplate, startTime); err != nil {
+		t.Errorf("getReportParametersFromRequest() error = %v; want nil for the template with comparison", err)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/reports?tag=sla&compare=1w", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	w := httptest.NewRecorder()
+	g.HandleGenerateMultiReport(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for comparison of several dashboards, got %d", w.Code)
+	}
+}
@@ -0,0 +1,326 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package report
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Netcracker/grafana-reporter/dashboard"
+	"github.com/Netcracker/grafana-reporter/timerange"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// maxDefinitionSize limits the size of the report definition in the request body
+const maxDefinitionSize = 1 << 20
+
+// reportDefinition is the report of panels from several dashboards in YAML or JSON format.
+// Parameters of the definition replace parameters of the request, parameters of sections replace parameters of the definition.
+type reportDefinition struct {
+	Title    string              `yaml:"title"`
+	Template string              `yaml:"template"`
+	From     string              `yaml:"from"`
+	To       string              `yaml:"to"`
+	Rows     string              `yaml:"rows"`
+	Alerts   *bool               `yaml:"alerts"`
+	Vars     definitionVars      `yaml:"vars"`
+	Sections []sectionDefinition `yaml:"sections"`
+}
+
+// sectionDefinition is a dashboard of the report definition. Only the panels and rows are included if they are set.
+type sectionDefinition struct {
+	Dashboard string         `yaml:"dashboard"`
+	Title     string         `yaml:"title"`
+	From      string         `yaml:"from"`
+	To        string         `yaml:"to"`
+	Vars      definitionVars `yaml:"vars"`
+	Panels    []int          `yaml:"panels"`
+	Rows      []string       `yaml:"rows"`
+}
+
+// definitionVars are Grafana variables of the definition. The value can be a string or a list of strings,
+// the prefix var- of names is optional.
+type definitionVars map[string][]string
+
+func (v *definitionVars) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: variables must be a map", node.Line)
+	}
+	vars := definitionVars{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		name := strings.TrimPrefix(node.Content[i].Value, "var-")
+		value := node.Content[i+1]
+		switch value.Kind {
+		case yaml.ScalarNode:
+			vars[name] = []string{value.Value}
+		case yaml.SequenceNode:
+			var values []string
+			if err := value.Decode(&values); err != nil {
+				return err
+			}
+			vars[name] = values
+		default:
+			return fmt.Errorf("line %d: value of variable %q must be a string or a list of strings", value.Line, name)
+		}
+	}
+	*v = vars
+	return nil
+}
+
+// merge returns the variables with values replaced by the definition variables
+func (v definitionVars) merge(vars url.Values) url.Values {
+	merged := url.Values{}
+	for name, values := range vars {
+		merged[name] = values
+	}
+	for name, values := range v {
+		merged[fmt.Sprintf("var-%s", name)] = values
+	}
+	return merged
+}
+
+// readReportDefinition reads the report definition from the file. It returns nil if the file is not set.
+func readReportDefinition(file string) ([]byte, *reportDefinition, error) {
+	if file == "" {
+		return nil, nil, nil
+	}
+	body, err := os.ReadFile(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	definition, err := parseReportDefinition(body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return body, definition, nil
+}
+
+// parseReportDefinition reads the report definition. JSON is a subset of YAML, so both formats are read by YAML parser.
+func parseReportDefinition(body []byte) (*reportDefinition, error) {
+	definition := &reportDefinition{}
+	if err := yaml.Unmarshal(body, definition); err != nil {
+		return nil, fmt.Errorf("could not parse report definition :%w", err)
+	}
+	if len(definition.Sections) == 0 {
+		return nil, fmt.Errorf("report definition must contain at least one section")
+	}
+	if len(definition.Sections) > maxReportSections {
+		return nil, fmt.Errorf("report definition must contain at most %d sections", maxReportSections)
+	}
+	for i, section := range definition.Sections {
+		if section.Dashboard == "" {
+			return nil, fmt.Errorf("dashboard of section %d is not set", i+1)
+		}
+	}
+	return definition, nil
+}
+
+// apply sets parameters of the definition to the parameters of the report
+func (d *reportDefinition) apply(params *reportParameters, now time.Time) error {
+	var err error
+	if d.Template != "" {
+		params.Template = d.Template
+	}
+	if d.Rows != "" {
+		if params.RowsMode, err = dashboard.ParseRowsMode(d.Rows); err != nil {
+			return err
+		}
+	}
+	if d.Alerts != nil {
+		params.Alerts = *d.Alerts
+	}
+	params.Vars = d.Vars.merge(params.Vars)
+	if err = params.overrideTimerange(d.From, d.To, now); err != nil {
+		return err
+	}
+	return nil
+}
+
+// getSections returns parameters of every section based on the parameters of the report
+func (d *reportDefinition) getSections(params *reportParameters, now time.Time) ([]*reportSection, error) {
+	sections := make([]*reportSection, 0, len(d.Sections))
+	for i, section := range d.Sections {
+		sectionParams := *params
+		sectionParams.DashboardUID = section.Dashboard
+		sectionParams.Vars = section.Vars.merge(params.Vars)
+		var err error
+		if err = sectionParams.overrideTimerange(section.From, section.To, now); err != nil {
+			return nil, fmt.Errorf("invalid time range of section %d :%w", i+1, err)
+		}
+		if len(section.Panels) > 0 || len(section.Rows) > 0 {
+			if sectionParams.Selection, err = section.getSelection(); err != nil {
+				return nil, fmt.Errorf("invalid selection of section %d :%w", i+1, err)
+			}
+		}
+		sections = append(sections, &reportSection{Title: section.Title, Params: &sectionParams})
+	}
+	return sections, nil
+}
+
+// getSelection returns selection of panels by IDs and rows by exact titles
+func (s *sectionDefinition) getSelection() (*dashboard.Selection, error) {
+	params := url.Values{}
+	if len(s.Panels) > 0 {
+		ids := make([]string, 0, len(s.Panels))
+		for _, id := range s.Panels {
+			ids = append(ids, strconv.Itoa(id))
+		}
+		params.Set(dashboard.ParamIncludePanels, strings.Join(ids, ","))
+	}
+	if len(s.Rows) > 0 {
+		titles := make([]string, 0, len(s.Rows))
+		for _, title := range s.Rows {
+			titles = append(titles, regexp.QuoteMeta(title))
+		}
+		params.Set(dashboard.ParamIncludeRows, fmt.Sprintf("^(?:%s)$", strings.Join(titles, "|")))
+	}
+	return dashboard.ParseSelection(params)
+}
+
+// overrideTimerange replaces the start and the end of the time range if they are set
+func (p *reportParameters) overrideTimerange(from, to string, now time.Time) error {
+	if from == "" && to == "" {
+		return nil
+	}
+	to = periodTimerangeTo(from, to, p.Calendar)
+	result := *p.Timerange
+	var err error
+	if from != "" {
+		result.From = from
+		if result.DateFrom, err = timerange.RelativeTimeToTimestampWithOptions(now, from, "from", timerange.Options{Location: p.getLocation(), Calendar: p.Calendar}); err != nil {
+			return err
+		}
+		p.FromDefault = false
+	}
+	if to != "" {
+		result.To = to
+		if result.DateTo, err = timerange.RelativeTimeToTimestampWithOptions(now, to, "to", timerange.Options{Location: p.getLocation(), Calendar: p.Calendar}); err != nil {
+			return err
+		}
+		p.ToDefault = false
+	}
+	p.Timerange = &result
+	return nil
+}
+
+// getCompositeRequestID returns ID of the report of the definition. The definition is hashed to keep the ID short.
+func getCompositeRequestID(params *reportParameters, definition []byte) string {
+	hash := fnv.New32a()
+	_, _ = hash.Write(definition)
+	compositeParams := *params
+	compositeParams.DashboardUID = fmt.Sprintf("composite-%08x", hash.Sum32())
+	return generateUniqueRequestID(&compositeParams)
+}
+
+// generateCompositeReport generates one report of the sections of the definition
+func (g *GrafanaInstance) generateCompositeReport(ctx context.Context, params *reportParameters, definition *reportDefinition, now time.Time) ([]byte, error) {
+	sections, err := definition.getSections(params, now)
+	if err != nil {
+		return nil, err
+	}
+	title := definition.Title
+	if title == "" {
+		title = "Report"
+	}
+	return g.generateSectionsReport(ctx, params, title, sections)
+}
+
+// HandleGenerateCompositeReport godoc
+//
+//	@Summary		Generate report of panels from several Grafana dashboards
+//	@Description	Generate one PDF file from the report definition in YAML or JSON format. The definition lists dashboards and optionally their panels and rows with time range and variables of each section. Query parameters are used if they are not set in the definition
+//	@Tags			Generate
+//	@id				generateCompositeReport
+//	@Param			Authorization	header	string	true	"Authentication header"
+//	@Param			definition		body	string	true	"Report definition in YAML or JSON format"
+//	@Param			template		query	string	false	"PDF tex template name"	default(multiTemplate)
+//	@Param			from			query	string	false	"The start of time range"
+//	@Param			to				query	string	false	"The end of time range"
+//	@Param			rows			query	string	false	"Rows to include: expanded, collapsed or all"	Enums(expanded, collapsed, all)
+//	@Param			alerts			query	bool	false	"If true, the summary of alert rules linked to the dashboard panels is included to the report"
+//	@Param			orgId			query	int		false	"Grafana organization of the dashboards"
+//	@Param			timezone		query	string	false	"Timezone of the time range: IANA name, utc or offset like +03:00, by default the timezone of the dashboard"
+//	@Param			width			query	int		false	"Width of the screen in pixels the panels are rendered on, by default the width of the template or 1920"
+//	@Param			scale			query	int		false	"Device scale factor of panel images from 1 to 4 for high resolution prints"
+//	@Param			theme			query	string	false	"Theme of panels, by default the theme of the template or light"	Enums(light, dark)
+//	@Param			onPanelError	query	string	false	"Policy of panels that are not rendered: fail the report, replace them with placeholders or skip them"	Enums(fail, placeholder, skip)
+//	@Accept			json
+//	@Accept			application/x-yaml
+//	@Produce		octet-stream
+//	@Success		200	{object}	string	"OK"
+//	@Header			200	{string}	Report-Status			"complete or partial if some panels are not rendered"
+//	@Header			200	{int}		Report-Failed-Panels	"Number of panels that are not rendered"
+//	@Failure		400	{string}	string	"Bad Request"
+//	@Failure		401	{string}	string	"Unauthorized"
+//	@Router			/api/v1/reports [post]
+func (g *GrafanaInstance) HandleGenerateCompositeReport(writer http.ResponseWriter, request *http.Request) {
+	startTime := time.Now()
+
+	body, err := io.ReadAll(http.MaxBytesReader(writer, request.Body, maxDefinitionSize))
+	if err != nil {
+		slog.Error(fmt.Sprintf("Error occurred when reading report definition. Error: %v", err))
+		writeErrorResponse(writer, http.StatusBadRequest, err)
+		return
+	}
+	definition, err := parseReportDefinition(body)
+	if err != nil {
+		slog.Error(fmt.Sprintf("Error occurred when parsing report definition. Error: %v", err))
+		writeErrorResponse(writer, http.StatusBadRequest, err)
+		return
+	}
+	params, status, err := g.getReportParametersFromRequest(request, "", defaultMultiTemplate, startTime)
+	if err != nil {
+		writeErrorResponse(writer, status, err)
+		return
+	}
+	if params.Compare != nil {
+		slog.Error(errComparisonNotSupported.Error())
+		writeErrorResponse(writer, http.StatusBadRequest, errComparisonNotSupported)
+		return
+	}
+	if err = definition.apply(params, startTime); err != nil {
+		slog.Error(fmt.Sprintf("Error occurred when parsing report definition. Error: %v", err))
+		writeErrorResponse(writer, http.StatusBadRequest, err)
+		return
+	}
+	params.RequestID = getCompositeRequestID(params, body)
+	requestID := params.RequestID
+	slog.Info(fmt.Sprintf("Generating report %q with parameters: sections=%d, from=%v, to=%v, template=%s, rows=%s, alerts=%t, vars=%s", requestID, len(definition.Sections), params.Timerange.From, params.Timerange.To, params.Template, params.RowsMode, params.Alerts, params.Vars.Encode()))
+	ctx, cancel := params.getContext(request.Context())
+	defer cancel()
+	report, err := g.generateCompositeReport(ctx, params, definition, startTime)
+	duration := time.Since(startTime).String()
+	writer.Header().Set("Duration", duration)
+	slog.Info(fmt.Sprintf("The request %s took %s", request.RequestURI, duration))
+	if err != nil {
+		slog.Error(fmt.Sprintf("Error occurred when generating report. Error: %v", err))
+		writeErrorResponse(writer, getErrorStatus(err), err)
+		return
+	}
+	setReportStatusHeaders(writer, params)
+	writeReportResponse(writer, requestID, report)
+}
@@ -0,0 +1,97 @@
+package report
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/Netcracker/grafana-reporter/dashboard"
+	"github.com/Netcracker/grafana-reporter/timerange"
+)
+
+func TestParseReportDefinition(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		isError bool
+	}{
+		{"yaml", "title: Review\nvars:\n  env: prod\nsections:\n  - dashboard: a\n    panels: [1, 2]\n    vars:\n      var-pod: [p1, p2]\n", false},
+		{"json", `{"title": "Review", "vars": {"env": "prod"}, "sections": [{"dashboard": "a", "panels": [1, 2], "vars": {"var-pod": ["p1", "p2"]}}]}`, false},
+		{"no sections", "title: Review\n", true},
+		{"no dashboard", "sections:\n  - title: A\n", true},
+		{"invalid vars", "sections:\n  - dashboard: a\n    vars: [env]\n", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			definition, err := parseReportDefinition([]byte(tt.body))
+			if (err != nil) != tt.isError {
+				t.Fatalf("parseReportDefinition() error = %v; want error %t", err, tt.isError)
+			}
+			if tt.isError {
+				return
+			}
+			section := definition.Sections[0]
+			if definition.Vars["env"][0] != "prod" || len(section.Vars["pod"]) != 2 || len(section.Panels) != 2 {
+				t.Errorf("parseReportDefinition() = %+v", definition)
+			}
+		})
+	}
+}
+
+func TestReportDefinitionSections(t *testing.T) {
+	now := time.Date(2024, 1, 25, 14, 43, 12, 0, time.UTC)
+	definition, err := parseReportDefinition([]byte(`
+title: Monthly service review
+from: now-30d
+vars:
+  env: prod
+sections:
+  - dashboard: overview
+  - dashboard: nodes
+    title: Nodes of the last week
+    from: now-7d
+    vars:
+      node: [n1, n2]
+    panels: [2]
+    rows: ["CPU (cores)"]
+`))
+	if err != nil {
+		t.Fatalf("parseReportDefinition failed: %v", err)
+	}
+	params := &reportParameters{
+		Timerange: &timerange.TimerangeData{From: "now-1h", To: "now", DateFrom: now.Add(-time.Hour), DateTo: now},
+		Template:  defaultMultiTemplate,
+		Vars:      url.Values{"var-env": {"dev"}, "var-region": {"eu"}},
+		RowsMode:  dashboard.RowsExpanded,
+	}
+	if err = definition.apply(params, now); err != nil {
+		t.Fatalf("apply failed: %v", err)
+	}
+	if params.Timerange.From != "now-30d" || !params.Timerange.DateFrom.Equal(now.AddDate(0, 0, -30)) || params.Vars.Get("var-env") != "prod" {
+		t.Errorf("apply() = %+v, %+v", params.Timerange, params.Vars)
+	}
+
+	sections, err := definition.getSections(params, now)
+	if err != nil {
+		t.Fatalf("getSections failed: %v", err)
+	}
+	if len(sections) != 2 {
+		t.Fatalf("getSections() returned %d sections; want 2", len(sections))
+	}
+	first, second := sections[0].Params, sections[1].Params
+	if first.DashboardUID != "overview" || first.Timerange.From != "now-30d" || first.Selection != nil {
+		t.Errorf("First section = %+v", first)
+	}
+	if second.DashboardUID != "nodes" || sections[1].Title != "Nodes of the last week" || second.Timerange.From != "now-7d" || second.Timerange.To != "now" {
+		t.Errorf("Second section = %+v, %+v", second, second.Timerange)
+	}
+	if len(second.Vars["var-node"]) != 2 || second.Vars.Get("var-env") != "prod" || second.Vars.Get("var-region") != "eu" {
+		t.Errorf("Variables of the second section = %v", second.Vars)
+	}
+	if !second.Selection.IncludesRow("CPU (cores)") || second.Selection.IncludesRow("CPU") {
+		t.Errorf("Selection of the second section = %s", second.Selection.String())
+	}
+	if params.Vars.Get("var-node") != "" {
+		t.Errorf("getSections() changed variables of the report: %v", params.Vars)
+	}
+}
@@ -16,17 +16,21 @@
 package report
 
 import (
+	"context"
 	"fmt"
+	"hash/fnv"
 	"log/slog"
 	"net/url"
 	"os"
 	"os/exec"
 	"path"
+	"regexp"
 	"strconv"
 	"strings"
 	"text/template"
 
 	"github.com/Netcracker/grafana-reporter/dashboard"
+	"github.com/Netcracker/grafana-reporter/textpanel"
 	"github.com/Netcracker/grafana-reporter/timerange"
 	"github.com/Netcracker/grafana-reporter/utils"
 )
@@ -38,9 +42,17 @@ type pdfData struct {
 	TimestampFrom   string
 	TimestampTo     string
 	Vars            string
+	// Width is the width of the screen the panels are rendered on, it is passed to GetRelativeWidth of panels
+	Width int
+	// Compare is the baseline time range of the comparison report, it is nil if the comparison is not requested
+	Compare *comparisonData
 }
 
-func generatePdf(templateBody string, structuredDashboard *dashboard.StructuredDashboard, timerangeData *timerange.TimerangeData, vars url.Values) error {
+// hyperrefPackage matches loading of the hyperref package in a line of the template that is not commented out
+var hyperrefPackage = regexp.MustCompile(`(?m)^[^%\n]*\\usepackage(\[[^\]]*\])?\{([^}]*,)?\s*hyperref\s*(,[^}]*)?\}`)
+
+func newReportTemplate(templateBody string, vars url.Values) (*template.Template, error) {
+	hyperref := hyperrefPackage.MatchString(templateBody)
 	funcMap := template.FuncMap{
 		"decrm": func(i int) int {
 			return i - 1
@@ -48,8 +60,41 @@ func generatePdf(templateBody string, structuredDashboard *dashboard.StructuredD
 		"rmdlr": func(s string) string {
 			return strings.ReplaceAll(s, "$", "")
 		},
+		"texesc": utils.EscapeTex,
+		// text panel functions use variables of the section if they are passed, for example in the report of several dashboards
+		"textpanel": func(p dashboard.Panel, sectionVars ...url.Values) string {
+			content, mode := p.GetTextContent()
+			return textpanel.ToTeX(dashboard.InterpolateVariables(content, getTemplateVars(vars, sectionVars)), mode, hyperref)
+		},
+	}
+	return template.New("pdf_report").Funcs(funcMap).Delims("[[", "]]").Parse(templateBody)
+}
+
+func getTemplateVars(vars url.Values, sectionVars []url.Values) url.Values {
+	if len(sectionVars) > 0 && sectionVars[0] != nil {
+		return sectionVars[0]
+	}
+	return vars
+}
+
+func generatePdf(ctx context.Context, templateBody string, structuredDashboard *dashboard.StructuredDashboard, timerangeData *timerange.TimerangeData, vars url.Values, width int, compare *comparisonData) error {
+	data := pdfData{
+		StructDashboard: structuredDashboard,
+		From:            timerangeData.From,
+		To:              timerangeData.To,
+		TimestampFrom:   timerangeData.DateFrom.Format(timerange.Format),
+		TimestampTo:     timerangeData.DateTo.Format(timerange.Format),
+		Vars:            strings.ReplaceAll(vars.Encode(), "&", " "),
+		Width:           width,
+		Compare:         compare,
 	}
-	templateObj, err := template.New("pdf_report").Funcs(funcMap).Delims("[[", "]]").Parse(templateBody)
+	return renderPdf(ctx, templateBody, structuredDashboard.RequestID, data, vars, 1)
+}
+
+// renderPdf executes the template with the data to the tex file of the request and converts it to PDF.
+// Documents with table of contents need several passes of pdflatex.
+func renderPdf(ctx context.Context, templateBody string, requestID string, data any, vars url.Values, passes int) error {
+	templateObj, err := newReportTemplate(templateBody, vars)
 	if err != nil {
 		return fmt.Errorf("failed to create pdf template. Error: %w", err)
 	}
@@ -57,10 +102,10 @@ func generatePdf(templateBody string, structuredDashboard *dashboard.StructuredD
 	if err = os.MkdirAll(reportsDir, 0777); err != nil {
 		return fmt.Errorf("failed to create reports directory. Error: %w", err)
 	}
-	if !utils.IsSafeFileName(structuredDashboard.RequestID) {
+	if !utils.IsSafeFileName(requestID) {
 		return fmt.Errorf("invalid request id") // block path traversal
 	}
-	fileTexName := fmt.Sprintf("%s.tex", structuredDashboard.RequestID)
+	fileTexName := fmt.Sprintf("%s.tex", requestID)
 	fileTex, err := os.Create(path.Join(reportsDir, fileTexName))
 	if err != nil {
 		return fmt.Errorf("failed to create report file. Error: %w", err)
@@ -71,68 +116,68 @@ func generatePdf(templateBody string, structuredDashboard *dashboard.StructuredD
 		}
 	}()
 
-	data := pdfData{
-		StructDashboard: structuredDashboard,
-		From:            timerangeData.From,
-		To:              timerangeData.To,
-		TimestampFrom:   timerangeData.DateFrom.Format(timerange.Format),
-		TimestampTo:     timerangeData.DateTo.Format(timerange.Format),
-		Vars:            strings.ReplaceAll(vars.Encode(), "&", " "),
-	}
-
 	if err = templateObj.Execute(fileTex, data); err != nil {
 		slog.Error(fmt.Sprintf("Error occurred when generating tex file. More details in %s%s and .log files", reportsDir, fileTexName), "err", err)
 		return err
 	}
 
-	command := exec.Command("pdflatex", fmt.Sprintf("--output-dir=%s", reportsDir), path.Join(reportsDir, fileTexName))
-	output, err := command.CombinedOutput()
-	if err != nil {
-		slog.Error("Error occurred when tex command executing", "err", err)
-		return err
-	}
-	if output != nil {
-		slog.Debug(fmt.Sprintf("Output of exec: %s", output))
+	for pass := 0; pass < passes; pass++ {
+		// pdflatex is killed if the report is cancelled
+		command := exec.CommandContext(ctx, "pdflatex", fmt.Sprintf("--output-dir=%s", reportsDir), path.Join(reportsDir, fileTexName))
+		output, err := command.CombinedOutput()
+		if ctx.Err() != nil {
+			return fmt.Errorf("pdflatex is stopped :%w", ctx.Err())
+		}
+		if err != nil {
+			slog.Error("Error occurred when tex command executing", "err", err)
+			return err
+		}
+		if output != nil {
+			slog.Debug(fmt.Sprintf("Output of exec: %s", output))
+		}
 	}
 
 	return nil
 }
 
-func generateFile(templateBody string, structuredDashboard *dashboard.StructuredDashboard, timerangeData *timerange.TimerangeData, vars url.Values) error {
-	defer func(requestID string) {
-		save, found := os.LookupEnv("SAVE_TEMP_IMAGES")
-		if found {
-			toSaveImages, err := strconv.ParseBool(save)
-			if err == nil && toSaveImages {
-				return
-			}
-		}
-		// delete all images from tmp directory
-		if !utils.IsSafeFileName(requestID) {
-			slog.Error("Invalid request id", "requestID", requestID)
-			return
-		}
-		dir := getPanelsDirPath(requestID)
-		entries, err := os.ReadDir(dir)
-		if err != nil {
-			slog.Error("Could not read tmp directory of images", "error", err, "path", dir)
+// removePanelImages deletes images of panels saved for the request unless SAVE_TEMP_IMAGES is true
+func removePanelImages(requestID string) {
+	save, found := os.LookupEnv("SAVE_TEMP_IMAGES")
+	if found {
+		toSaveImages, err := strconv.ParseBool(save)
+		if err == nil && toSaveImages {
 			return
 		}
-		for _, entry := range entries {
-			if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".png") {
-				imageFile := path.Join(dir, entry.Name())
-				if !utils.IsSafeFileName(imageFile) { // block path traversal
-					slog.Error("Invalid image file", "error", err, "file", imageFile)
-					continue
-				}
-				if err = os.Remove(imageFile); err != nil {
-					slog.Error("Could not successfully delete image file", "error", err, "file", imageFile)
-				}
+	}
+	// delete all images from tmp directory
+	if !utils.IsSafeFileName(requestID) {
+		slog.Error("Invalid request id", "requestID", requestID)
+		return
+	}
+	dir := getPanelsDirPath(requestID)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		slog.Error("Could not read tmp directory of images", "error", err, "path", dir)
+		return
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".png") {
+			imageFile := path.Join(dir, entry.Name())
+			if !utils.IsSafeFileName(imageFile) { // block path traversal
+				slog.Error("Invalid image file", "error", err, "file", imageFile)
+				continue
+			}
+			if err = os.Remove(imageFile); err != nil {
+				slog.Error("Could not successfully delete image file", "error", err, "file", imageFile)
 			}
 		}
-	}(structuredDashboard.RequestID)
+	}
+}
+
+func generateFile(ctx context.Context, templateBody string, structuredDashboard *dashboard.StructuredDashboard, timerangeData *timerange.TimerangeData, vars url.Values, width int, compare *comparisonData) error {
+	defer removePanelImages(structuredDashboard.RequestID)
 
-	err := generatePdf(templateBody, structuredDashboard, timerangeData, vars)
+	err := generatePdf(ctx, templateBody, structuredDashboard, timerangeData, vars, width, compare)
 	if err != nil {
 		slog.Error(fmt.Sprintf("Error occurred while generating PDF report. Error: %v", err))
 		return err
@@ -154,12 +199,48 @@ func getReport(requestID string) ([]byte, error) {
 	return report, err
 }
 
-func generateUniqueRequestID(uid, from, to string, isCollapsed bool) string {
-	var collapsed string
-	if isCollapsed {
-		collapsed = ""
-	} else {
-		collapsed = "_expanded"
+func generateUniqueRequestID(params *reportParameters) string {
+	var rows string
+	switch params.RowsMode {
+	case dashboard.RowsCollapsed, dashboard.RowsAll:
+		rows = fmt.Sprintf("_%s", params.RowsMode)
+	default:
+		rows = ""
+	}
+	var selection string
+	if !params.Selection.IsEmpty() {
+		hash := fnv.New32a()
+		_, _ = hash.Write([]byte(params.Selection.String()))
+		selection = fmt.Sprintf("_partial-%08x", hash.Sum32())
 	}
-	return fmt.Sprintf("%s_report_%s-%s%s", uid, from, to, collapsed)
+	var alerts string
+	if params.Alerts {
+		alerts = "_alerts"
+	}
+	var version string
+	switch {
+	case params.Version > 0:
+		version = fmt.Sprintf("_v%d", params.Version)
+	case !params.AsOf.IsZero():
+		version = fmt.Sprintf("_asof-%d", params.AsOf.Unix())
+	}
+	var org string
+	if params.OrgID > 0 {
+		org = fmt.Sprintf("_org%d", params.OrgID)
+	}
+	var timezone string
+	if params.Timezone != "" {
+		// time zone names contain slashes, for example Europe/Berlin
+		timezone = fmt.Sprintf("_tz-%s", strings.NewReplacer("/", "-", ":", "").Replace(params.Timezone))
+	}
+	return fmt.Sprintf("%s_report_%s-%s%s%s%s%s%s%s%s%s%s", params.DashboardUID, getRequestIDTime(params.Timerange.From), getRequestIDTime(params.Timerange.To),
+		rows, selection, alerts, version, org, timezone, params.Render.getRequestIDPart(), params.Compare.getRequestIDPart(), getPanelErrorRequestIDPart(params.OnPanelError))
+}
+
+// regExpressionRequestIDTime matches characters of time that can not be used in names of files, for example / of now/d
+var regExpressionRequestIDTime = regexp.MustCompile(`[^A-Za-z0-9_+-]`)
+
+// getRequestIDTime returns the time of the time range that can be used in the request ID
+func getRequestIDTime(text string) string {
+	return regExpressionRequestIDTime.ReplaceAllString(text, "-")
 }
@@ -0,0 +1,227 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Netcracker/grafana-reporter/dashboard"
+	"github.com/Netcracker/grafana-reporter/timerange"
+)
+
+func testStructuredDashboard(t *testing.T) *dashboard.StructuredDashboard {
+	entity := &dashboard.Entity{
+		Dashboard: dashboard.Dashboard{
+			Title: "Test Dashboard",
+			UID:   "test-uid",
+			Panels: []dashboard.Panel{
+				{ID: 1, Type: "graph", GridPos: dashboard.GridPos{H: 16, W: 12, X: 0, Y: 0}},
+				{ID: 2, Type: "graph", GridPos: dashboard.GridPos{H: 8, W: 12, X: 12, Y: 0}},
+				{ID: 3, Type: "graph", GridPos: dashboard.GridPos{H: 8, W: 12, X: 12, Y: 8}},
+				{ID: 4, Type: "row", Title: "Row $var", GridPos: dashboard.GridPos{H: 1, W: 24, X: 0, Y: 16}},
+				{ID: 5, Type: "graph", GridPos: dashboard.GridPos{H: 8, W: 24, X: 0, Y: 17}, TimeFrom: "7d", TimeShift: "1w"},
+				{ID: 6, Type: "text", GridPos: dashboard.GridPos{H: 4, W: 24, X: 0, Y: 25},
+					Options: dashboard.PanelOptions{Mode: "markdown", Content: "## Notes for $env\n\n- 100% *done*"}},
+			},
+		},
+		Meta: dashboard.Meta{Slug: "test-slug"},
+	}
+	sd, err := entity.GetStructuredDashboard(dashboard.RowsExpanded)
+	if err != nil {
+		t.Fatalf("GetStructuredDashboard failed: %v", err)
+	}
+	sd.RequestID = "test-uid_report"
+	sd.SetAlerts(&dashboard.AlertSummary{Rules: []dashboard.AlertRule{{Name: "High_CPU", PanelID: 2, State: "firing", FiringInstances: 1}}})
+	sd.Version = &dashboard.DashboardVersion{Version: 7, Created: time.Date(2024, 1, 20, 13, 0, 0, 0, time.UTC), CreatedBy: "admin", Message: "50% done"}
+	sd.AddAnnotations([]dashboard.Annotation{{ID: 1, Time: 1706190192000, Tags: []string{"deploy"}, Text: "Deployed 100%", PanelID: 5}})
+	sd.SetLocation(time.FixedZone("MSK", 3*60*60))
+	sd.RenderIssues = []dashboard.RenderIssue{{PanelID: 3, Title: "Disk_usage", Reason: "failed to get Grafana panel: Status code is 500"}}
+	now := time.Date(2024, 1, 25, 14, 43, 12, 0, time.UTC)
+	if err = sd.SetPanelTimes(&timerange.TimerangeData{From: "now-1h", To: "now", DateFrom: now.Add(-time.Hour), DateTo: now}, now, timerange.Options{}); err != nil {
+		t.Fatalf("SetPanelTimes failed: %v", err)
+	}
+	return sd
+}
+
+func TestDefaultTemplatesExecute(t *testing.T) {
+	sd := testStructuredDashboard(t)
+	now := time.Date(2024, 1, 25, 14, 43, 12, 0, time.UTC)
+	for _, name := range []string{"gridTemplate", "pngTemplate", "simpleTemplate", "compareTemplate"} {
+		t.Run(name, func(t *testing.T) {
+			body, err := os.ReadFile(path.Join("..", "templates", name))
+			if err != nil {
+				t.Fatalf("Could not read template: %v", err)
+			}
+			templateObj, err := newReportTemplate(string(body), url.Values{"var-env": {"prod"}})
+			if err != nil {
+				t.Fatalf("Could not parse template: %v", err)
+			}
+			var buf bytes.Buffer
+			data := pdfData{
+				StructDashboard: sd,
+				From:            "now-1h",
+				To:              "now",
+				TimestampFrom:   now.Add(-time.Hour).Format(timerange.Format),
+				TimestampTo:     now.Format(timerange.Format),
+				Width:           defaultRenderWidth,
+			}
+			if err = templateObj.Execute(&buf, data); err != nil {
+				t.Fatalf("Could not execute template: %v", err)
+			}
+			if strings.Contains(buf.String(), "NaN") {
+				t.Errorf("Report includes invalid width of panels")
+			}
+			for _, image := range []string{"1.png", "2.png", "3.png", "5.png"} {
+				if !strings.Contains(buf.String(), image) {
+					t.Errorf("Report does not include panel image %s", image)
+				}
+			}
+			if !strings.Contains(buf.String(), `2024-01-25 16:43:12 MSK &  & deploy & Deployed 100\% &`) {
+				t.Errorf("Report does not include annotations")
+			}
+			if !strings.Contains(buf.String(), `High\_CPU & Panel 2 & \textcolor{red}{\textbf{firing}}`) {
+				t.Errorf("Report does not include alerts summary")
+			}
+			if !strings.Contains(buf.String(), `3 & Disk\_usage & failed to get Grafana panel: Status code is 500`) {
+				t.Errorf("Report does not include rendering issues")
+			}
+			if !strings.Contains(buf.String(), `Dashboard version 7 saved at 2024-01-20 16:00:00 MSK by admin (\textit{50\% done})`) {
+				t.Errorf("Report does not include dashboard version")
+			}
+			if !strings.Contains(buf.String(), "Last 7 days, shifted 1w: 2024-01-11 14:43:12 +0000 UTC to 2024-01-18 14:43:12 +0000 UTC") {
+				t.Errorf("Report does not include the time range of the panel")
+			}
+			if strings.Contains(buf.String(), "6.png") {
+				t.Errorf("Report includes image of the text panel")
+			}
+			if !strings.Contains(buf.String(), `{\large\bfseries Notes for prod\par}`) || !strings.Contains(buf.String(), `\item 100\% \textit{done}`) {
+				t.Errorf("Report does not include content of the text panel:\n%s", buf.String())
+			}
+		})
+	}
+}
+
+func TestMultiTemplateExecute(t *testing.T) {
+	first := testStructuredDashboard(t)
+	second := testStructuredDashboard(t)
+	second.Title = "Second_Dashboard"
+	second.RequestID = "multi_report_second"
+	body, err := os.ReadFile(path.Join("..", "templates", defaultMultiTemplate))
+	if err != nil {
+		t.Fatalf("Could not read template: %v", err)
+	}
+	templateObj, err := newReportTemplate(string(body), url.Values{"var-env": {"prod"}})
+	if err != nil {
+		t.Fatalf("Could not parse template: %v", err)
+	}
+	var buf bytes.Buffer
+	data := multiPdfData{
+		Title:    "Tags: sla",
+		Sections: []*multiPdfSection{{StructuredDashboard: first}, {StructuredDashboard: second, TimestampFrom: "2024-01-01 00:00:00", Variables: url.Values{"var-env": {"dev"}}}},
+		From:     "now-1h",
+		To:       "now",
+	}
+	if err = templateObj.Execute(&buf, data); err != nil {
+		t.Fatalf("Could not execute template: %v", err)
+	}
+	for _, expected := range []string{`\tableofcontents`, `\section{Test Dashboard}`, `\section{Second\_Dashboard}`,
+		"{tmp/test-uid_report/1.png}", "{tmp/multi_report_second/5.png}", `High\_CPU & Panel 2`, `3 & Disk\_usage &`,
+		"Notes for prod", "Notes for dev", "2024-01-01 00:00:00 to ", "Last 7 days, shifted 1w"} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("Report does not include %q", expected)
+		}
+	}
+}
+
+func TestCompareTemplateExecute(t *testing.T) {
+	sd := testStructuredDashboard(t)
+	body, err := os.ReadFile(path.Join("..", "templates", defaultCompareTemplate))
+	if err != nil {
+		t.Fatalf("Could not read template: %v", err)
+	}
+	templateObj, err := newReportTemplate(string(body), url.Values{"var-env": {"prod"}})
+	if err != nil {
+		t.Fatalf("Could not parse template: %v", err)
+	}
+	for _, layout := range []string{compareLayoutSide, compareLayoutStacked} {
+		t.Run(layout, func(t *testing.T) {
+			var buf bytes.Buffer
+			data := pdfData{
+				StructDashboard: sd,
+				From:            "now-1h",
+				To:              "now",
+				TimestampFrom:   "2024-01-25 13:43:12 +0000 UTC",
+				TimestampTo:     "2024-01-25 14:43:12 +0000 UTC",
+				Vars:            "var-env=prod",
+				Compare: &comparisonData{From: "now-1h-1w", To: "now-1w", TimestampFrom: "2024-01-18 13:43:12 +0000 UTC",
+					TimestampTo: "2024-01-18 14:43:12 +0000 UTC", Layout: layout},
+			}
+			if err = templateObj.Execute(&buf, data); err != nil {
+				t.Fatalf("Could not execute template: %v", err)
+			}
+			report := buf.String()
+			for _, expected := range []string{"compared with 2024-01-18 13:43:12 +0000 UTC to 2024-01-18 14:43:12 +0000 UTC (now-1h-1w to now-1w)",
+				"{1.png}", "{1_baseline.png}", "{5.png}"} {
+				if !strings.Contains(report, expected) {
+					t.Errorf("Report does not include %q", expected)
+				}
+			}
+			if strings.Contains(report, "{5_baseline.png}") {
+				t.Errorf("Report includes the baseline of the panel with relative time")
+			}
+			if strings.Count(report, "Variables: var-env=prod") != 1 {
+				t.Errorf("Report must include variables once")
+			}
+			if (layout == compareLayoutSide) != strings.Contains(report, `\begin{minipage}[t]{0.49\textwidth}`) {
+				t.Errorf("Layout of panels is not %s", layout)
+			}
+		})
+	}
+}
+
+func TestRenderPdfCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := renderPdf(ctx, "\\documentclass{article}", "cancelled_test", pdfData{}, url.Values{}, 1)
+	defer func() {
+		_ = os.Remove(path.Join(reportsDir, "cancelled_test.tex"))
+	}()
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("renderPdf() error = %v; want context.Canceled", err)
+	}
+}
+
+func TestNewReportTemplateLinks(t *testing.T) {
+	panel := dashboard.Panel{Type: "text", Options: dashboard.PanelOptions{Mode: "markdown", Content: "[docs](https://example.com/#a)"}}
+	tests := []struct {
+		name     string
+		preamble string
+		expected string
+	}{
+		{"hyperref", `\usepackage[hidelinks]{hyperref}`, `\href{https://example.com/\#a}{docs}`},
+		{"several packages", `\usepackage{graphicx, hyperref}`, `\href{https://example.com/\#a}{docs}`},
+		{"no hyperref", `\usepackage{graphicx}`, `docs (\texttt{https://example.com/\#a})`},
+		{"commented out", `% \usepackage{hyperref}`, `docs (\texttt{https://example.com/\#a})`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			templateObj, err := newReportTemplate(tt.preamble+"\n[[textpanel .]]", url.Values{})
+			if err != nil {
+				t.Fatalf("Could not parse template: %v", err)
+			}
+			var buf bytes.Buffer
+			if err = templateObj.Execute(&buf, panel); err != nil {
+				t.Fatalf("Could not execute template: %v", err)
+			}
+			if !strings.Contains(buf.String(), tt.expected) {
+				t.Errorf("Expected %q in %q", tt.expected, buf.String())
+			}
+		})
+	}
+}
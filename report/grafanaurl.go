@@ -0,0 +1,171 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package report
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Netcracker/grafana-reporter/dashboard"
+	"github.com/Netcracker/grafana-reporter/timerange"
+)
+
+// dashboardURL is the URL of Grafana dashboard copied from the browser,
+// for example https://grafana/d/uid/slug?orgId=1&from=now-6h&to=now&var-env=prod&viewPanel=2
+type dashboardURL struct {
+	UID      string
+	OrgID    int
+	From     string
+	To       string
+	Vars     url.Values
+	Timezone string
+	// ViewPanel is the ID of the panel opened in view mode, it is 0 for the whole dashboard
+	ViewPanel int
+}
+
+// parseDashboardURL reads the dashboard URL. Grafana can be served from a sub path, so the path is searched
+// for /d/{uid} part. The host of the URL is ignored, dashboards are always requested from the configured Grafana.
+func parseDashboardURL(rawURL string) (*dashboardURL, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return nil, fmt.Errorf("could not parse Grafana dashboard URL :%w", err)
+	}
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	result := &dashboardURL{Vars: url.Values{}}
+	for i := 0; i+1 < len(segments); i++ {
+		if segments[i] == "d" || segments[i] == "d-solo" {
+			result.UID = segments[i+1]
+			break
+		}
+	}
+	if result.UID == "" {
+		return nil, fmt.Errorf("URL %q is not a Grafana dashboard URL, path /d/{uid} is not found", rawURL)
+	}
+
+	query := u.Query()
+	result.From = query.Get("from")
+	result.To = query.Get("to")
+	result.Timezone = query.Get("timezone")
+	if value := query.Get("orgId"); value != "" {
+		if result.OrgID, err = strconv.Atoi(value); err != nil || result.OrgID < 1 {
+			return nil, fmt.Errorf("parameter %q of the URL must be a positive number, got %q", "orgId", value)
+		}
+	}
+	// viewPanel is the panel ID in old Grafana versions and panel key like panel-2 in new ones
+	for _, name := range []string{"viewPanel", "panelId"} {
+		if value := query.Get(name); value != "" {
+			if result.ViewPanel, err = strconv.Atoi(strings.TrimPrefix(value, "panel-")); err != nil || result.ViewPanel < 1 {
+				return nil, fmt.Errorf("parameter %q of the URL must be a panel ID, got %q", name, value)
+			}
+			break
+		}
+	}
+	for name, values := range query {
+		if strings.HasPrefix(name, "var-") {
+			result.Vars[name] = values
+		}
+	}
+	return result, nil
+}
+
+// apply sets parameters of the URL to the parameters of the report. Only one panel is included in view mode.
+func (d *dashboardURL) apply(params *reportParameters, now time.Time) error {
+	var err error
+	params.DashboardUID = d.UID
+	if d.OrgID > 0 {
+		params.OrgID = d.OrgID
+	}
+	if d.Timezone != "" {
+		location, err := timerange.ParseLocation(d.Timezone)
+		if err != nil {
+			return err
+		}
+		params.Timezone = getTimezoneName(location)
+	}
+	for name, values := range d.Vars {
+		params.Vars[name] = values
+	}
+	if err = params.overrideTimerange(d.From, d.To, now); err != nil {
+		return err
+	}
+	if d.ViewPanel > 0 {
+		params.ViewPanel = d.ViewPanel
+		// the panel can be in a collapsed row
+		params.RowsMode = dashboard.RowsAll
+		if params.Selection, err = dashboard.ParseSelection(url.Values{dashboard.ParamIncludePanels: {strconv.Itoa(d.ViewPanel)}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HandleGenerateReportFromURL godoc
+//
+//	@Summary		Generate report of Grafana dashboard URL
+//	@Description	Generate report of the dashboard URL copied from the browser with the same time range, variables, organization, time zone and the panel in view mode. Other parameters are the same as for the report of the dashboard UID
+//	@Tags			Generate
+//	@id				generateReportFromURL
+//	@Param			Authorization	header	string	true	"Authentication header"
+//	@Param			url				query	string	true	"Grafana dashboard URL, for example https://grafana/d/uid/slug?orgId=1&from=now-6h&to=now&var-env=prod"
+//	@Param			template		query	string	false	"PDF tex template name"
+//	@Param			rows			query	string	false	"Rows to include: expanded, collapsed or all"	Enums(expanded, collapsed, all)
+//	@Param			alerts			query	bool	false	"If true, the summary of alert rules linked to the dashboard panels is included to the report"
+//	@Param			onPanelError	query	string	false	"Policy of panels that are not rendered: fail the report, replace them with placeholders or skip them"	Enums(fail, placeholder, skip)
+//	@Produce		octet-stream
+//	@Success		200	{object}	string	"OK"
+//	@Header			200	{string}	Report-Status			"complete or partial if some panels are not rendered"
+//	@Header			200	{int}		Report-Failed-Panels	"Number of panels that are not rendered"
+//	@Failure		400	{string}	string	"Bad Request"
+//	@Failure		401	{string}	string	"Unauthorized"
+//	@Router			/api/v1/report [get]
+func (g *GrafanaInstance) HandleGenerateReportFromURL(writer http.ResponseWriter, request *http.Request) {
+	startTime := time.Now()
+
+	target, err := parseDashboardURL(request.URL.Query().Get("url"))
+	if err != nil {
+		slog.Error(fmt.Sprintf("Error occurred when parsing dashboard URL. Error: %v", err))
+		writeErrorResponse(writer, http.StatusBadRequest, err)
+		return
+	}
+	params, status, err := g.getReportParametersFromRequest(request, target.UID, g.DefaultTemplate, startTime)
+	if err != nil {
+		writeErrorResponse(writer, status, err)
+		return
+	}
+	if err = target.apply(params, startTime); err != nil {
+		slog.Error(fmt.Sprintf("Error occurred when parsing dashboard URL. Error: %v", err))
+		writeErrorResponse(writer, http.StatusBadRequest, err)
+		return
+	}
+	ctx, cancel := params.getContext(request.Context())
+	defer cancel()
+	report, err := g.runReport(ctx, params, nil, nil, nil, startTime)
+	duration := time.Since(startTime).String()
+	writer.Header().Set("Duration", duration)
+	slog.Info(fmt.Sprintf("The request %s took %s", request.RequestURI, duration))
+	if err != nil {
+		slog.Error(fmt.Sprintf("Error occurred when generating report. Error: %v", err))
+		writeErrorResponse(writer, getErrorStatus(err), err)
+		return
+	}
+	setReportStatusHeaders(writer, params)
+	writeReportResponse(writer, params.RequestID, report)
+}
@@ -0,0 +1,89 @@
+package report
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/Netcracker/grafana-reporter/dashboard"
+	"github.com/Netcracker/grafana-reporter/timerange"
+)
+
+func TestParseDashboardURL(t *testing.T) {
+	tests := []struct {
+		name      string
+		url       string
+		uid       string
+		orgID     int
+		viewPanel int
+		isError   bool
+	}{
+		{"dashboard", "https://grafana.example.com/d/abc/my-dashboard?orgId=2&from=now-6h&to=now", "abc", 2, 0, false},
+		{"sub path", "https://example.com/grafana/d/abc/my-dashboard", "abc", 0, 0, false},
+		{"without slug", "/d/abc?viewPanel=5", "abc", 0, 5, false},
+		{"panel key", "https://grafana/d/abc/slug?viewPanel=panel-7", "abc", 0, 7, false},
+		{"solo", "https://grafana/d-solo/abc/slug?panelId=3", "abc", 0, 3, false},
+		{"not dashboard", "https://grafana/explore?left=1", "", 0, 0, true},
+		{"invalid org", "https://grafana/d/abc?orgId=main", "", 0, 0, true},
+		{"invalid panel", "https://grafana/d/abc?viewPanel=abc", "", 0, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := parseDashboardURL(tt.url)
+			if (err != nil) != tt.isError {
+				t.Fatalf("parseDashboardURL() error = %v; want error %t", err, tt.isError)
+			}
+			if tt.isError {
+				return
+			}
+			if result.UID != tt.uid || result.OrgID != tt.orgID || result.ViewPanel != tt.viewPanel {
+				t.Errorf("parseDashboardURL() = %+v; want uid %s, orgId %d, viewPanel %d", result, tt.uid, tt.orgID, tt.viewPanel)
+			}
+		})
+	}
+}
+
+func TestDashboardURLApply(t *testing.T) {
+	now := time.Date(2024, 1, 25, 14, 43, 12, 0, time.UTC)
+	target, err := parseDashboardURL("https://grafana/d/abc/slug?orgId=2&from=now-6h&to=now&var-env=prod&var-pod=a&var-pod=b&timezone=Europe/Berlin&viewPanel=4&refresh=5s")
+	if err != nil {
+		t.Fatalf("parseDashboardURL failed: %v", err)
+	}
+	params := &reportParameters{
+		DashboardUID: "other",
+		Timerange:    &timerange.TimerangeData{From: "now-1h", To: "now", DateFrom: now.Add(-time.Hour), DateTo: now},
+		Vars:         url.Values{"var-env": {"dev"}, "var-region": {"eu"}},
+		RowsMode:     dashboard.RowsExpanded,
+	}
+	if err = target.apply(params, now); err != nil {
+		t.Fatalf("apply failed: %v", err)
+	}
+	if params.DashboardUID != "abc" || params.OrgID != 2 || params.Timezone != "Europe/Berlin" {
+		t.Errorf("apply() = %+v", params)
+	}
+	if params.Timerange.From != "now-6h" || !params.Timerange.DateFrom.Equal(now.Add(-6*time.Hour)) {
+		t.Errorf("Time range = %+v; want now-6h", params.Timerange)
+	}
+	if params.Vars.Get("var-env") != "prod" || len(params.Vars["var-pod"]) != 2 || params.Vars.Get("var-region") != "eu" || params.Vars.Has("refresh") {
+		t.Errorf("Variables = %v", params.Vars)
+	}
+	if params.ViewPanel != 4 || params.RowsMode != dashboard.RowsAll || !params.Selection.IncludesPanel(dashboard.Panel{ID: 4}) || params.Selection.IncludesPanel(dashboard.Panel{ID: 1}) {
+		t.Errorf("View mode = %d, rows %s, selection %s", params.ViewPanel, params.RowsMode, params.Selection.String())
+	}
+	params.RequestID = generateUniqueRequestID(params)
+	if params.RequestID != "abc_report_now-6h-now_all_partial-55dcf05d_org2_tz-Europe-Berlin" {
+		t.Errorf("generateUniqueRequestID() = %q", params.RequestID)
+	}
+}
+
+func TestHandleGenerateReportFromInvalidURL(t *testing.T) {
+	g := &GrafanaInstance{DefaultFrom: "now-1h", DefaultTo: "now", RowsMode: dashboard.RowsExpanded}
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/report?url="+url.QueryEscape("https://grafana/explore"), nil)
+	w := httptest.NewRecorder()
+	g.HandleGenerateReportFromURL(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package report
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"math"
+	"mime"
+	"strconv"
+	"strings"
+)
+
+const (
+	// maxPanelImageBytes limits the size of the response of Grafana image renderer
+	maxPanelImageBytes = 64 << 20
+	// maxPanelImagePixels limits the size of the panel image in pixels including the device scale factor,
+	// so the image is not decoded if it does not fit the memory
+	maxPanelImagePixels = 50_000_000
+	// maxContentSamples limits the number of pixels compared with the background
+	maxContentSamples = 1 << 16
+	// backgroundTolerance is the difference of color channels of the pixel from the background that is not content
+	backgroundTolerance = 16
+	// maxResponseSnippet is the length of the response included to the error if it is not an image
+	maxResponseSnippet = 200
+)
+
+// invalidImageError is the response of Grafana image renderer with status 200 that is not the image of the panel,
+// for example HTML page of the error, the image of wrong size or the blank image
+type invalidImageError struct {
+	Reason string
+	// Permanent is true if the same request gets the same response, for example the image of wrong size, so it is not retried
+	Permanent bool
+	// Kept is true if the image is blank and it is saved to the report, because blank images are allowed
+	Kept bool
+}
+
+func (e *invalidImageError) Error() string {
+	return fmt.Sprintf("invalid image of Grafana panel: %s", e.Reason)
+}
+
+// imageSize is the size of the panel image requested from Grafana image renderer
+type imageSize struct {
+	Width  int
+	Height int
+	Scale  int
+}
+
+// matches returns true if the image has the requested size or the size multiplied by the device scale factor
+func (s imageSize) matches(width, height int) bool {
+	if s.Width <= 0 || s.Height <= 0 {
+		return true
+	}
+	scale := max(s.Scale, 1)
+	return (width == s.Width && height == s.Height) || (width == s.Width*scale && height == s.Height*scale)
+}
+
+// validatePanelImage checks that the response of Grafana image renderer is PNG image of the requested size and
+// returns the decoded image. The image is decoded once after its size is checked.
+func validatePanelImage(contentType string, body []byte, size imageSize) (image.Image, error) {
+	if len(body) > maxPanelImageBytes {
+		return nil, &invalidImageError{Reason: fmt.Sprintf("image is larger than %d bytes", maxPanelImageBytes), Permanent: true}
+	}
+	if contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || mediaType != "image/png" {
+			return nil, &invalidImageError{Reason: fmt.Sprintf("response is %q instead of PNG image: %s", contentType, getResponseSnippet(body))}
+		}
+	}
+	config, err := png.DecodeConfig(bytes.NewReader(body))
+	if err != nil {
+		return nil, &invalidImageError{Reason: fmt.Sprintf("response is not PNG image (%v): %s", err, getResponseSnippet(body))}
+	}
+	if int64(config.Width)*int64(config.Height) > maxPanelImagePixels {
+		return nil, &invalidImageError{Reason: fmt.Sprintf("image is %dx%d pixels, more than %d pixels", config.Width, config.Height, maxPanelImagePixels), Permanent: true}
+	}
+	if !size.matches(config.Width, config.Height) {
+		return nil, &invalidImageError{Reason: fmt.Sprintf("image is %dx%d pixels, requested %dx%d", config.Width, config.Height, size.Width, size.Height), Permanent: true}
+	}
+	img, err := png.Decode(bytes.NewReader(body))
+	if err != nil {
+		return nil, &invalidImageError{Reason: fmt.Sprintf("could not decode PNG image: %v", err)}
+	}
+	return img, nil
+}
+
+// checkBlankPanelImage returns the error if less than minContentPercent of pixels of the decoded image differ from
+// the background. Grafana responds with the image of the message if the panel has no data or its plugin is not found.
+// The error tells that the image is kept in the report if keep is true. It is not checked if minContentPercent is 0.
+func checkBlankPanelImage(img image.Image, minContentPercent float64, keep bool) error {
+	if minContentPercent <= 0 {
+		return nil
+	}
+	if content := getContentPercent(img); content < minContentPercent {
+		return &invalidImageError{Reason: fmt.Sprintf("image is blank or shows a message like \"No data\" or \"Panel plugin not found\": "+
+			"%.3f%% of pixels differ from the background", content), Kept: keep}
+	}
+	return nil
+}
+
+// isKeptImage returns true if the panel is not rendered because its image is blank, but the image is kept in the report
+func isKeptImage(err error) bool {
+	var imageErr *invalidImageError
+	return errors.As(err, &imageErr) && imageErr.Kept
+}
+
+// getContentPercent returns the share of pixels of the image that differ from the background in percent.
+// The most frequent color is the background, big images are sampled on the grid.
+func getContentPercent(img image.Image) float64 {
+	bounds := img.Bounds()
+	pixels := bounds.Dx() * bounds.Dy()
+	if pixels == 0 {
+		return 0
+	}
+	step := max(1, int(math.Sqrt(float64(pixels)/maxContentSamples)))
+	var samples [][4]uint8
+	counts := map[[4]uint8]int{}
+	var background [4]uint8
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += step {
+		for x := bounds.Min.X; x < bounds.Max.X; x += step {
+			r, g, b, a := img.At(x, y).RGBA()
+			sample := [4]uint8{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8), uint8(a >> 8)}
+			samples = append(samples, sample)
+			counts[sample]++
+			if counts[sample] > counts[background] {
+				background = sample
+			}
+		}
+	}
+	content := 0
+	for _, sample := range samples {
+		for i := range sample {
+			if diff := int(sample[i]) - int(background[i]); diff > backgroundTolerance || diff < -backgroundTolerance {
+				content++
+				break
+			}
+		}
+	}
+	return float64(content) * 100 / float64(len(samples))
+}
+
+// getResponseSnippet returns the beginning of the response that is not an image to explain the error
+func getResponseSnippet(body []byte) string {
+	if len(body) > maxResponseSnippet {
+		body = body[:maxResponseSnippet]
+	}
+	snippet := strings.Join(strings.Fields(strings.ToValidUTF8(string(body), "")), " ")
+	if snippet == "" {
+		return "empty response"
+	}
+	return strconv.Quote(snippet)
+}
@@ -0,0 +1,219 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestPanelImage returns PNG image of the panel with the grid of lines like the graph or the blank image
+func newTestPanelImage(width, height int, blank bool) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+			if !blank && (x%10 == 0 || y%10 == 0) {
+				c = color.RGBA{R: 0x30, G: 0x80, B: 0xd0, A: 0xff}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, img)
+	return buf.Bytes()
+}
+
+// newTestPNGHeader returns the beginning of PNG image with the header of the given size without pixels
+func newTestPNGHeader(width, height int) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("\x89PNG\r\n\x1a\n")
+	header := make([]byte, 17)
+	copy(header, "IHDR")
+	binary.BigEndian.PutUint32(header[4:], uint32(width))
+	binary.BigEndian.PutUint32(header[8:], uint32(height))
+	header[12] = 8 // bit depth
+	header[13] = 6 // RGBA
+	_ = binary.Write(&buf, binary.BigEndian, uint32(len(header)-4))
+	buf.Write(header)
+	_ = binary.Write(&buf, binary.BigEndian, crc32.ChecksumIEEE(header))
+	return buf.Bytes()
+}
+
+// writeTestPanelImage responds like Grafana image renderer with the image of the requested size
+func writeTestPanelImage(w http.ResponseWriter, r *http.Request, blank bool) {
+	width, _ := strconv.Atoi(r.URL.Query().Get("width"))
+	height, _ := strconv.Atoi(r.URL.Query().Get("height"))
+	scale, err := strconv.Atoi(r.URL.Query().Get("scale"))
+	if err != nil {
+		scale = 1
+	}
+	w.Header().Set("Content-Type", "image/png")
+	_, _ = w.Write(newTestPanelImage(max(width, 1)*scale, max(height, 1)*scale, blank))
+}
+
+func TestValidatePanelImage(t *testing.T) {
+	size := imageSize{Width: 100, Height: 50, Scale: 2}
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		wantReason  string
+		retryable   bool
+	}{
+		{"image", "image/png", newTestPanelImage(100, 50, false), "", false},
+		{"scaled image", "", newTestPanelImage(200, 100, false), "", false},
+		{"HTML page", "text/html; charset=utf-8", []byte("<html>\n<body>Grafana error</body></html>"), `"text/html; charset=utf-8" instead of PNG image: "<html> <body>Grafana error</body></html>"`, true},
+		{"not PNG", "image/png", []byte("GIF89a"), "response is not PNG image", true},
+		{"empty", "image/png", nil, "empty response", true},
+		{"truncated", "image/png", newTestPNGHeader(100, 50), "could not decode PNG image", true},
+		{"wrong size", "image/png", newTestPanelImage(1, 1, false), "image is 1x1 pixels, requested 100x50", false},
+		{"too many pixels", "image/png", newTestPNGHeader(10000, 10000), "image is 10000x10000 pixels, more than", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := validatePanelImage(tt.contentType, tt.body, size)
+			if tt.wantReason == "" {
+				if err != nil || img == nil {
+					t.Errorf("validatePanelImage() = %v, %v; want the image", img, err)
+				}
+				return
+			}
+			var imageErr *invalidImageError
+			if !errors.As(err, &imageErr) || !strings.Contains(err.Error(), tt.wantReason) {
+				t.Errorf("validatePanelImage() error = %v; want invalid image error with %q", err, tt.wantReason)
+			}
+			if isRetryableRenderError(err) != tt.retryable {
+				t.Errorf("isRetryableRenderError(%v) = %t; want %t", err, !tt.retryable, tt.retryable)
+			}
+		})
+	}
+	if _, err := validatePanelImage("image/png", newTestPanelImage(100, 50, true), size); err != nil {
+		t.Errorf("validatePanelImage() error = %v; want nil for the blank image", err)
+	}
+}
+
+// decodeTestPanelImage returns the decoded image of newTestPanelImage
+func decodeTestPanelImage(t *testing.T, blank bool) image.Image {
+	img, err := png.Decode(bytes.NewReader(newTestPanelImage(100, 50, blank)))
+	if err != nil {
+		t.Fatalf("png.Decode failed: %v", err)
+	}
+	return img
+}
+
+func TestCheckBlankPanelImage(t *testing.T) {
+	if err := checkBlankPanelImage(decodeTestPanelImage(t, false), defaultMinContentPercent, false); err != nil {
+		t.Errorf("checkBlankPanelImage() = %v for the image of the graph; want nil", err)
+	}
+	err := checkBlankPanelImage(decodeTestPanelImage(t, true), defaultMinContentPercent, false)
+	var imageErr *invalidImageError
+	if !errors.As(err, &imageErr) || !strings.Contains(err.Error(), "0.000% of pixels") || !isRetryableRenderError(err) || isKeptImage(err) {
+		t.Errorf("checkBlankPanelImage() = %v for the blank image; want retryable invalid image error", err)
+	}
+	if err = checkBlankPanelImage(decodeTestPanelImage(t, true), defaultMinContentPercent, true); !isKeptImage(err) {
+		t.Errorf("checkBlankPanelImage() = %v; want error of the kept image", err)
+	}
+	if err = checkBlankPanelImage(decodeTestPanelImage(t, true), 0, false); err != nil {
+		t.Errorf("checkBlankPanelImage() = %v; want nil if the content is not checked", err)
+	}
+}
+
+func TestGetContentPercent(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 100; x++ {
+			img.Set(x, y, color.White)
+		}
+	}
+	// the message like "No data" in the middle of the panel
+	for y := 45; y < 50; y++ {
+		for x := 40; x < 60; x++ {
+			img.Set(x, y, color.Black)
+		}
+	}
+	// the pixel close to the background is not content
+	img.Set(0, 0, color.RGBA{R: 0xf8, G: 0xf8, B: 0xf8, A: 0xff})
+	if got := getContentPercent(img); got != 1 {
+		t.Errorf("getContentPercent() = %v; want 1", got)
+	}
+}
+
+func TestRenderPanelRetriesInvalidImage(t *testing.T) {
+	setRetryPolicy(t, renderRetryPolicy{Attempts: 2, InitialDelay: time.Millisecond, MaxDelay: time.Millisecond, RenderTimeout: time.Second})
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte("<html>Login</html>"))
+			return
+		}
+		writeTestPanelImage(w, r, false)
+	}))
+	defer server.Close()
+	g := &GrafanaInstance{Endpoint: server.URL}
+	requestID := "invalid_image_test"
+	defer removePanelImages(requestID)
+	err := g.renderPanel(context.Background(), &PanelRequestInfo{URL: server.URL + "?width=40&height=20", ImageName: "1.png", Width: 40, Height: 20}, requestID, "", 0)
+	if err != nil || requests != 2 {
+		t.Errorf("renderPanel() = %v after %d requests; want the image after 2 requests", err, requests)
+	}
+}
+
+// newBlankImageServer returns Grafana that responds with the blank image and counts requests
+func newBlankImageServer(t *testing.T, requests *int) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		writeTestPanelImage(w, r, true)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRenderPanelRetriesBlankImage(t *testing.T) {
+	setRetryPolicy(t, renderRetryPolicy{Attempts: 2, InitialDelay: time.Millisecond, MaxDelay: time.Millisecond, RenderTimeout: time.Second,
+		MinContentPercent: defaultMinContentPercent})
+	requests := 0
+	server := newBlankImageServer(t, &requests)
+	t.Setenv("TMPDIR", t.TempDir())
+	g := &GrafanaInstance{Endpoint: server.URL}
+	requestID := "blank_image_test"
+	err := g.renderPanel(context.Background(), &PanelRequestInfo{URL: server.URL + "?width=40&height=20", ImageName: "1.png", Width: 40, Height: 20}, requestID, "", 0)
+	var imageErr *invalidImageError
+	if !errors.As(err, &imageErr) || isKeptImage(err) || requests != 2 {
+		t.Errorf("renderPanel() = %v after %d requests; want invalid image error after 2 requests", err, requests)
+	}
+	if _, err = os.Stat(path.Join(getPanelsDirPath(requestID), "1.png")); err == nil {
+		t.Errorf("Blank image is saved; want it to be handled as the failed panel")
+	}
+}
+
+func TestRenderPanelKeepsBlankImage(t *testing.T) {
+	setRetryPolicy(t, renderRetryPolicy{Attempts: 2, InitialDelay: time.Millisecond, MaxDelay: time.Millisecond, RenderTimeout: time.Second,
+		MinContentPercent: defaultMinContentPercent, KeepBlankImages: true})
+	requests := 0
+	server := newBlankImageServer(t, &requests)
+	g := &GrafanaInstance{Endpoint: server.URL}
+	requestID := "kept_blank_image_test"
+	defer removePanelImages(requestID)
+	err := g.renderPanel(context.Background(), &PanelRequestInfo{URL: server.URL + "?width=40&height=20", ImageName: "1.png", Width: 40, Height: 20}, requestID, "", 0)
+	if !isKeptImage(err) || requests != 2 {
+		t.Errorf("renderPanel() = %v after %d requests; want error of the kept image after 2 requests", err, requests)
+	}
+	if _, err = os.Stat(path.Join(getPanelsDirPath(requestID), "1.png")); err != nil {
+		t.Errorf("Blank image is not saved: %v", err)
+	}
+}
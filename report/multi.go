@@ -0,0 +1,255 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package report
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Netcracker/grafana-reporter/dashboard"
+	"github.com/Netcracker/grafana-reporter/timerange"
+)
+
+const (
+	// defaultMultiTemplate is the template of the report of several dashboards
+	defaultMultiTemplate = "multiTemplate"
+	// maxReportSections limits the number of dashboards in one report
+	maxReportSections = 100
+	// multiReportPasses is the number of pdflatex runs to build table of contents and bookmarks
+	multiReportPasses = 2
+)
+
+type multiPdfData struct {
+	Title         string
+	Sections      []*multiPdfSection
+	From          string
+	To            string
+	TimestampFrom string
+	TimestampTo   string
+	Vars          string
+	// Width is the width of the screen the panels are rendered on, it is passed to GetRelativeWidth of panels
+	Width int
+}
+
+// multiPdfSection is a dashboard of the report of several dashboards with its time range and variables
+type multiPdfSection struct {
+	*dashboard.StructuredDashboard
+	From          string
+	To            string
+	TimestampFrom string
+	TimestampTo   string
+	Vars          string
+	// Variables are passed to textpanel function of templates
+	Variables url.Values
+}
+
+// reportSection is a dashboard of the report of several dashboards with its own parameters
+type reportSection struct {
+	// Title replaces the title of the dashboard if it is set
+	Title  string
+	Params *reportParameters
+}
+
+// HandleGenerateMultiReport godoc
+//
+//	@Summary		Generate report of several Grafana dashboards
+//	@Description	Generate one PDF file for all dashboards found in the folders or by the tags. Every dashboard is a section of the report with the same time range and variables `var-`
+//	@Tags			Generate
+//	@id				generateMultiReport
+//	@Param			Authorization	header	string	true	"Authentication header"
+//	@Param			folderUID		query	string	false	"UIDs of folders to search dashboards in separated by comma"
+//	@Param			tag				query	string	false	"Tags of dashboards separated by comma, dashboards must have all the tags"
+//	@Param			template		query	string	false	"PDF tex template name"	default(multiTemplate)
+//	@Param			from			query	string	false	"The start of time range"
+//	@Param			to				query	string	false	"The end of time range"
+//	@Param			rows			query	string	false	"Rows to include: expanded, collapsed or all"	Enums(expanded, collapsed, all)
+//	@Param			includePanelTitle	query	string	false	"Regular expression of titles of panels to include"
+//	@Param			excludePanelTitle	query	string	false	"Regular expression of titles of panels to exclude"
+//	@Param			includeRows			query	string	false	"Regular expression of titles of rows to include"
+//	@Param			excludeRows			query	string	false	"Regular expression of titles of rows to exclude"
+//	@Param			includePanelTypes	query	string	false	"Types of panels to include separated by comma"
+//	@Param			excludePanelTypes	query	string	false	"Types of panels to exclude separated by comma, for example text,news,dashlist"
+//	@Param			alerts				query	bool	false	"If true, the summary of alert rules linked to the dashboard panels is included to the report"
+//	@Param			orgId				query	int		false	"Grafana organization of the dashboards"
+//	@Param			timezone			query	string	false	"Timezone of the time range: IANA name, utc or offset like +03:00, by default the timezone of the dashboard"
+//	@Param			width				query	int		false	"Width of the screen in pixels the panels are rendered on, by default the width of the template or 1920"
+//	@Param			scale				query	int		false	"Device scale factor of panel images from 1 to 4 for high resolution prints"
+//	@Param			theme				query	string	false	"Theme of panels, by default the theme of the template or light"	Enums(light, dark)
+//	@Param			onPanelError		query	string	false	"Policy of panels that are not rendered: fail the report, replace them with placeholders or skip them"	Enums(fail, placeholder, skip)
+//	@Produce		octet-stream
+//	@Success		200	{object}	string	"OK"
+//	@Header			200	{string}	Report-Status			"complete or partial if some panels are not rendered"
+//	@Header			200	{int}		Report-Failed-Panels	"Number of panels that are not rendered"
+//	@Failure		400	{string}	string	"Bad Request"
+//	@Failure		401	{string}	string	"Unauthorized"
+//	@Router			/api/v1/reports [get]
+func (g *GrafanaInstance) HandleGenerateMultiReport(writer http.ResponseWriter, request *http.Request) {
+	startTime := time.Now()
+
+	search := dashboard.ParseSearch(request.URL.Query())
+	if search.IsEmpty() {
+		err := fmt.Errorf("parameter %q or %q must be set", dashboard.ParamFolderUID, dashboard.ParamTag)
+		slog.Error(fmt.Sprintf("Error occurred when parsing search of dashboards. Error: %v", err))
+		writeErrorResponse(writer, http.StatusBadRequest, err)
+		return
+	}
+	params, status, err := g.getReportParametersFromRequest(request, "", defaultMultiTemplate, startTime)
+	if err != nil {
+		writeErrorResponse(writer, status, err)
+		return
+	}
+	if params.Version > 0 || !params.AsOf.IsZero() {
+		err = fmt.Errorf("dashboard version is not supported for the report of several dashboards")
+		slog.Error(err.Error())
+		writeErrorResponse(writer, http.StatusBadRequest, err)
+		return
+	}
+	if params.Compare != nil {
+		slog.Error(errComparisonNotSupported.Error())
+		writeErrorResponse(writer, http.StatusBadRequest, errComparisonNotSupported)
+		return
+	}
+	params.RequestID = getMultiRequestID(params, search)
+	requestID := params.RequestID
+	slog.Info(fmt.Sprintf("Generating report %q with parameters: search=%q, from=%v, to=%v, template=%s, rows=%s, selection=%q, alerts=%t, vars=%s", requestID, search.String(), params.Timerange.From, params.Timerange.To, params.Template, params.RowsMode, params.Selection.String(), params.Alerts, params.Vars.Encode()))
+	ctx, cancel := params.getContext(request.Context())
+	defer cancel()
+	report, err := g.generateMultiReport(ctx, params, search)
+	duration := time.Since(startTime).String()
+	writer.Header().Set("Duration", duration)
+	slog.Info(fmt.Sprintf("The request %s took %s", request.RequestURI, duration))
+	if err != nil {
+		slog.Error(fmt.Sprintf("Error occurred when generating report. Error: %v", err))
+		writeErrorResponse(writer, getErrorStatus(err), err)
+		return
+	}
+	setReportStatusHeaders(writer, params)
+	writeReportResponse(writer, requestID, report)
+}
+
+// generateMultiReport generates one report of all dashboards found by the search
+func (g *GrafanaInstance) generateMultiReport(ctx context.Context, params *reportParameters, search *dashboard.Search) ([]byte, error) {
+	results, err := g.searchDashboards(ctx, search, params.AuthHeader, params.OrgID)
+	if err != nil {
+		slog.Error(fmt.Sprintf("Error occurred while searching Grafana dashboards: %s", err))
+		return nil, err
+	}
+	sections := make([]*reportSection, 0, len(results))
+	for _, result := range results {
+		sectionParams := *params
+		sectionParams.DashboardUID = result.UID
+		sections = append(sections, &reportSection{Params: &sectionParams})
+	}
+	return g.generateSectionsReport(ctx, params, search.GetTitle(results), sections)
+}
+
+// generateSectionsReport generates one report of the sections in the given order. Dashboards are requested one by one
+// to keep the limit of concurrent render requests, the report fails if any dashboard fails.
+func (g *GrafanaInstance) generateSectionsReport(ctx context.Context, params *reportParameters, title string, sections []*reportSection) ([]byte, error) {
+	var requestIDs []string
+	defer func() {
+		for _, requestID := range requestIDs {
+			removePanelImages(requestID)
+		}
+	}()
+	pdfSections := make([]*multiPdfSection, 0, len(sections))
+	for i, section := range sections {
+		section.Params.RequestID = fmt.Sprintf("%s_%d", params.RequestID, i+1)
+		requestIDs = append(requestIDs, section.Params.RequestID)
+		structuredDashboard, err := g.prepareDashboard(ctx, section.Params)
+		if err != nil {
+			return nil, fmt.Errorf("could not get dashboard %s of the report :%w", section.Params.DashboardUID, err)
+		}
+		if section.Title != "" {
+			structuredDashboard.Title = section.Title
+		}
+		pdfSections = append(pdfSections, &multiPdfSection{
+			StructuredDashboard: structuredDashboard,
+			From:                section.Params.Timerange.From,
+			To:                  section.Params.Timerange.To,
+			TimestampFrom:       section.Params.Timerange.DateFrom.Format(timerange.Format),
+			TimestampTo:         section.Params.Timerange.DateTo.Format(timerange.Format),
+			Vars:                strings.ReplaceAll(section.Params.Vars.Encode(), "&", " "),
+			Variables:           section.Params.Vars,
+		})
+	}
+
+	data := multiPdfData{
+		Title:         title,
+		Sections:      pdfSections,
+		From:          params.Timerange.From,
+		To:            params.Timerange.To,
+		TimestampFrom: params.Timerange.DateFrom.Format(timerange.Format),
+		TimestampTo:   params.Timerange.DateTo.Format(timerange.Format),
+		Vars:          strings.ReplaceAll(params.Vars.Encode(), "&", " "),
+		Width:         g.getRenderOptions(params).Width,
+	}
+	err := renderPdf(ctx, string(g.Templates[params.Template]), params.RequestID, data, params.Vars, multiReportPasses)
+	if err != nil {
+		slog.Error(fmt.Sprintf("Error occurred while generating report file: %s", err))
+		return nil, err
+	}
+	report, err := getReport(params.RequestID)
+	if err != nil {
+		slog.Error(fmt.Sprintf("Error occurred while generating PDF report. Error: %v", err))
+		return nil, err
+	}
+	return report, nil
+}
+
+// searchDashboards requests dashboards found by the search from Grafana and orders them by folders and titles
+func (g *GrafanaInstance) searchDashboards(ctx context.Context, search *dashboard.Search, authHeader string, orgID int) ([]dashboard.SearchResult, error) {
+	urlString, err := url.JoinPath(g.Endpoint, "/api/search")
+	if err != nil {
+		return nil, fmt.Errorf("could not create URL for search of Grafana dashboards :%w", err)
+	}
+	// one more dashboard is requested to find out if the limit is exceeded
+	urlString = fmt.Sprintf("%s?%s", urlString, search.GetQuery(maxReportSections+1).Encode())
+	var found []dashboard.SearchResult
+	if err = g.requestJSON(ctx, urlString, authHeader, orgID, &found); err != nil {
+		return nil, err
+	}
+	var results []dashboard.SearchResult
+	for _, result := range found {
+		if result.Type == "dash-db" && result.UID != "" {
+			results = append(results, result)
+		}
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("no dashboards found by search %s", search.String())
+	}
+	if len(results) > maxReportSections {
+		return nil, fmt.Errorf("more than %d dashboards found by search %s", maxReportSections, search.String())
+	}
+	dashboard.SortSearchResults(results)
+	slog.Debug(fmt.Sprintf("Found %d dashboards by search %s", len(results), search.String()))
+	return results, nil
+}
+
+// getMultiRequestID returns ID of the report of several dashboards. The search is hashed to keep the ID short.
+func getMultiRequestID(params *reportParameters, search *dashboard.Search) string {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(search.String()))
+	multiParams := *params
+	multiParams.DashboardUID = fmt.Sprintf("multi-%08x", hash.Sum32())
+	return generateUniqueRequestID(&multiParams)
+}
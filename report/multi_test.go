@@ -0,0 +1,59 @@
+package report
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Netcracker/grafana-reporter/dashboard"
+	"github.com/Netcracker/grafana-reporter/timerange"
+)
+
+func TestSearchDashboards(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/search" || r.Header.Get("X-Grafana-Org-Id") != "2" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.URL.Query().Get("tag") == "none" {
+			_, _ = w.Write([]byte(`[]`))
+			return
+		}
+		_, _ = w.Write([]byte(`[
+			{"uid": "b", "title": "Nodes", "type": "dash-db", "folderUid": "k8s", "folderTitle": "Kubernetes"},
+			{"uid": "f", "title": "Kubernetes", "type": "dash-folder"},
+			{"uid": "a", "title": "Cluster", "type": "dash-db", "folderUid": "k8s", "folderTitle": "Kubernetes"}
+		]`))
+	}))
+	defer server.Close()
+
+	g := &GrafanaInstance{Endpoint: server.URL}
+	results, err := g.searchDashboards(context.Background(), &dashboard.Search{FolderUIDs: []string{"k8s"}}, "", 2)
+	if err != nil {
+		t.Fatalf("searchDashboards failed: %v", err)
+	}
+	if len(results) != 2 || results[0].UID != "a" || results[1].UID != "b" {
+		t.Errorf("searchDashboards(context.Background(), ) = %+v; want dashboards a and b", results)
+	}
+
+	if _, err = g.searchDashboards(context.Background(), &dashboard.Search{Tags: []string{"none"}}, "", 2); err == nil {
+		t.Error("Expected error if no dashboards are found, got nil")
+	}
+}
+
+func TestGetMultiRequestID(t *testing.T) {
+	params := &reportParameters{
+		Timerange: &timerange.TimerangeData{From: "now-1h", To: "now"},
+		RowsMode:  dashboard.RowsExpanded,
+	}
+	folderID := getMultiRequestID(params, &dashboard.Search{FolderUIDs: []string{"k8s"}})
+	tagID := getMultiRequestID(params, &dashboard.Search{Tags: []string{"sla"}})
+	if !strings.HasPrefix(folderID, "multi-") || folderID == tagID {
+		t.Errorf("getMultiRequestID() = %q, %q; want different IDs with prefix multi-", folderID, tagID)
+	}
+	if params.DashboardUID != "" {
+		t.Errorf("getMultiRequestID() changed parameters: %+v", params)
+	}
+}
@@ -0,0 +1,288 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package report
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Netcracker/grafana-reporter/dashboard"
+	"github.com/Netcracker/grafana-reporter/timerange"
+)
+
+const (
+	defaultPanelTemplate = "panelTemplate"
+	panelFormatPDF       = "pdf"
+	panelFormatPNG       = "png"
+	maxPanelImageSize    = 8192
+	maxPanelImageScale   = 4
+)
+
+var (
+	errPanelNotFound      = errors.New("panel is not found")
+	errPanelImageTooLarge = errors.New("panel image is too large")
+)
+
+// panelImageOptions describes the image of one panel
+type panelImageOptions struct {
+	PanelID int
+	Width   int
+	Height  int
+	Scale   int
+	Format  string
+}
+
+// panelPdfData is the data of the template of one panel report
+type panelPdfData struct {
+	Panel          dashboard.Panel
+	Title          string
+	DashboardTitle string
+	RequestID      string
+	From           string
+	To             string
+	TimestampFrom  string
+	TimestampTo    string
+	Vars           string
+}
+
+// HandleGeneratePanelReport godoc
+//
+//	@Summary		Generate report of one panel
+//	@Description	Render one panel of the dashboard to PNG image or to one page PDF report with the panel title, time range and variables
+//	@Tags			Generate
+//	@id				generatePanelReport
+//	@Param			Authorization	header	string	true	"Authentication header"
+//	@Param			dashboard_uid	path	string	true	"Dashboard UID"
+//	@Param			panel_id		path	int		true	"Panel ID"
+//	@Param			format			query	string	false	"Format of the result"	Enums(pdf, png)
+//	@Param			width			query	int		false	"Width of the panel image in pixels, by default the width of the panel on the dashboard"
+//	@Param			height			query	int		false	"Height of the panel image in pixels, by default the height of the panel on the dashboard"
+//	@Param			scale			query	int		false	"Device scale factor of the panel image from 1 to 4, width*height*scale² must not exceed 50000000 pixels"
+//	@Param			theme			query	string	false	"Theme of the panel, by default the theme of the template or light"	Enums(light, dark)
+//	@Param			template		query	string	false	"PDF tex template name"
+//	@Param			from			query	string	false	"The start of time range"
+//	@Param			to				query	string	false	"The end of time range"
+//	@Param			orgId			query	int		false	"Grafana organization of the dashboard"
+//	@Param			timezone		query	string	false	"Timezone of the time range: IANA name, utc or offset like +03:00, by default the timezone of the dashboard"
+//	@Param			version			query	int		false	"Version of the dashboard to take the panel from"
+//	@Param			asOf			query	string	false	"Time to take the panel from the dashboard version saved at that time"
+//	@Produce		octet-stream
+//	@Success		200	{object}	string	"OK"
+//	@Failure		400	{string}	string	"Bad Request"
+//	@Failure		401	{string}	string	"Unauthorized"
+//	@Failure		404	{string}	string	"Not Found"
+//	@Router			/api/v1/report/{dashboard_uid}/panel/{panel_id} [get]
+func (g *GrafanaInstance) HandleGeneratePanelReport(writer http.ResponseWriter, request *http.Request) {
+	startTime := time.Now()
+
+	urlPath := strings.Split(request.URL.Path, "/")
+	if len(urlPath) != 7 || urlPath[4] == "" || urlPath[5] != "panel" {
+		slog.Error(fmt.Sprintf("Handle of invalid URL path. Path: %s", request.URL.Path))
+		writeErrorResponse(writer, http.StatusBadRequest, fmt.Errorf("invalid URL path: %s", request.URL.Path))
+		return
+	}
+	options, err := getPanelImageOptionsFromRequest(request, urlPath[6])
+	if err != nil {
+		slog.Error(fmt.Sprintf("Error occurred when parsing parameters of the panel. Error: %v", err))
+		writeErrorResponse(writer, http.StatusBadRequest, err)
+		return
+	}
+	params, status, err := g.getReportParametersFromRequest(request, urlPath[4], defaultPanelTemplate, startTime)
+	if err != nil {
+		writeErrorResponse(writer, status, err)
+		return
+	}
+	if params.Compare != nil {
+		slog.Error(errComparisonNotSupported.Error())
+		writeErrorResponse(writer, http.StatusBadRequest, errComparisonNotSupported)
+		return
+	}
+	// the panel can be in any row, other panels and alerts are not rendered
+	params.RowsMode = dashboard.RowsAll
+	params.Selection = nil
+	params.Alerts = false
+	// the width and the scale of the request are the size of the panel image
+	params.Render = renderOptions{Theme: params.Render.Theme}
+	params.RequestID = getPanelRequestID(params, options)
+	slog.Info(fmt.Sprintf("Generating panel report %q with parameters: dashboardId=%s, panelId=%d, format=%s, from=%v, to=%v, vars=%s", params.RequestID, params.DashboardUID, options.PanelID, options.Format, params.Timerange.From, params.Timerange.To, params.Vars.Encode()))
+	ctx, cancel := params.getContext(request.Context())
+	defer cancel()
+	report, err := g.generatePanelReport(ctx, params, options)
+	duration := time.Since(startTime).String()
+	writer.Header().Set("Duration", duration)
+	slog.Info(fmt.Sprintf("The request %s took %s", request.RequestURI, duration))
+	if err != nil {
+		slog.Error(fmt.Sprintf("Error occurred when generating panel report. Error: %v", err))
+		status = getErrorStatus(err)
+		if errors.Is(err, errPanelNotFound) {
+			status = http.StatusNotFound
+		}
+		if errors.Is(err, errPanelImageTooLarge) {
+			status = http.StatusBadRequest
+		}
+		writeErrorResponse(writer, status, err)
+		return
+	}
+	if options.Format == panelFormatPNG {
+		writer.Header().Set("Content-Type", "image/png")
+		writer.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s.png", params.RequestID))
+		writer.WriteHeader(http.StatusOK)
+		if _, err = writer.Write(report); err != nil {
+			slog.Error("Could not write response", "error", err)
+		}
+		return
+	}
+	writeReportResponse(writer, params.RequestID, report)
+}
+
+// getPanelImageOptionsFromRequest reads the panel ID from the path and the image parameters from the request
+func getPanelImageOptionsFromRequest(request *http.Request, panelID string) (*panelImageOptions, error) {
+	options := &panelImageOptions{Format: getQueryParameter(request.URL.Query(), "format", panelFormatPDF)}
+	if options.Format != panelFormatPDF && options.Format != panelFormatPNG {
+		return nil, fmt.Errorf("invalid format %q, must be %s or %s", options.Format, panelFormatPDF, panelFormatPNG)
+	}
+	var err error
+	if options.PanelID, err = parsePositiveInt(panelID, "panel ID", 0); err != nil {
+		return nil, err
+	}
+	if options.Width, err = parsePositiveInt(request.URL.Query().Get("width"), "width", maxPanelImageSize); err != nil {
+		return nil, err
+	}
+	if options.Height, err = parsePositiveInt(request.URL.Query().Get("height"), "height", maxPanelImageSize); err != nil {
+		return nil, err
+	}
+	if options.Scale, err = parsePositiveInt(request.URL.Query().Get("scale"), "scale", maxPanelImageScale); err != nil {
+		return nil, err
+	}
+	if options.PanelID == 0 {
+		return nil, fmt.Errorf("panel ID is required")
+	}
+	if options.Width > 0 && options.Height > 0 {
+		if err = checkPanelImagePixels(options.Width, options.Height, options.Scale); err != nil {
+			return nil, err
+		}
+	}
+	return options, nil
+}
+
+// checkPanelImagePixels returns the error if the image of the size multiplied by the device scale factor is larger
+// than the image accepted from Grafana image renderer
+func checkPanelImagePixels(width, height, scale int) error {
+	scale = max(scale, 1)
+	if pixels := int64(width) * int64(height) * int64(scale*scale); pixels > maxPanelImagePixels {
+		return fmt.Errorf("%w: %dx%d pixels with scale %d is %d pixels, more than %d", errPanelImageTooLarge, width, height, scale, pixels, maxPanelImagePixels)
+	}
+	return nil
+}
+
+// parsePositiveInt parses the optional positive integer not greater than maxValue if maxValue is set.
+// It returns 0 for the empty value
+func parsePositiveInt(value, name string, maxValue int) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil || number <= 0 {
+		return 0, fmt.Errorf("%s must be a positive integer, got %q", name, value)
+	}
+	if maxValue > 0 && number > maxValue {
+		return 0, fmt.Errorf("%s must not be greater than %d, got %d", name, maxValue, number)
+	}
+	return number, nil
+}
+
+// getPanelRequestID returns the unique ID of the panel report
+func getPanelRequestID(params *reportParameters, options *panelImageOptions) string {
+	requestID := fmt.Sprintf("%s_panel%d", generateUniqueRequestID(params), options.PanelID)
+	if options.Width > 0 || options.Height > 0 {
+		requestID += fmt.Sprintf("_%dx%d", options.Width, options.Height)
+	}
+	if options.Scale > 0 {
+		requestID += fmt.Sprintf("_scale%d", options.Scale)
+	}
+	return fmt.Sprintf("%s_%s", requestID, options.Format)
+}
+
+// generatePanelReport renders the panel and returns its PNG image or the PDF report
+func (g *GrafanaInstance) generatePanelReport(ctx context.Context, params *reportParameters, options *panelImageOptions) ([]byte, error) {
+	structuredDashboard, err := g.getDashboard(ctx, params)
+	if err != nil {
+		slog.Error(fmt.Sprintf("Error occurred while getting Grafana dashboard: %s", err))
+		return nil, err
+	}
+	structuredDashboard.RequestID = params.RequestID
+	setDashboardTime(structuredDashboard, params)
+	panel, ok := structuredDashboard.GetPanel(options.PanelID)
+	if !ok {
+		return nil, fmt.Errorf("%w: panel %d on the dashboard %s", errPanelNotFound, options.PanelID, params.DashboardUID)
+	}
+	render := g.getRenderOptions(params)
+	width, height, scale := options.Width, options.Height, options.Scale
+	if width == 0 {
+		width = panel.GetPxWidth(render.Width)
+	}
+	if height == 0 {
+		height = panel.GetPxHeight(render.Width)
+	}
+	if scale == 0 {
+		scale = render.Scale
+	}
+	if err = checkPanelImagePixels(width, height, scale); err != nil {
+		return nil, err
+	}
+	urlString, err := getPanelRenderURL(g.Endpoint, structuredDashboard, panel.ID, width, height, scale, render.Theme,
+		params.Timerange.GrafanaFrom(), params.Timerange.GrafanaTo(), params.Vars, params.OrgID, params.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	imageName := fmt.Sprintf("%d.png", panel.ID)
+	defer removePanelImages(params.RequestID)
+	if err = g.renderPanel(ctx, &PanelRequestInfo{URL: urlString, ImageName: imageName, PanelID: panel.ID, Width: width, Height: height, Scale: scale}, params.RequestID, params.AuthHeader, params.OrgID); err != nil {
+		return nil, err
+	}
+	if options.Format == panelFormatPNG {
+		image, err := os.ReadFile(path.Join(getPanelsDirPath(params.RequestID), imageName))
+		if err != nil {
+			return nil, fmt.Errorf("could not read image of the panel :%w", err)
+		}
+		return image, nil
+	}
+
+	data := panelPdfData{
+		Panel:          panel,
+		Title:          dashboard.InterpolateVariables(panel.Title, params.Vars),
+		DashboardTitle: structuredDashboard.Title,
+		RequestID:      params.RequestID,
+		From:           params.Timerange.From,
+		To:             params.Timerange.To,
+		TimestampFrom:  params.Timerange.DateFrom.Format(timerange.Format),
+		TimestampTo:    params.Timerange.DateTo.Format(timerange.Format),
+		Vars:           strings.ReplaceAll(params.Vars.Encode(), "&", " "),
+	}
+	if err = renderPdf(ctx, string(g.Templates[params.Template]), params.RequestID, data, params.Vars, 1); err != nil {
+		slog.Error(fmt.Sprintf("Error occurred while generating panel report file: %s", err))
+		return nil, err
+	}
+	return getReport(params.RequestID)
+}
@@ -0,0 +1,145 @@
+package report
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/Netcracker/grafana-reporter/dashboard"
+	"github.com/Netcracker/grafana-reporter/timerange"
+)
+
+func TestGetPanelImageOptionsFromRequest(t *testing.T) {
+	tests := []struct {
+		name     string
+		panelID  string
+		query    string
+		expected panelImageOptions
+		hasError bool
+	}{
+		{"default", "2", "", panelImageOptions{PanelID: 2, Format: panelFormatPDF}, false},
+		{"png", "2", "format=png&width=800&height=400&scale=2", panelImageOptions{PanelID: 2, Width: 800, Height: 400, Scale: 2, Format: panelFormatPNG}, false},
+		{"invalid format", "2", "format=svg", panelImageOptions{}, true},
+		{"invalid panel", "cpu", "", panelImageOptions{}, true},
+		{"empty panel", "", "", panelImageOptions{}, true},
+		{"negative width", "2", "width=-1", panelImageOptions{}, true},
+		{"too large height", "2", "height=100000", panelImageOptions{}, true},
+		{"too large scale", "2", "scale=5", panelImageOptions{}, true},
+		{"largest image", "2", "width=8192&height=6000", panelImageOptions{PanelID: 2, Width: 8192, Height: 6000, Format: panelFormatPDF}, false},
+		{"too many pixels", "2", "width=8192&height=8192&scale=4", panelImageOptions{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/report/uid/panel/"+tt.panelID+"?"+tt.query, nil)
+			result, err := getPanelImageOptionsFromRequest(req, tt.panelID)
+			if (err != nil) != tt.hasError {
+				t.Fatalf("getPanelImageOptionsFromRequest() error = %v; want error %t", err, tt.hasError)
+			}
+			if err == nil && *result != tt.expected {
+				t.Errorf("getPanelImageOptionsFromRequest() = %+v; want %+v", *result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGetPanelRequestID(t *testing.T) {
+	params := &reportParameters{DashboardUID: "uid", Timerange: &timerange.TimerangeData{From: "now-6h", To: "now"}, RowsMode: dashboard.RowsAll}
+	result := getPanelRequestID(params, &panelImageOptions{PanelID: 2, Width: 800, Scale: 2, Format: panelFormatPNG})
+	if result != "uid_report_now-6h-now_all_panel2_800x0_scale2_png" {
+		t.Errorf("getPanelRequestID() = %q", result)
+	}
+	result = getPanelRequestID(params, &panelImageOptions{PanelID: 2, Format: panelFormatPDF})
+	if result != "uid_report_now-6h-now_all_panel2_pdf" {
+		t.Errorf("getPanelRequestID() = %q", result)
+	}
+}
+
+func TestHandleGeneratePanelReportPNG(t *testing.T) {
+	image := newTestPanelImage(1920, 600, false)
+	var renderQuery url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/dashboards/uid/uid":
+			_, _ = w.Write([]byte(`{"dashboard": {"uid": "uid", "title": "Nodes", "panels": [
+				{"id": 1, "type": "row", "title": "Hidden", "collapsed": true, "gridPos": {"h": 1, "w": 24, "x": 0, "y": 0},
+					"panels": [{"id": 2, "type": "graph", "title": "CPU", "gridPos": {"h": 8, "w": 12, "x": 0, "y": 1}}]}
+			]}, "meta": {"slug": "nodes"}}`))
+		case "/render/d-solo/uid/nodes":
+			renderQuery = r.URL.Query()
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write(image)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	g := &GrafanaInstance{Endpoint: server.URL, DefaultFrom: "now-1h", DefaultTo: "now", RowsMode: dashboard.RowsExpanded}
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/report/uid/panel/2?format=png&height=300&scale=2&from=now-6h&var-node=a", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	w := httptest.NewRecorder()
+	g.HandleGeneratePanelReport(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w.Header().Get("Content-Type") != "image/png" || !bytes.Equal(w.Body.Bytes(), image) {
+		t.Errorf("Expected PNG image of the panel, got %d bytes", w.Body.Len())
+	}
+	for name, value := range map[string]string{"panelId": "2", "width": "960", "height": "300", "scale": "2", "from": "now-6h", "var-node": "a"} {
+		if renderQuery.Get(name) != value {
+			t.Errorf("Render parameter %s = %q; want %q", name, renderQuery.Get(name), value)
+		}
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/report/uid/panel/5?format=png", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	w = httptest.NewRecorder()
+	g.HandleGeneratePanelReport(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for unknown panel, got %d", w.Code)
+	}
+
+	// the width of the panel on the dashboard is 960 pixels
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/report/uid/panel/2?format=png&height=8192&scale=4", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	w = httptest.NewRecorder()
+	g.HandleGeneratePanelReport(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for too large image, got %d", w.Code)
+	}
+}
+
+func TestPanelTemplateExecute(t *testing.T) {
+	body, err := os.ReadFile(path.Join("..", "templates", defaultPanelTemplate))
+	if err != nil {
+		t.Fatalf("Could not read template: %v", err)
+	}
+	templateObj, err := newReportTemplate(string(body), url.Values{})
+	if err != nil {
+		t.Fatalf("Could not parse template: %v", err)
+	}
+	var buf bytes.Buffer
+	data := panelPdfData{
+		Panel:          dashboard.Panel{ID: 2},
+		Title:          "CPU_usage",
+		DashboardTitle: "Nodes",
+		RequestID:      "uid_report_panel2_pdf",
+		From:           "now-6h",
+		To:             "now",
+		Vars:           "var-node=a%2Cb",
+	}
+	if err = templateObj.Execute(&buf, data); err != nil {
+		t.Fatalf("Could not execute template: %v", err)
+	}
+	for _, expected := range []string{`CPU\_usage`, "{tmp/uid_report_panel2_pdf/2.png}", `Variables: var-node=a\%2Cb`} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("Report does not include %q:\n%s", expected, buf.String())
+		}
+	}
+}
@@ -0,0 +1,247 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package report
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"log/slog"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/Netcracker/grafana-reporter/dashboard"
+	"github.com/Netcracker/grafana-reporter/utils"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	// panelErrorFail fails the report if any panel is not rendered
+	panelErrorFail = "fail"
+	// panelErrorPlaceholder replaces the panel that is not rendered with the image of the title and the error
+	panelErrorPlaceholder = "placeholder"
+	// panelErrorSkip removes the panel that is not rendered from the report
+	panelErrorSkip = "skip"
+
+	// reportStatusHeader is the header of the response with the status of the report: complete or partial
+	reportStatusHeader = "Report-Status"
+	// failedPanelsHeader is the header of the response with the number of panels that are not rendered
+	failedPanelsHeader   = "Report-Failed-Panels"
+	reportStatusComplete = "complete"
+	reportStatusPartial  = "partial"
+
+	// placeholderMargin is the margin of the text of the placeholder image in pixels
+	placeholderMargin = 10
+	// minPlaceholderSize is the minimal width and height of the placeholder image in pixels
+	minPlaceholderSize = 100
+)
+
+var (
+	placeholderBackground = color.RGBA{R: 0xf4, G: 0xf5, B: 0xf5, A: 0xff}
+	placeholderBorder     = color.RGBA{R: 0xc7, G: 0xd0, B: 0xd9, A: 0xff}
+	placeholderTitle      = color.RGBA{R: 0x20, G: 0x22, B: 0x26, A: 0xff}
+	placeholderError      = color.RGBA{R: 0xc4, G: 0x16, B: 0x2a, A: 0xff}
+)
+
+// panelFailure is the panel that could not be rendered and its error
+type panelFailure struct {
+	Info *PanelRequestInfo
+	Err  error
+}
+
+// parsePanelErrorPolicy checks the policy of panels that are not rendered, fail is used if it is not set
+func parsePanelErrorPolicy(value string) (string, error) {
+	switch value {
+	case "":
+		return panelErrorFail, nil
+	case panelErrorFail, panelErrorPlaceholder, panelErrorSkip:
+		return value, nil
+	default:
+		return "", fmt.Errorf("parameter %q must be %s, %s or %s, got %q", "onPanelError", panelErrorFail, panelErrorPlaceholder, panelErrorSkip, value)
+	}
+}
+
+// getPanelErrorRequestIDPart returns the part of the request ID with the policy, it is empty for the default policy
+func getPanelErrorRequestIDPart(policy string) string {
+	if policy == "" || policy == panelErrorFail {
+		return ""
+	}
+	return fmt.Sprintf("_on-error-%s", policy)
+}
+
+// addFailedPanels counts panels of the report that are not rendered
+func (p *reportParameters) addFailedPanels(count int) {
+	if p.FailedPanels != nil {
+		p.FailedPanels.Add(int32(count))
+	}
+}
+
+// getFailedPanels returns the number of panels of the report that are not rendered
+func (p *reportParameters) getFailedPanels() int {
+	if p.FailedPanels == nil {
+		return 0
+	}
+	return int(p.FailedPanels.Load())
+}
+
+// newFailedPanelsCounter returns the counter of failed panels shared by sections of the report
+func newFailedPanelsCounter() *atomic.Int32 {
+	return &atomic.Int32{}
+}
+
+// setReportStatusHeaders tells the client if the report is partial because some panels are not rendered
+func setReportStatusHeaders(writer http.ResponseWriter, params *reportParameters) {
+	failed := params.getFailedPanels()
+	status := reportStatusComplete
+	if failed > 0 {
+		status = reportStatusPartial
+	}
+	writer.Header().Set(reportStatusHeader, status)
+	writer.Header().Set(failedPanelsHeader, strconv.Itoa(failed))
+}
+
+// handlePanelFailures applies the policy of the report to panels that are not rendered: placeholders are saved
+// instead of images of the panels or the panels are removed. Blank images that are allowed stay in the report.
+// Failed panels are listed in rendering issues.
+func handlePanelFailures(structuredDashboard *dashboard.StructuredDashboard, params *reportParameters, failures []*panelFailure) error {
+	if len(failures) == 0 {
+		return nil
+	}
+	params.addFailedPanels(len(failures))
+	var skipped []int
+	for _, failure := range failures {
+		reason := failure.Err.Error()
+		if failure.Info.Baseline {
+			reason = fmt.Sprintf("baseline time range: %s", reason)
+		}
+		structuredDashboard.RenderIssues = append(structuredDashboard.RenderIssues, dashboard.RenderIssue{
+			PanelID: failure.Info.PanelID,
+			Title:   failure.Info.Title,
+			Reason:  reason,
+		})
+		if isKeptImage(failure.Err) {
+			continue
+		}
+		switch params.OnPanelError {
+		case panelErrorPlaceholder:
+			if err := savePlaceholderImage(params.RequestID, failure.Info, reason); err != nil {
+				return fmt.Errorf("could not save placeholder of panel %d :%w", failure.Info.PanelID, err)
+			}
+		case panelErrorSkip:
+			skipped = append(skipped, failure.Info.PanelID)
+		}
+	}
+	if len(skipped) > 0 {
+		structuredDashboard.RemovePanels(skipped...)
+	}
+	slog.Warn(fmt.Sprintf("%d panels of the dashboard %s are not rendered, policy %q is applied", len(failures), params.DashboardUID, params.OnPanelError))
+	return nil
+}
+
+// savePlaceholderImage saves the image with the title of the panel and the error instead of the image of the panel
+func savePlaceholderImage(requestID string, info *PanelRequestInfo, reason string) error {
+	if !utils.IsSafeFileName(requestID) || !utils.IsSafeFileName(info.ImageName) {
+		return fmt.Errorf("invalid image name") // block path traversal
+	}
+	panelsDirPath := getPanelsDirPath(requestID)
+	if err := os.MkdirAll(panelsDirPath, 0777); err != nil {
+		return fmt.Errorf("could not create directory for panel on path %q. Error: %w", panelsDirPath, err)
+	}
+	f, err := os.Create(path.Join(panelsDirPath, info.ImageName))
+	if err != nil {
+		return fmt.Errorf("could not create file %q. Error: %w", info.ImageName, err)
+	}
+	defer func() {
+		if cerr := f.Close(); cerr != nil {
+			slog.Error(fmt.Sprintf("Error closing panel file: %v", cerr))
+		}
+	}()
+	return png.Encode(f, drawPlaceholder(info.Width, info.Height, info.Title, reason))
+}
+
+// drawPlaceholder draws the image of the panel that is not rendered. Lines that do not fit the image are cut.
+func drawPlaceholder(width, height int, title, reason string) image.Image {
+	width, height = max(width, minPlaceholderSize), max(height, minPlaceholderSize)
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(placeholderBorder), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(1, 1, width-1, height-1), image.NewUniform(placeholderBackground), image.Point{}, draw.Src)
+
+	face := basicfont.Face7x13
+	columns := (width - 2*placeholderMargin) / face.Advance
+	lines := wrapText(title, columns)
+	titleLines := len(lines)
+	lines = append(lines, "Panel is not rendered:")
+	lines = append(lines, wrapText(reason, columns)...)
+	drawer := &font.Drawer{Dst: img, Face: face}
+	for i, line := range lines {
+		y := placeholderMargin + (i+1)*face.Height
+		if y > height-placeholderMargin {
+			break
+		}
+		drawer.Src = image.NewUniform(placeholderError)
+		if i < titleLines {
+			drawer.Src = image.NewUniform(placeholderTitle)
+		}
+		drawer.Dot = fixed.P(placeholderMargin, y)
+		drawer.DrawString(line)
+	}
+	return img
+}
+
+// wrapText splits the text to lines of not more than columns characters, long words are cut
+func wrapText(text string, columns int) []string {
+	if columns <= 0 {
+		return nil
+	}
+	var lines []string
+	var line []rune
+	for _, word := range strings.Fields(text) {
+		runes := []rune(word)
+		for len(runes) > 0 {
+			space := 0
+			if len(line) > 0 {
+				space = 1
+			}
+			if len(line)+space+len(runes) <= columns {
+				if space > 0 {
+					line = append(line, ' ')
+				}
+				line = append(line, runes...)
+				break
+			}
+			if len(line) > 0 {
+				lines = append(lines, string(line))
+				line = nil
+				continue
+			}
+			lines = append(lines, string(runes[:columns]))
+			runes = runes[columns:]
+		}
+	}
+	if len(line) > 0 {
+		lines = append(lines, string(line))
+	}
+	return lines
+}
@@ -0,0 +1,177 @@
+package report
+
+import (
+	"context"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Netcracker/grafana-reporter/dashboard"
+	"github.com/Netcracker/grafana-reporter/timerange"
+)
+
+func TestParsePanelErrorPolicy(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{value: "", want: panelErrorFail},
+		{value: "fail", want: panelErrorFail},
+		{value: "placeholder", want: panelErrorPlaceholder},
+		{value: "skip", want: panelErrorSkip},
+		{value: "ignore", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parsePanelErrorPolicy(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parsePanelErrorPolicy(%q) = %q, %v; want %q, error %t", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	got := wrapText("status code 500 verylongword", 8)
+	want := []string{"status", "code 500", "verylong", "word"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("wrapText() = %q; want %q", got, want)
+	}
+}
+
+// newPanelErrorServer returns Grafana that fails to render panel 1 and renders other panels
+func newPanelErrorServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("panelId") == "1" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		writeTestPanelImage(w, r, false)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newPanelErrorDashboard() *dashboard.StructuredDashboard {
+	return &dashboard.StructuredDashboard{
+		UID:  "uid",
+		Slug: "slug",
+		Rows: []*dashboard.Row{{Panels: []dashboard.Panel{
+			{ID: 1, Type: "graph", Title: "CPU of $host", GridPos: dashboard.GridPos{H: 8, W: 12}},
+			{ID: 2, Type: "graph", Title: "Memory", GridPos: dashboard.GridPos{H: 8, W: 12, X: 12}},
+		}}},
+	}
+}
+
+func TestGetPanelsPlaceholder(t *testing.T) {
+	setRetryPolicy(t, renderRetryPolicy{Attempts: 1, RenderTimeout: time.Minute})
+	server := newPanelErrorServer(t)
+	requestID := "placeholder_test"
+	defer removePanelImages(requestID)
+	sd := newPanelErrorDashboard()
+	params := &reportParameters{RequestID: requestID, Vars: url.Values{"var-host": {"node1"}}, OnPanelError: panelErrorPlaceholder, FailedPanels: newFailedPanelsCounter()}
+	g := &GrafanaInstance{Endpoint: server.URL}
+	failures, err := g.getPanels(context.Background(), sd, params, &timerange.TimerangeData{From: "now-1h", To: "now"}, "")
+	if err != nil {
+		t.Fatalf("getPanels() error = %v; want failed panels", err)
+	}
+	if len(failures) != 1 || failures[0].Info.PanelID != 1 {
+		t.Fatalf("getPanels() failures = %v; want panel 1", failures)
+	}
+	if err = handlePanelFailures(sd, params, failures); err != nil {
+		t.Fatalf("handlePanelFailures() error = %v", err)
+	}
+	if len(sd.RenderIssues) != 1 || sd.RenderIssues[0].Title != "CPU of node1" || !strings.Contains(sd.RenderIssues[0].Reason, "404") {
+		t.Errorf("RenderIssues = %+v; want issue of panel 1 with status 404", sd.RenderIssues)
+	}
+	if _, ok := sd.GetPanel(1); !ok {
+		t.Errorf("Panel 1 is removed; want the placeholder")
+	}
+	f, err := os.Open(path.Join(getPanelsDirPath(requestID), "1.png"))
+	if err != nil {
+		t.Fatalf("Placeholder is not saved: %v", err)
+	}
+	defer f.Close()
+	config, err := png.DecodeConfig(f)
+	if err != nil {
+		t.Fatalf("Placeholder is not PNG: %v", err)
+	}
+	if config.Width != 960 || config.Height != 640 {
+		t.Errorf("Placeholder size = %dx%d; want 960x640", config.Width, config.Height)
+	}
+	if params.getFailedPanels() != 1 {
+		t.Errorf("getFailedPanels() = %d; want 1", params.getFailedPanels())
+	}
+}
+
+func TestHandlePanelFailuresSkip(t *testing.T) {
+	sd := newPanelErrorDashboard()
+	params := &reportParameters{RequestID: "skip_test", OnPanelError: panelErrorSkip, FailedPanels: newFailedPanelsCounter()}
+	failures := []*panelFailure{{Info: &PanelRequestInfo{PanelID: 1, ImageName: "1_baseline.png", Baseline: true}, Err: &renderError{StatusCode: http.StatusNotFound}}}
+	if err := handlePanelFailures(sd, params, failures); err != nil {
+		t.Fatalf("handlePanelFailures() error = %v", err)
+	}
+	if _, ok := sd.GetPanel(1); ok {
+		t.Errorf("Panel 1 is not removed")
+	}
+	if len(sd.RenderIssues) != 1 || !strings.HasPrefix(sd.RenderIssues[0].Reason, "baseline time range: ") {
+		t.Errorf("RenderIssues = %+v; want issue of the baseline panel", sd.RenderIssues)
+	}
+	if _, err := os.Stat(getPanelsDirPath("skip_test")); err == nil {
+		t.Errorf("Placeholder is saved for the skipped panel")
+	}
+}
+
+func TestGetPanelsPlaceholderCancelled(t *testing.T) {
+	setRetryPolicy(t, renderRetryPolicy{Attempts: 1, RenderTimeout: time.Minute})
+	server := newPanelErrorServer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	g := &GrafanaInstance{Endpoint: server.URL}
+	params := &reportParameters{RequestID: "cancelled_test", OnPanelError: panelErrorPlaceholder, FailedPanels: newFailedPanelsCounter()}
+	_, err := g.getPanels(ctx, newPanelErrorDashboard(), params, &timerange.TimerangeData{From: "now-1h", To: "now"}, "")
+	if err == nil {
+		t.Errorf("getPanels() error = nil; want error of the cancelled report")
+	}
+}
+
+func TestGetPanelsKeepsBlankImages(t *testing.T) {
+	setRetryPolicy(t, renderRetryPolicy{Attempts: 1, RenderTimeout: time.Minute, MinContentPercent: defaultMinContentPercent, KeepBlankImages: true})
+	requests := 0
+	server := newBlankImageServer(t, &requests)
+	requestID := "kept_blank_panels_test"
+	defer removePanelImages(requestID)
+	sd := newPanelErrorDashboard()
+	params := &reportParameters{RequestID: requestID, OnPanelError: panelErrorFail, FailedPanels: newFailedPanelsCounter()}
+	g := &GrafanaInstance{Endpoint: server.URL}
+	failures, err := g.getPanels(context.Background(), sd, params, &timerange.TimerangeData{From: "now-1h", To: "now"}, "")
+	if err != nil || len(failures) != 2 {
+		t.Fatalf("getPanels() = %v, %v; want 2 blank panels without error", failures, err)
+	}
+	if err = handlePanelFailures(sd, params, failures); err != nil {
+		t.Fatalf("handlePanelFailures() error = %v", err)
+	}
+	if _, ok := sd.GetPanel(1); !ok || len(sd.RenderIssues) != 2 || params.getFailedPanels() != 2 {
+		t.Errorf("RenderIssues = %+v, failed panels = %d; want blank panels kept and reported", sd.RenderIssues, params.getFailedPanels())
+	}
+}
+
+func TestSetReportStatusHeaders(t *testing.T) {
+	params := &reportParameters{FailedPanels: newFailedPanelsCounter()}
+	recorder := httptest.NewRecorder()
+	setReportStatusHeaders(recorder, params)
+	if got := recorder.Header().Get(reportStatusHeader); got != reportStatusComplete {
+		t.Errorf("%s = %q; want %q", reportStatusHeader, got, reportStatusComplete)
+	}
+	params.addFailedPanels(2)
+	recorder = httptest.NewRecorder()
+	setReportStatusHeaders(recorder, params)
+	if got := recorder.Header().Get(reportStatusHeader); got != reportStatusPartial || recorder.Header().Get(failedPanelsHeader) != "2" {
+		t.Errorf("Headers = %v; want partial report with 2 failed panels", recorder.Header())
+	}
+}
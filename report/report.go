@@ -20,6 +20,7 @@ import (
 	"crypto/tls"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -27,9 +28,11 @@ import (
 	"net/url"
 	"os"
 	"path"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/Netcracker/grafana-reporter/dashboard"
@@ -42,6 +45,8 @@ import (
 
 var (
 	reportsDir = path.Join(os.TempDir(), "reports/")
+	// dashboardResourceVersions are versions of the dashboard resource API of schema v2 in the order of preference
+	dashboardResourceVersions = []string{"v2beta1", "v2alpha1"}
 )
 
 type GrafanaInstance struct {
@@ -52,7 +57,11 @@ type GrafanaInstance struct {
 	DefaultTo       string
 	DefaultTemplate string
 	Templates       map[string][]byte
-	RenderCollapsed bool
+	RowsMode        dashboard.RowsMode
+	// OrgID is the default Grafana organization of requests, the organization of the user is used if it is 0
+	OrgID int
+	// Calendar contains custom named periods and holidays accepted as the time range
+	Calendar *timerange.Calendar
 }
 
 type Credentials struct {
@@ -61,70 +70,151 @@ type Credentials struct {
 	Token    string `yaml:"apiKey"`
 }
 
-func RunGenerateReport(addr, credentialsFile, dashboardUID, variables string, templates map[string][]byte, defaultTemplate, defaultFrom, defaultTo string, renderCollapsed bool, tlsConfig *tls.Config, user, password, token string) error {
+// reportParameters are the parameters of one report received from the request or the command line
+type reportParameters struct {
+	DashboardUID string
+	Timerange    *timerange.TimerangeData
+	// FromDefault and ToDefault are true if the time range is not requested, the time range of the dashboard is used
+	// then and the default time range of the application is used only if the dashboard has no time range
+	FromDefault bool
+	ToDefault   bool
+	// Now is the time of the request, relative time range is calculated from it
+	Now       time.Time
+	Template  string
+	Vars      url.Values
+	RowsMode  dashboard.RowsMode
+	Selection *dashboard.Selection
+	// Alerts is true if the summary of alert rules is included to the report
+	Alerts bool
+	// Version or AsOf time of the historical dashboard version, the latest dashboard is used if both are not set
+	Version int
+	AsOf    time.Time
+	// OrgID is the Grafana organization of the dashboard, the organization of the user is used if it is 0
+	OrgID int
+	// Timezone of the time range and panels, the timezone of the dashboard is used if it is empty
+	Timezone string
+	// ViewPanel is the panel of the report in view mode, all panels are included if it is 0
+	ViewPanel int
+	// Calendar contains custom named periods and holidays accepted as the time range
+	Calendar *timerange.Calendar
+	// Compare is the baseline time range of the comparison report, it is nil if the comparison is not requested
+	Compare *comparison
+	// Deadline of the report, rendering of panels is not retried after it. It is zero if the time is not limited
+	Deadline time.Time
+	// Render contains the requested options of Grafana image renderer, options of the template are used if they are not set
+	Render renderOptions
+	// OnPanelError is the policy of panels that are not rendered: fail, placeholder or skip
+	OnPanelError string
+	// FailedPanels counts panels that are not rendered, it is shared by sections of the report
+	FailedPanels *atomic.Int32
+	AuthHeader   string
+	RequestID    string
+}
+
+// CommandLineOptions are the options of the report generated in command line mode
+type CommandLineOptions struct {
+	// Endpoint, credentials and defaults of Grafana are the same as in HTTP service mode
+	Endpoint        string
+	Credentials     string
+	DefaultTemplate string
+	Templates       map[string][]byte
+	DefaultFrom     string
+	DefaultTo       string
+	RowsMode        dashboard.RowsMode
+	OrgID           int
+	Calendar        *timerange.Calendar
+	TLSConfig       *tls.Config
+	// DashboardUID is the dashboard of the report
+	DashboardUID string
+	// DashboardURL is the URL of the dashboard copied from the browser, it is used instead of DashboardUID if it is set
+	DashboardURL string
+	// Variables are var-* parameters separated by &
+	Variables string
+	// DefinitionFile is the path to the report definition, the composite report is generated if it is set
+	DefinitionFile string
+	// Query contains other parameters of the report named as parameters of the request, for example selection of panels
+	Query    url.Values
+	User     string
+	Password string
+	Token    string
+}
+
+func RunGenerateReport(ctx context.Context, options *CommandLineOptions) error {
 	slog.Info("Generation started...")
 
-	if len(dashboardUID) == 0 {
-		return fmt.Errorf("dashboard UID can not be empty")
+	definitionBody, definition, err := readReportDefinition(options.DefinitionFile)
+	if err != nil {
+		slog.Error(fmt.Sprintf("Error occurred when reading report definition. Error: %v", err))
+		return err
+	}
+	dashboardUID := options.DashboardUID
+	var target *dashboardURL
+	if options.DashboardURL != "" {
+		if target, err = parseDashboardURL(options.DashboardURL); err != nil {
+			slog.Error(fmt.Sprintf("Error occurred when parsing dashboard URL. Error: %v", err))
+			return err
+		}
+		dashboardUID = target.UID
+	}
+	search := dashboard.ParseSearch(options.Query)
+	// the report of several dashboards is generated if the definition is set or dashboard UID is not set
+	multi := definition != nil || (len(dashboardUID) == 0 && !search.IsEmpty())
+	if len(dashboardUID) == 0 && !multi {
+		return fmt.Errorf("dashboard UID, search of dashboards or report definition can not be empty")
 	}
 
 	transportConf := http.DefaultTransport.(*http.Transport).Clone()
-	transportConf.TLSClientConfig = tlsConfig
+	transportConf.TLSClientConfig = options.TLSConfig
 	g := &GrafanaInstance{
-		DefaultTemplate: defaultTemplate,
-		Templates:       templates,
-		DefaultFrom:     defaultFrom,
-		DefaultTo:       defaultTo,
-		Endpoint:        addr,
-		Credentials:     credentialsFile,
-		RenderCollapsed: renderCollapsed,
+		DefaultTemplate: options.DefaultTemplate,
+		Templates:       options.Templates,
+		DefaultFrom:     options.DefaultFrom,
+		DefaultTo:       options.DefaultTo,
+		Endpoint:        options.Endpoint,
+		Credentials:     options.Credentials,
+		RowsMode:        options.RowsMode,
+		OrgID:           options.OrgID,
+		Calendar:        options.Calendar,
 		Client: http.Client{
 			Transport: transportConf,
 		},
 	}
 	startTime := time.Now()
-	timerangeFrom := g.DefaultFrom
-	timerangeTo := g.DefaultTo
-	texTemplate := g.DefaultTemplate
-
-	vars, err := url.ParseQuery(variables)
+	query, err := getCommandLineQuery(options.Query, options.Variables)
 	if err != nil {
 		slog.Error(fmt.Sprintf("Error occurred when parsing variables. Error: %v", err))
 		return err
 	}
-	for k := range vars {
-		if !strings.HasPrefix(k, "var-") {
-			err = fmt.Errorf("could not read var-* parameter. Name of parameter %q is not valid", k)
-			slog.Error(fmt.Sprintf("Error occurred when parsing variables. Error: %v", err))
-			return err
-		}
+	texTemplate := g.DefaultTemplate
+	if multi {
+		texTemplate = defaultMultiTemplate
 	}
-
-	authHeader, err := g.getAuthHeaderFromParameters(user, password, token)
+	params, err := g.getReportParameters(query, dashboardUID, texTemplate, startTime)
 	if err != nil {
-		slog.Error(fmt.Sprintf("Error occurred when getting authorization header. Error: %v", err))
 		return err
 	}
-
-	timestampFrom, err := timerange.RelativeTimeToTimestamp(startTime, timerangeFrom, "from")
-	if err != nil {
-		slog.Error(fmt.Sprintf("Error occurred when converting parameter %q to timestamp. Error: %v", "from", err))
-		return err
+	if multi && (params.Version > 0 || !params.AsOf.IsZero()) {
+		return fmt.Errorf("dashboard version is not supported for the report of several dashboards")
 	}
-	timestampTo, err := timerange.RelativeTimeToTimestamp(startTime, timerangeTo, "to")
+	if multi && params.Compare != nil {
+		return errComparisonNotSupported
+	}
+	params.AuthHeader, err = g.getAuthHeaderFromParameters(options.User, options.Password, options.Token)
 	if err != nil {
-		slog.Error(fmt.Sprintf("Error occurred when converting parameter %q to timestamp. Error: %v", "to", err))
+		slog.Error(fmt.Sprintf("Error occurred when getting authorization header. Error: %v", err))
 		return err
 	}
-	timerangeData := &timerange.TimerangeData{
-		From:     timerangeFrom,
-		To:       timerangeTo,
-		DateFrom: timestampFrom,
-		DateTo:   timestampTo,
+	params.Deadline = getRetryPolicy().getCommandLineDeadline(startTime)
+	if target != nil {
+		if err = target.apply(params, startTime); err != nil {
+			slog.Error(fmt.Sprintf("Error occurred when parsing dashboard URL. Error: %v", err))
+			return err
+		}
 	}
-	requestID := generateUniqueRequestID(dashboardUID, timerangeFrom, timerangeTo, !g.RenderCollapsed)
-	slog.Info(fmt.Sprintf("Generating report %q with parameters: dashboardId=%s, from=%v, to=%v, template=%s, vars=%s", requestID, dashboardUID, timerangeFrom, timerangeTo, texTemplate, vars.Encode()))
-	report, err := g.generateReport(dashboardUID, timerangeData, texTemplate, vars, requestID, authHeader, g.RenderCollapsed)
+	ctx, cancel := params.getContext(ctx)
+	defer cancel()
+	report, err := g.runReport(ctx, params, search, definitionBody, definition, startTime)
+	requestID := params.RequestID
 	duration := time.Since(startTime).String()
 	slog.Info(fmt.Sprintf("The job took %s", duration))
 	if err != nil {
@@ -145,36 +235,70 @@ func RunGenerateReport(addr, credentialsFile, dashboardUID, variables string, te
 	if err != nil {
 		return err
 	}
+	if failed := params.getFailedPanels(); failed > 0 {
+		slog.Warn(fmt.Sprintf("Report is partial, %d panels are not rendered. File name: %s", failed, fileName))
+		return nil
+	}
 	slog.Info(fmt.Sprintf("Report generation is succeeded. File name: %s", fileName))
 	return nil
 }
 
-func (g *GrafanaInstance) generateReport(dashboardID string, timerangeData *timerange.TimerangeData, templateName string, vars url.Values, requestID string, authHeader string, renderCollapsed bool) ([]byte, error) {
-	// get dashboard
-	structuredDashboard, err := g.getDashboard(dashboardID, authHeader, renderCollapsed)
+// getCommandLineQuery returns parameters of the report with variables of the command line, only var-* parameters
+// are accepted as variables
+func getCommandLineQuery(query url.Values, variables string) (url.Values, error) {
+	vars, err := url.ParseQuery(variables)
 	if err != nil {
-		slog.Error(fmt.Sprintf("Error occurred while getting Grafana dashboard: %s", err))
 		return nil, err
 	}
-	structuredDashboard.RequestID = requestID
-	// get panels
-	ok, err := g.getPanels(structuredDashboard, timerangeData.From, timerangeData.To, vars, requestID, authHeader)
-	if err != nil {
-		slog.Error(fmt.Sprintf("Error occurred while getting panels: %s", err))
-		return nil, err
+	result := url.Values{}
+	for k, values := range query {
+		result[k] = values
 	}
-	if !ok {
+	for k, values := range vars {
+		if !strings.HasPrefix(k, "var-") {
+			return nil, fmt.Errorf("could not read var-* parameter. Name of parameter %q is not valid", k)
+		}
+		result[k] = values
+	}
+	return result, nil
+}
+
+// runReport generates the report of the definition, of the dashboards found by the search or of one dashboard
+func (g *GrafanaInstance) runReport(ctx context.Context, params *reportParameters, search *dashboard.Search, definitionBody []byte, definition *reportDefinition, now time.Time) ([]byte, error) {
+	switch {
+	case definition != nil:
+		if err := definition.apply(params, now); err != nil {
+			slog.Error(fmt.Sprintf("Error occurred when parsing report definition. Error: %v", err))
+			return nil, err
+		}
+		params.RequestID = getCompositeRequestID(params, definitionBody)
+		slog.Info(fmt.Sprintf("Generating report %q with parameters: sections=%d, from=%v, to=%v, template=%s, rows=%s, alerts=%t, vars=%s", params.RequestID, len(definition.Sections), params.Timerange.From, params.Timerange.To, params.Template, params.RowsMode, params.Alerts, params.Vars.Encode()))
+		return g.generateCompositeReport(ctx, params, definition, now)
+	case params.DashboardUID == "":
+		params.RequestID = getMultiRequestID(params, search)
+		slog.Info(fmt.Sprintf("Generating report %q with parameters: search=%q, from=%v, to=%v, template=%s, rows=%s, selection=%q, alerts=%t, vars=%s", params.RequestID, search.String(), params.Timerange.From, params.Timerange.To, params.Template, params.RowsMode, params.Selection.String(), params.Alerts, params.Vars.Encode()))
+		return g.generateMultiReport(ctx, params, search)
+	default:
+		params.RequestID = generateUniqueRequestID(params)
+		slog.Info(fmt.Sprintf("Generating report %q with parameters: dashboardId=%s, from=%v, to=%v, template=%s, rows=%s, selection=%q, alerts=%t, vars=%s", params.RequestID, params.DashboardUID, params.Timerange.From, params.Timerange.To, params.Template, params.RowsMode, params.Selection.String(), params.Alerts, params.Vars.Encode()))
+		return g.generateReport(ctx, params)
+	}
+}
+
+func (g *GrafanaInstance) generateReport(ctx context.Context, params *reportParameters) ([]byte, error) {
+	structuredDashboard, err := g.prepareDashboard(ctx, params)
+	if err != nil {
 		return nil, err
 	}
 
 	// generate report from images and template
-	err = generateFile(string(g.Templates[templateName]), structuredDashboard, timerangeData, vars)
+	err = generateFile(ctx, string(g.Templates[params.Template]), structuredDashboard, params.Timerange, params.Vars, g.getRenderOptions(params).Width, params.Compare.getComparisonData())
 	if err != nil {
 		slog.Error(fmt.Sprintf("Error occurred while generating report file: %s", err))
 		return nil, err
 	}
 	// get tex file from reportsDir
-	report, err := getReport(requestID)
+	report, err := getReport(params.RequestID)
 	if err != nil {
 		slog.Error(fmt.Sprintf("Error occurred while generating PDF report. Error: %v", err))
 		return nil, err
@@ -183,6 +307,124 @@ func (g *GrafanaInstance) generateReport(dashboardID string, timerangeData *time
 
 }
 
+// prepareDashboard gets the dashboard with annotations and alerts and saves images of its panels
+func (g *GrafanaInstance) prepareDashboard(ctx context.Context, params *reportParameters) (*dashboard.StructuredDashboard, error) {
+	// get dashboard
+	structuredDashboard, err := g.getDashboard(ctx, params)
+	if err != nil {
+		slog.Error(fmt.Sprintf("Error occurred while getting Grafana dashboard: %s", err))
+		return nil, err
+	}
+	structuredDashboard.RequestID = params.RequestID
+	setDashboardTime(structuredDashboard, params)
+	if params.ViewPanel > 0 {
+		if err = structuredDashboard.ViewPanel(params.ViewPanel); err != nil {
+			return nil, err
+		}
+	}
+	g.addAnnotations(ctx, structuredDashboard, params)
+	if params.Alerts {
+		g.addAlerts(ctx, structuredDashboard, params)
+	}
+	// get panels
+	failures, err := g.getPanels(ctx, structuredDashboard, params, params.Timerange, "")
+	if err != nil {
+		slog.Error(fmt.Sprintf("Error occurred while getting panels: %s", err))
+		return nil, err
+	}
+	if params.Compare != nil {
+		// panels of the baseline time range are saved next to the panels of the time range
+		baselineFailures, err := g.getPanels(ctx, structuredDashboard, params, params.Compare.Timerange, compareImageSuffix)
+		if err != nil {
+			slog.Error(fmt.Sprintf("Error occurred while getting panels of the baseline time range: %s", err))
+			return nil, err
+		}
+		failures = append(failures, baselineFailures...)
+	}
+	if err = handlePanelFailures(structuredDashboard, params, failures); err != nil {
+		return nil, err
+	}
+	return structuredDashboard, nil
+}
+
+// setDashboardTime applies the time settings of the dashboard to the parameters and calculates time ranges of panels
+func setDashboardTime(structuredDashboard *dashboard.StructuredDashboard, params *reportParameters) {
+	options := params.applyDashboardTime(structuredDashboard.Time)
+	structuredDashboard.SetLocation(options.Location)
+	if err := structuredDashboard.SetPanelTimes(params.Timerange, params.Now, options); err != nil {
+		slog.Warn(fmt.Sprintf("Time ranges of some panels of the dashboard %s are ignored. Error: %v", params.DashboardUID, err))
+	}
+	if err := params.applyComparison(options); err != nil {
+		slog.Warn(fmt.Sprintf("Time settings of the dashboard %s are not applied to the baseline time range. Error: %v", params.DashboardUID, err))
+	}
+}
+
+// applyDashboardTime replaces the default time range with the time range saved in the dashboard and calculates
+// the time range with the week start and the now delay of the dashboard. Invalid settings of the dashboard are ignored.
+// It returns the time options of the dashboard.
+func (p *reportParameters) applyDashboardTime(settings dashboard.TimeSettings) timerange.Options {
+	if p.Timezone == "" {
+		location, err := timerange.ParseLocation(settings.Timezone)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Timezone of the dashboard %s is ignored. Error: %v", p.DashboardUID, err))
+		}
+		p.Timezone = getTimezoneName(location)
+	}
+	options, err := timerange.ParseOptions(settings.WeekStart, settings.NowDelay, settings.FiscalYearStartMonth)
+	if err != nil {
+		slog.Warn(fmt.Sprintf("Time settings of the dashboard %s are ignored. Error: %v", p.DashboardUID, err))
+		options = timerange.Options{}
+	}
+	options.Location = p.getLocation()
+	options.Calendar = p.Calendar
+	result := *p.Timerange
+	if p.FromDefault && settings.From != "" {
+		result.From = settings.From
+	}
+	if p.ToDefault && settings.To != "" {
+		result.To = settings.To
+	}
+	dateFrom, err := timerange.RelativeTimeToTimestampWithOptions(p.Now, result.From, "from", options)
+	if err != nil {
+		slog.Warn(fmt.Sprintf("Time range of the dashboard %s is ignored. Error: %v", p.DashboardUID, err))
+		return options
+	}
+	dateTo, err := timerange.RelativeTimeToTimestampWithOptions(p.Now, result.To, "to", options)
+	if err != nil {
+		slog.Warn(fmt.Sprintf("Time range of the dashboard %s is ignored. Error: %v", p.DashboardUID, err))
+		return options
+	}
+	result.DateFrom, result.DateTo = dateFrom, dateTo
+	p.Timerange = &result
+	return options
+}
+
+// getLocation returns the timezone of the report, UTC is used if the timezone is not set
+func (p *reportParameters) getLocation() *time.Location {
+	location, err := timerange.ParseLocation(p.Timezone)
+	if err != nil || location == nil {
+		return time.UTC
+	}
+	return location
+}
+
+// periodTimerangeTo returns the end of the time range. If the start of the time range is a named period and the end
+// is not set, the end of the period is the end of the time range.
+func periodTimerangeTo(from, to string, calendar *timerange.Calendar) string {
+	if to == "" && calendar.IsPeriod(from) {
+		return from
+	}
+	return to
+}
+
+// getTimezoneName returns the name of the timezone passed to Grafana, it is empty if the timezone is not set
+func getTimezoneName(location *time.Location) string {
+	if location == nil {
+		return ""
+	}
+	return location.String()
+}
+
 // HandleGetTemplatesList godoc
 //
 //	@Summary		Get names of available tex templates
@@ -264,6 +506,10 @@ func (g *GrafanaInstance) HandleGetDefaultParameters(writer http.ResponseWriter)
 		"template": g.DefaultTemplate,
 		"from":     g.DefaultFrom,
 		"to":       g.DefaultTo,
+		"rows":     string(g.RowsMode),
+	}
+	if g.OrgID > 0 {
+		defaults["orgId"] = strconv.Itoa(g.OrgID)
 	}
 	err := json.NewEncoder(writer).Encode(defaults)
 	if err != nil {
@@ -282,8 +528,33 @@ func (g *GrafanaInstance) HandleGetDefaultParameters(writer http.ResponseWriter)
 //	@Param			template		query	string	false	"PDF tex template name"
 //	@Param			from			query	string	false	"The start of time range"
 //	@Param			to				query	string	false	"The end of time range"
+//	@Param			rows			query	string	false	"Rows to include: expanded, collapsed or all"	Enums(expanded, collapsed, all)
+//	@Param			renderCollapsed	query	bool	false	"Deprecated: use rows. If true, only collapsed rows are rendered"
+//	@Param			includePanels		query	string	false	"IDs of panels to include separated by comma"
+//	@Param			excludePanels		query	string	false	"IDs of panels to exclude separated by comma"
+//	@Param			includePanelTitle	query	string	false	"Regular expression of titles of panels to include"
+//	@Param			excludePanelTitle	query	string	false	"Regular expression of titles of panels to exclude"
+//	@Param			includeRows			query	string	false	"Regular expression of titles of rows to include"
+//	@Param			excludeRows			query	string	false	"Regular expression of titles of rows to exclude"
+//	@Param			includePanelTypes	query	string	false	"Types of panels to include separated by comma"
+//	@Param			excludePanelTypes	query	string	false	"Types of panels to exclude separated by comma, for example text,news,dashlist"
+//	@Param			alerts				query	bool	false	"If true, the summary of alert rules linked to the dashboard panels is included to the report"
+//	@Param			orgId				query	int		false	"Grafana organization of the dashboard"
+//	@Param			timezone			query	string	false	"Timezone of the time range: IANA name, utc or offset like +03:00, by default the timezone of the dashboard"
+//	@Param			compare				query	string	false	"Interval like 1w to compare the time range with the time range shifted back by the interval"
+//	@Param			compareFrom			query	string	false	"The start of the baseline time range to compare the time range with"
+//	@Param			compareTo			query	string	false	"The end of the baseline time range to compare the time range with"
+//	@Param			compareLayout		query	string	false	"Layout of panels of the comparison report"	Enums(side, stacked)
+//	@Param			width				query	int		false	"Width of the screen in pixels the panels are rendered on, by default the width of the template or 1920"
+//	@Param			scale				query	int		false	"Device scale factor of panel images from 1 to 4 for high resolution prints"
+//	@Param			theme				query	string	false	"Theme of panels, by default the theme of the template or light"	Enums(light, dark)
+//	@Param			onPanelError		query	string	false	"Policy of panels that are not rendered: fail the report, replace them with placeholders or skip them"	Enums(fail, placeholder, skip)
+//	@Param			version				query	int		false	"Version of the dashboard to render the report from"
+//	@Param			asOf				query	string	false	"Time to render the report from the dashboard version saved at that time: RFC 3339 date, timestamp or Grafana relative time"
 //	@Produce		octet-stream
 //	@Success		200	{object}	string	"OK"
+//	@Header			200	{string}	Report-Status			"complete or partial if some panels are not rendered"
+//	@Header			200	{int}		Report-Failed-Panels	"Number of panels that are not rendered"
 //	@Failure		400	{string}	string	"Bad Request"
 //	@Failure		401	{string}	string	"Unauthorized"
 //	@Router			/api/v1/report/{dashboard_uid} [get]
@@ -303,13 +574,102 @@ func (g *GrafanaInstance) HandleGenerateReport(writer http.ResponseWriter, reque
 		return
 	}
 	dashboardID := urlPath[4]
-	timerangeFrom := getParameterFromRequest(request, "from", g.DefaultFrom)
-	timerangeTo := getParameterFromRequest(request, "to", g.DefaultTo)
-	texTemplate := getParameterFromRequest(request, "template", g.DefaultTemplate)
-	renderCollapsed := getBoolParameterFromRequest(request, "renderCollapsed", g.RenderCollapsed)
+	params, status, err := g.getReportParametersFromRequest(request, dashboardID, g.DefaultTemplate, startTime)
+	if err != nil {
+		writeErrorResponse(writer, status, err)
+		return
+	}
+	params.RequestID = generateUniqueRequestID(params)
+	requestID := params.RequestID
+	slog.Info(fmt.Sprintf("Generating report %q with parameters: dashboardId=%s, from=%v, to=%v, template=%s, rows=%s, selection=%q, alerts=%t, vars=%s", requestID, dashboardID, params.Timerange.From, params.Timerange.To, params.Template, params.RowsMode, params.Selection.String(), params.Alerts, params.Vars.Encode()))
+	ctx, cancel := params.getContext(request.Context())
+	defer cancel()
+	// generateReport as a job and return immediate requestID
+	report, err := g.generateReport(ctx, params)
+	duration := time.Since(startTime).String()
+	writer.Header().Set("Duration", duration)
+	slog.Info(fmt.Sprintf("The request %s took %s", request.RequestURI, duration))
+	// writer.Write([]byte(requestID))
+	if err != nil {
+		slog.Error(fmt.Sprintf("Error occurred when generating report. Error: %v", err))
+		writer.WriteHeader(getErrorStatus(err))
+		_, err = writer.Write([]byte(err.Error()))
+		if err != nil {
+			slog.Error("Could not write response", "error", err)
+		}
+		return
+	}
+	setReportStatusHeaders(writer, params)
+	writeReportResponse(writer, requestID, report)
+}
+
+// getReportParametersFromRequest reads parameters of the report from the request. It returns HTTP status of the response
+// in case of error.
+func (g *GrafanaInstance) getReportParametersFromRequest(request *http.Request, dashboardUID, defaultTemplate string, startTime time.Time) (*reportParameters, int, error) {
+	params, err := g.getReportParameters(request.URL.Query(), dashboardUID, defaultTemplate, startTime)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	params.AuthHeader, err = g.getAuthHeaderFromRequest(request)
+	if err != nil {
+		slog.Error(fmt.Sprintf("Error occurred when getting authorization header. Error: %v", err))
+		return nil, http.StatusUnauthorized, err
+	}
+	params.Deadline = getRetryPolicy().getDeadline(startTime)
+	return params, http.StatusOK, nil
+}
+
+// getReportParameters reads parameters of the report named as parameters of the request from the query, it is shared
+// by the request and the command line. The authorization header and the deadline of the report are not set.
+func (g *GrafanaInstance) getReportParameters(query url.Values, dashboardUID, defaultTemplate string, startTime time.Time) (*reportParameters, error) {
+	timerangeFrom := getQueryParameter(query, "from", g.DefaultFrom)
+	periodTo := periodTimerangeTo(query.Get("from"), query.Get("to"), g.Calendar)
+	timerangeTo := getQueryParameter(query, "to", g.DefaultTo)
+	if periodTo != "" {
+		timerangeTo = periodTo
+	}
+	texTemplate := getQueryParameter(query, "template", defaultTemplate)
+	comparison, err := newComparison(query.Get("compare"), query.Get("compareFrom"), query.Get("compareTo"), query.Get("compareLayout"), g.Calendar)
+	if err != nil {
+		slog.Error(fmt.Sprintf("Error occurred when parsing comparison. Error: %v", err))
+		return nil, err
+	}
+	if comparison != nil && !query.Has("template") {
+		texTemplate = defaultCompareTemplate
+	}
+	if err = checkComparisonTemplate(comparison, texTemplate, g.Templates); err != nil {
+		slog.Error(fmt.Sprintf("Error occurred when parsing comparison. Error: %v", err))
+		return nil, err
+	}
+	render, err := parseRenderOptions(query)
+	if err != nil {
+		slog.Error(fmt.Sprintf("Error occurred when parsing render options. Error: %v", err))
+		return nil, err
+	}
+	onPanelError, err := parsePanelErrorPolicy(query.Get("onPanelError"))
+	if err != nil {
+		slog.Error(fmt.Sprintf("Error occurred when parsing parameter %q. Error: %v", "onPanelError", err))
+		return nil, err
+	}
+	rowsMode, err := g.getRowsModeFromQuery(query)
+	if err != nil {
+		slog.Error(fmt.Sprintf("Error occurred when parsing parameter %q. Error: %v", "rows", err))
+		return nil, err
+	}
+	selection, err := dashboard.ParseSelection(query)
+	if err != nil {
+		slog.Error(fmt.Sprintf("Error occurred when parsing selection of panels. Error: %v", err))
+		return nil, err
+	}
+	alerts := getBoolQueryParameter(query, "alerts", false)
+	orgID, err := g.getOrgIDFromQuery(query)
+	if err != nil {
+		slog.Error(fmt.Sprintf("Error occurred when parsing parameter %q. Error: %v", "orgId", err))
+		return nil, err
+	}
 
 	vars := url.Values{}
-	for k, values := range request.URL.Query() {
+	for k, values := range query {
 		if strings.HasPrefix(k, "var-") {
 			for _, value := range values {
 				vars.Add(k, value)
@@ -317,198 +677,269 @@ func (g *GrafanaInstance) HandleGenerateReport(writer http.ResponseWriter, reque
 		}
 	}
 
-	authHeader, err := g.getAuthHeaderFromRequest(request)
+	location, err := timerange.ParseLocation(query.Get("timezone"))
 	if err != nil {
-		slog.Error(fmt.Sprintf("Error occurred when getting authorization header. Error: %v", err))
-		writer.WriteHeader(http.StatusUnauthorized)
-		_, err = writer.Write([]byte(err.Error()))
-		if err != nil {
-			slog.Error("Could not write response", "error", err)
-		}
-		return
+		slog.Error(fmt.Sprintf("Error occurred when parsing parameter %q. Error: %v", "timezone", err))
+		return nil, err
 	}
-
-	timestampFrom, err := timerange.RelativeTimeToTimestamp(startTime, timerangeFrom, "from")
+	versionNumber, asOfTime, err := parseDashboardVersion(query.Get("version"), query.Get("asOf"), startTime, timerange.Options{Location: location, Calendar: g.Calendar})
+	if err != nil {
+		slog.Error(fmt.Sprintf("Error occurred when parsing dashboard version. Error: %v", err))
+		return nil, err
+	}
+	timestampFrom, err := timerange.RelativeTimeToTimestampWithOptions(startTime, timerangeFrom, "from", timerange.Options{Location: location, Calendar: g.Calendar})
 	if err != nil {
 		slog.Error(fmt.Sprintf("Error occurred when converting parameter %q to timestamp. Error: %v", "from", err))
-		writer.WriteHeader(http.StatusBadRequest)
-		_, err = writer.Write([]byte(err.Error()))
-		if err != nil {
-			slog.Error("Could not write response", "error", err)
-		}
-		return
+		return nil, err
 	}
-	timestampTo, err := timerange.RelativeTimeToTimestamp(startTime, timerangeTo, "to")
+	timestampTo, err := timerange.RelativeTimeToTimestampWithOptions(startTime, timerangeTo, "to", timerange.Options{Location: location, Calendar: g.Calendar})
 	if err != nil {
 		slog.Error(fmt.Sprintf("Error occurred when converting parameter %q to timestamp. Error: %v", "to", err))
-		writer.WriteHeader(http.StatusBadRequest)
-		_, err = writer.Write([]byte(err.Error()))
-		if err != nil {
-			slog.Error("Could not write response", "error", err)
-		}
-		return
+		return nil, err
 	}
-	timerangeData := &timerange.TimerangeData{
-		From:     timerangeFrom,
-		To:       timerangeTo,
-		DateFrom: timestampFrom,
-		DateTo:   timestampTo,
+	params := &reportParameters{
+		DashboardUID: dashboardUID,
+		Timerange: &timerange.TimerangeData{
+			From:     timerangeFrom,
+			To:       timerangeTo,
+			DateFrom: timestampFrom,
+			DateTo:   timestampTo,
+		},
+		FromDefault:  !query.Has("from"),
+		ToDefault:    periodTo == "",
+		Now:          startTime,
+		Template:     texTemplate,
+		Vars:         vars,
+		RowsMode:     rowsMode,
+		Selection:    selection,
+		Alerts:       alerts,
+		Version:      versionNumber,
+		AsOf:         asOfTime,
+		OrgID:        orgID,
+		Timezone:     getTimezoneName(location),
+		Calendar:     g.Calendar,
+		Compare:      comparison,
+		Render:       render,
+		OnPanelError: onPanelError,
+		FailedPanels: newFailedPanelsCounter(),
 	}
-	requestID := generateUniqueRequestID(dashboardID, timerangeFrom, timerangeTo, !renderCollapsed)
-	slog.Info(fmt.Sprintf("Generating report %q with parameters: dashboardId=%s, from=%v, to=%v, template=%s, vars=%s", requestID, dashboardID, timerangeFrom, timerangeTo, texTemplate, vars.Encode()))
-	// generateReport as a job and return immediate requestID
-	report, err := g.generateReport(dashboardID, timerangeData, texTemplate, vars, requestID, authHeader, renderCollapsed)
-	duration := time.Since(startTime).String()
-	writer.Header().Set("Duration", duration)
-	slog.Info(fmt.Sprintf("The request %s took %s", request.RequestURI, duration))
-	// writer.Write([]byte(requestID))
-	if err != nil {
-		slog.Error(fmt.Sprintf("Error occurred when generating report. Error: %v", err))
-		writer.WriteHeader(http.StatusInternalServerError)
-		_, err = writer.Write([]byte(err.Error()))
-		if err != nil {
-			slog.Error("Could not write response", "error", err)
-		}
-		return
+	if err = params.applyComparison(timerange.Options{Location: location, Calendar: g.Calendar}); err != nil {
+		slog.Error(fmt.Sprintf("Error occurred when parsing comparison. Error: %v", err))
+		return nil, err
 	}
+	return params, nil
+}
+
+func writeReportResponse(writer http.ResponseWriter, requestID string, report []byte) {
 	writer.Header().Set("Content-Type", "application/pdf")
 	writer.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s.pdf", requestID))
 	writer.WriteHeader(http.StatusOK)
-	_, err = writer.Write(report)
+	_, err := writer.Write(report)
 	if err != nil {
 		slog.Error("Could not write response", "error", err)
 	}
 }
 
-func (g *GrafanaInstance) getDashboard(dashboardUID string, authHeader string, renderCollapsed bool) (*dashboard.StructuredDashboard, error) {
-	urlString, err := url.JoinPath(g.Endpoint, "/api/dashboards/uid/", dashboardUID)
+// getErrorStatus returns HTTP status of the error of the report: the timeout if the deadline of the report is exceeded,
+// the service is unavailable if the report is cancelled, for example on shutdown, and the internal error otherwise
+func getErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, errReportDeadline):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, context.Canceled):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeErrorResponse(writer http.ResponseWriter, status int, err error) {
+	writer.WriteHeader(status)
+	_, err = writer.Write([]byte(err.Error()))
 	if err != nil {
-		return nil, fmt.Errorf("could not create URL for request Grafana dashboard :%w", err)
+		slog.Error("Could not write response", "error", err)
 	}
-	req, err := http.NewRequest(http.MethodGet, urlString, nil)
+}
+
+func (g *GrafanaInstance) getDashboard(ctx context.Context, params *reportParameters) (*dashboard.StructuredDashboard, error) {
+	if params.Version > 0 || !params.AsOf.IsZero() {
+		return g.getDashboardVersion(ctx, params)
+	}
+	urlString, err := url.JoinPath(g.Endpoint, "/api/dashboards/uid/", params.DashboardUID)
 	if err != nil {
-		return nil, fmt.Errorf("could not create request to get Grafana dashboard :%w", err)
+		return nil, fmt.Errorf("could not create URL for request Grafana dashboard :%w", err)
 	}
-	req.Header.Set("Authorization", authHeader)
-	res, err := g.Do(req)
+	body, status, err := g.requestDashboard(ctx, urlString, params)
 	if err != nil {
-		return nil, fmt.Errorf("request to Grafana failed: %w", err)
+		return nil, err
 	}
-	slog.Debug(fmt.Sprintf("Response %s %q received", http.MethodGet, urlString), "status", res.Status)
-	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to get Grafana dashboard, status code = %v", res.Status)
+	if status == http.StatusNotAcceptable {
+		// the legacy API refuses dashboards stored in schema v2, they are requested as resources
+		slog.Debug(fmt.Sprintf("Dashboard %s is not returned by the legacy API, requesting the dashboard resource", params.DashboardUID))
+		body, status, err = g.requestDashboardResource(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("failed to get Grafana dashboard, status code = %d %s", status, http.StatusText(status))
 	}
 
-	body, err := io.ReadAll(res.Body)
+	structured, err := dashboard.ParseStructuredDashboard(body, params.RowsMode, params.Selection)
 	if err != nil {
 		return nil, err
 	}
-	// slog.Debug(fmt.Sprintf("Response body for '%s' received: %s", urlString, body))
-	err = res.Body.Close()
-	if err != nil {
-		slog.Error("Could not close body response", "error", err)
+	if structured.UID == "" {
+		structured.UID = params.DashboardUID
 	}
+	return structured, nil
+}
 
-	var dashboardEntity *dashboard.Entity
-	if err = json.Unmarshal(body, &dashboardEntity); err != nil {
-		return nil, err
+// requestDashboardResource requests the dashboard from the resource API of Grafana in the versions of schema v2.
+// It returns the status of the last request if no version is found.
+func (g *GrafanaInstance) requestDashboardResource(ctx context.Context, params *reportParameters) ([]byte, int, error) {
+	namespace := "default"
+	if params.OrgID > 1 {
+		namespace = fmt.Sprintf("org-%d", params.OrgID)
+	}
+	var body []byte
+	status := http.StatusNotFound
+	for _, version := range dashboardResourceVersions {
+		urlString, err := url.JoinPath(g.Endpoint, "/apis/dashboard.grafana.app/", version, "namespaces", namespace, "dashboards", params.DashboardUID)
+		if err != nil {
+			return nil, 0, fmt.Errorf("could not create URL for request Grafana dashboard :%w", err)
+		}
+		if body, status, err = g.requestDashboard(ctx, urlString, params); err != nil || status != http.StatusNotFound {
+			return body, status, err
+		}
 	}
-	structured, err := dashboardEntity.GetStructuredDashboard(renderCollapsed)
+	return body, status, nil
+}
+
+// requestDashboard returns the body and the status of the response of Grafana to the request of the dashboard
+func (g *GrafanaInstance) requestDashboard(ctx context.Context, urlString string, params *reportParameters) ([]byte, int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, urlString, nil)
 	if err != nil {
-		return nil, err
+		return nil, 0, fmt.Errorf("could not create request to get Grafana dashboard :%w", err)
 	}
-	return structured, nil
+	setGrafanaHeaders(req, params.AuthHeader, params.OrgID)
+	res, err := g.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("request to Grafana failed: %w", err)
+	}
+	defer func() {
+		if cerr := res.Body.Close(); cerr != nil {
+			slog.Error("Could not close body response", "error", cerr)
+		}
+	}()
+	slog.Debug(fmt.Sprintf("Response %s %q received", http.MethodGet, urlString), "status", res.Status)
+	if res.StatusCode != http.StatusOK {
+		return nil, res.StatusCode, nil
+	}
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+	return body, res.StatusCode, nil
 }
 
 type PanelRequestInfo struct {
 	ImageName string
 	URL       string
+	// PanelID, Title and size of the image in pixels describe the panel in the placeholder if it is not rendered
+	PanelID int
+	Title   string
+	Width   int
+	Height  int
+	// Scale is the device scale factor of the image, the size of the image is multiplied by it
+	Scale int
+	// Baseline is true if the panel is rendered for the baseline time range of the comparison
+	Baseline bool
 }
 
-func (g *GrafanaInstance) getPanels(structuredDashboard *dashboard.StructuredDashboard, from string, to string, vars url.Values, requestID string, authHeader string) (bool, error) {
-	panelRequestInfos, err := getPanelsURLs(g.Endpoint, structuredDashboard, from, to, vars)
+// imageSize returns the size of the image requested from Grafana image renderer
+func (p *PanelRequestInfo) imageSize() imageSize {
+	return imageSize{Width: p.Width, Height: p.Height, Scale: p.Scale}
+}
+
+// getPanels saves images of panels of the dashboard for the time range, the suffix is added to names of images.
+// With the fail policy the first failed panel cancels requests of other panels and the error is returned, otherwise
+// failed panels are returned to be handled by the policy.
+func (g *GrafanaInstance) getPanels(ctx context.Context, structuredDashboard *dashboard.StructuredDashboard, params *reportParameters, timerangeData *timerange.TimerangeData, imageSuffix string) ([]*panelFailure, error) {
+	panelRequestInfos, err := getPanelsURLs(g.Endpoint, structuredDashboard, timerangeData.GrafanaFrom(), timerangeData.GrafanaTo(), imageSuffix, g.getRenderOptions(params), params.Vars, params.OrgID, params.Timezone)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
-	attempts := 3
-	var wg sync.WaitGroup
-	wg.Add(len(panelRequestInfos))
-	concurrentRequests := getMaxConcurrentRequests()
-	concurrencyLimiter := make(chan struct{}, concurrentRequests)
-	isFailed := false
-
-	for _, requestInfo := range panelRequestInfos {
-		concurrencyLimiter <- struct{}{}
-		go func(panelInfo *PanelRequestInfo) {
-			defer func() {
-				<-concurrencyLimiter
-				wg.Done()
-			}()
-			if isFailed {
-				return
-			}
-			for i := 0; i < attempts; i++ {
-				err := g.requestAndSaveGetPanel(panelInfo.URL, panelInfo.ImageName, requestID, authHeader)
-				if err != nil {
-					slog.Error(fmt.Sprintf("Error occurred when requesting for panel. The request will be sent again in 5 seconds: %s", err), "panelId", panelInfo.ImageName)
-					time.Sleep(time.Second * 5)
-					slog.Info(fmt.Sprintf("Requesting for the panel again. URL: %q. Remaining attempts: %v", panelInfo.URL, attempts-i), "panelId", panelInfo.ImageName)
-				} else {
-					break
+	// concurrency of render requests is limited by the scheduler of the process
+	failFast := params.OnPanelError == "" || params.OnPanelError == panelErrorFail
+	groupCtx := ctx
+	errGroup := &errgroup.Group{}
+	if failFast {
+		errGroup, groupCtx = errgroup.WithContext(ctx)
+	}
+	mutex := sync.Mutex{}
+	var failures []*panelFailure
+	for _, panelInfo := range panelRequestInfos {
+		errGroup.Go(func() error {
+			if err := g.renderPanel(groupCtx, panelInfo, params.RequestID, params.AuthHeader, params.OrgID); err != nil {
+				slog.Error(fmt.Sprintf("Error occurred when requesting for panel: %s", err), "panelId", panelInfo.ImageName)
+				if failFast && !isKeptImage(err) {
+					return err
 				}
+				mutex.Lock()
+				failures = append(failures, &panelFailure{Info: panelInfo, Err: err})
+				mutex.Unlock()
 			}
-			if err != nil {
-				isFailed = true
-			}
-		}(requestInfo)
+			return nil
+		})
+	}
+	err = errGroup.Wait()
+	if err == nil && ctx.Err() != nil {
+		// panels that are not rendered because the report is cancelled are not replaced
+		err = ctx.Err()
 	}
-	wg.Wait()
-	close(concurrencyLimiter)
-	if isFailed {
-		err = fmt.Errorf("could not get all panels successfully")
+	if err != nil {
+		err = fmt.Errorf("could not get all panels successfully :%w", err)
 		slog.Error(err.Error())
-		return false, err
+		return nil, err
 	}
-	slog.Debug(fmt.Sprintf("All the panels successfully saved to tmp/%s/", requestID))
-	return true, nil
+	sort.Slice(failures, func(i, j int) bool {
+		return failures[i].Info.PanelID < failures[j].Info.PanelID
+	})
+	slog.Debug(fmt.Sprintf("All the panels successfully saved to tmp/%s/", params.RequestID))
+	return failures, nil
 }
 
-func getPanelsURLs(grafanaEndpoint string, structuredDashboard *dashboard.StructuredDashboard, from string, to string, vars url.Values) ([]*PanelRequestInfo, error) {
+func getPanelsURLs(grafanaEndpoint string, structuredDashboard *dashboard.StructuredDashboard, from string, to string, imageSuffix string, render renderOptions, vars url.Values, orgID int, timezone string) ([]*PanelRequestInfo, error) {
 	errGroup, _ := errgroup.WithContext(context.Background())
 
 	mutex := sync.Mutex{}
 	var panelRequestInfos []*PanelRequestInfo
 	for _, rows := range structuredDashboard.Rows {
 		for _, panel := range rows.Panels {
+			if panel.IsText() {
+				// text panels are rendered by the template
+				continue
+			}
+			if imageSuffix == compareImageSuffix && panel.HasRelativeTime() {
+				// the baseline of the panel with relative time is the same as its time range
+				continue
+			}
 			panelc := panel
 			errGroup.Go(func() error {
-				varsLocal := url.Values{}
-				for k, values := range vars {
-					for _, value := range values {
-						varsLocal.Add(k, value)
-					}
-				}
-				varsLocal.Add("panelId", strconv.Itoa(panelc.ID))
-				varsLocal.Add("theme", theme)
-				varsLocal.Add("from", from)
-				varsLocal.Add("to", to)
-				// add width and height
-				var width, height int
-				height = panelc.GetPxHeight(screenResolutionWidth)
-				width = panelc.GetPxWidth(screenResolutionWidth)
-
-				varsLocal.Add("width", strconv.Itoa(width))
-				varsLocal.Add("height", strconv.Itoa(height))
-
-				urlString, err := url.JoinPath(grafanaEndpoint, "/render/d-solo/", structuredDashboard.UID, structuredDashboard.Slug)
-				if err != nil {
-					err = fmt.Errorf("could not create URL for request Grafana panel :%w", err)
-				}
+				urlString, err := getPanelRenderURL(grafanaEndpoint, structuredDashboard, panelc.ID, panelc.GetPxWidth(render.Width),
+					panelc.GetPxHeight(render.Width), render.Scale, render.Theme, from, to, vars, orgID, timezone)
 				mutex.Lock()
 				panelRequestInfos = append(panelRequestInfos, &PanelRequestInfo{
-					URL:       fmt.Sprintf("%s?%s", urlString, varsLocal.Encode()),
-					ImageName: fmt.Sprintf("%s.png", strconv.Itoa(panelc.ID)),
+					URL:       urlString,
+					ImageName: fmt.Sprintf("%s%s.png", strconv.Itoa(panelc.ID), imageSuffix),
+					PanelID:   panelc.ID,
+					Title:     dashboard.InterpolateVariables(panelc.Title, vars),
+					Width:     panelc.GetPxWidth(render.Width),
+					Height:    panelc.GetPxHeight(render.Width),
+					Scale:     render.Scale,
+					Baseline:  imageSuffix != "",
 				})
 				mutex.Unlock()
 				return err
@@ -521,20 +952,52 @@ func getPanelsURLs(grafanaEndpoint string, structuredDashboard *dashboard.Struct
 	return panelRequestInfos, nil
 }
 
-func (g *GrafanaInstance) requestAndSaveGetPanel(urlString string, imageName string, requestID string, header string) error {
-	req, err := http.NewRequest(http.MethodGet, urlString, nil)
+// getPanelRenderURL returns URL of Grafana image renderer for the panel of the dashboard
+func getPanelRenderURL(grafanaEndpoint string, structuredDashboard *dashboard.StructuredDashboard, panelID, width, height, scale int, theme, from, to string, vars url.Values, orgID int, timezone string) (string, error) {
+	varsLocal := url.Values{}
+	for k, values := range vars {
+		for _, value := range values {
+			varsLocal.Add(k, value)
+		}
+	}
+	varsLocal.Add("panelId", strconv.Itoa(panelID))
+	varsLocal.Add("theme", theme)
+	varsLocal.Add("timeout", strconv.Itoa(int(getRetryPolicy().RenderTimeout.Seconds())))
+	varsLocal.Add("from", from)
+	varsLocal.Add("to", to)
+	if orgID > 0 {
+		varsLocal.Add("orgId", strconv.Itoa(orgID))
+	}
+	if timezone != "" {
+		varsLocal.Add("tz", timezone)
+	}
+	varsLocal.Add("width", strconv.Itoa(width))
+	varsLocal.Add("height", strconv.Itoa(height))
+	if scale > 0 {
+		varsLocal.Add("scale", strconv.Itoa(scale))
+	}
+
+	urlString, err := url.JoinPath(grafanaEndpoint, "/render/d-solo/", structuredDashboard.UID, structuredDashboard.Slug)
 	if err != nil {
-		return fmt.Errorf("could not create request to get Grafana panel :%w", err)
+		return "", fmt.Errorf("could not create URL for request Grafana panel :%w", err)
 	}
-	slog.Info(fmt.Sprintf("Requesting panel by url: %s", req.URL))
-	req.Header.Set("Authorization", header)
-	res, err := g.Do(req)
+	return fmt.Sprintf("%s?%s", urlString, varsLocal.Encode()), nil
+}
+
+func (g *GrafanaInstance) requestAndSaveGetPanel(ctx context.Context, urlString string, imageName string, size imageSize, requestID string, header string, orgID int) error {
+	contentType, body, err := g.requestPanelImage(ctx, urlString, requestID, header, orgID)
 	if err != nil {
-		return fmt.Errorf("request to Grafana failed: %w", err)
+		return err
 	}
-	slog.Info(fmt.Sprintf("Response %s %q received", http.MethodGet, urlString), "status", res.Status)
-	if res.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to get Grafana panel: Status code is %v", res.StatusCode)
+	img, err := validatePanelImage(contentType, body, size)
+	if err != nil {
+		return err
+	}
+	// the blank image is saved if blank images are allowed, the error is returned anyway to retry and report it
+	policy := getRetryPolicy()
+	blankErr := checkBlankPanelImage(img, policy.MinContentPercent, policy.KeepBlankImages)
+	if blankErr != nil && !isKeptImage(blankErr) {
+		return blankErr
 	}
 
 	if !utils.IsSafeFileName(requestID) {
@@ -557,14 +1020,62 @@ func (g *GrafanaInstance) requestAndSaveGetPanel(urlString string, imageName str
 			slog.Error(fmt.Sprintf("Error closing panel file: %v", cerr))
 		}
 	}()
-	_, err = f.ReadFrom(res.Body)
+	_, err = f.Write(body)
 	if err != nil {
-		return fmt.Errorf("could not save image of panel from response to file %q. Error: %w", imageName, err)
+		return fmt.Errorf("could not save image of panel to file %q. Error: %w", imageName, err)
+	}
+	if blankErr != nil {
+		slog.Warn(fmt.Sprintf("Blank image of panel is saved to file %q: %s", fullFilePath, blankErr))
+		return blankErr
 	}
 	slog.Info("Panel successfully saved to file", "path", fullFilePath)
 	return nil
 }
 
+// requestPanelImage requests the image of the panel from Grafana image renderer and returns its content type and body.
+// The slot of the render queue is held only while the image is requested.
+func (g *GrafanaInstance) requestPanelImage(ctx context.Context, urlString string, requestID string, header string, orgID int) (string, []byte, error) {
+	release, err := getRenderScheduler().acquire(ctx, requestID, g.Endpoint)
+	if err != nil {
+		return "", nil, fmt.Errorf("request of panel is cancelled in the queue :%w", err)
+	}
+	defer release()
+	ctx, cancel := getRenderContext(ctx)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, urlString, nil)
+	if err != nil {
+		return "", nil, fmt.Errorf("could not create request to get Grafana panel :%w", err)
+	}
+	slog.Info(fmt.Sprintf("Requesting panel by url: %s", req.URL))
+	setGrafanaHeaders(req, header, orgID)
+	res, err := g.Do(req)
+	if err != nil {
+		return "", nil, fmt.Errorf("request to Grafana failed: %w", err)
+	}
+	defer func() {
+		if cerr := res.Body.Close(); cerr != nil {
+			slog.Error("Could not close body response", "error", cerr)
+		}
+	}()
+	slog.Info(fmt.Sprintf("Response %s %q received", http.MethodGet, urlString), "status", res.Status)
+	if res.StatusCode != http.StatusOK {
+		return "", nil, &renderError{StatusCode: res.StatusCode, RetryAfter: parseRetryAfter(res.Header.Get("Retry-After"), time.Now())}
+	}
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxPanelImageBytes+1))
+	if err != nil {
+		return "", nil, fmt.Errorf("could not read image of panel from response :%w", err)
+	}
+	return res.Header.Get("Content-Type"), body, nil
+}
+
+// setGrafanaHeaders sets authorization and organization of the request to Grafana
+func setGrafanaHeaders(req *http.Request, authHeader string, orgID int) {
+	req.Header.Set("Authorization", authHeader)
+	if orgID > 0 {
+		req.Header.Set("X-Grafana-Org-Id", strconv.Itoa(orgID))
+	}
+}
+
 func getPanelsDirPath(requestID string) string {
 	return path.Join(os.TempDir(), requestID)
 }
@@ -612,10 +1123,10 @@ func (g *GrafanaInstance) getAuthHeaderFromParameters(user, password, token stri
 	return creds.getAuthHeader()
 }
 
-func getParameterFromRequest(r *http.Request, name string, defaultValue string) string {
+func getQueryParameter(query url.Values, name string, defaultValue string) string {
 	if len(name) != 0 {
-		if r.URL.Query().Has(name) {
-			return r.URL.Query().Get(name)
+		if query.Has(name) {
+			return query.Get(name)
 		} else {
 			return defaultValue
 		}
@@ -623,10 +1134,10 @@ func getParameterFromRequest(r *http.Request, name string, defaultValue string)
 	return ""
 }
 
-func getBoolParameterFromRequest(r *http.Request, name string, defaultValue bool) bool {
+func getBoolQueryParameter(query url.Values, name string, defaultValue bool) bool {
 	if len(name) != 0 {
-		if r.URL.Query().Has(name) {
-			value, err := strconv.ParseBool(r.URL.Query().Get(name))
+		if query.Has(name) {
+			value, err := strconv.ParseBool(query.Get(name))
 			if err != nil {
 				return defaultValue
 			}
@@ -638,6 +1149,33 @@ func getBoolParameterFromRequest(r *http.Request, name string, defaultValue bool
 	return false
 }
 
+// getOrgIDFromQuery reads Grafana organization from the query, the default organization is used if it is not set
+func (g *GrafanaInstance) getOrgIDFromQuery(query url.Values) (int, error) {
+	if !query.Has("orgId") {
+		return g.OrgID, nil
+	}
+	orgID, err := strconv.Atoi(query.Get("orgId"))
+	if err != nil || orgID < 1 {
+		return 0, fmt.Errorf("parameter %q must be a positive number, got %q", "orgId", query.Get("orgId"))
+	}
+	return orgID, nil
+}
+
+// getRowsModeFromQuery reads rows mode from the query. The deprecated parameter renderCollapsed is used
+// if rows parameter is not set.
+func (g *GrafanaInstance) getRowsModeFromQuery(query url.Values) (dashboard.RowsMode, error) {
+	if query.Has("rows") {
+		return dashboard.ParseRowsMode(query.Get("rows"))
+	}
+	if query.Has("renderCollapsed") {
+		if getBoolQueryParameter(query, "renderCollapsed", false) {
+			return dashboard.RowsCollapsed, nil
+		}
+		return dashboard.RowsExpanded, nil
+	}
+	return g.RowsMode, nil
+}
+
 func getMaxConcurrentRequests() int {
 	maxRequestsEnv, found := os.LookupEnv("MAX_CONCURRENT_RENDER_REQUESTS")
 	if found {
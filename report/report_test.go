@@ -1,31 +1,177 @@
 package report
 
 import (
+	"context"
+	"fmt"
+	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
+	"slices"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/Netcracker/grafana-reporter/dashboard"
+	"github.com/Netcracker/grafana-reporter/timerange"
 )
 
 func TestGenerateUniqueRequestID(t *testing.T) {
+	selection, err := dashboard.ParseSelection(url.Values{dashboard.ParamIncludePanels: {"1,2"}})
+	if err != nil {
+		t.Fatalf("ParseSelection failed: %v", err)
+	}
 	tests := []struct {
-		uid         string
-		from        string
-		to          string
-		isCollapsed bool
-		expected    string
+		uid       string
+		from      string
+		to        string
+		rowsMode  dashboard.RowsMode
+		selection *dashboard.Selection
+		alerts    bool
+		expected  string
 	}{
-		{"dashboard1", "now-1h", "now", false, "dashboard1_report_now-1h-now_expanded"},
-		{"dashboard1", "now-1h", "now", true, "dashboard1_report_now-1h-now"},
+		{"dashboard1", "now-1h", "now", dashboard.RowsExpanded, nil, false, "dashboard1_report_now-1h-now"},
+		{"dashboard1", "now-1h", "now", dashboard.RowsCollapsed, nil, false, "dashboard1_report_now-1h-now_collapsed"},
+		{"dashboard1", "now-1h", "now", dashboard.RowsAll, nil, false, "dashboard1_report_now-1h-now_all"},
+		{"dashboard1", "now-1h", "now", dashboard.RowsExpanded, selection, false, "dashboard1_report_now-1h-now_partial-7bf673ac"},
+		{"dashboard1", "now-1h", "now", dashboard.RowsExpanded, nil, true, "dashboard1_report_now-1h-now_alerts"},
+	}
+	versionParams := &reportParameters{DashboardUID: "dashboard1", Timerange: &timerange.TimerangeData{From: "now-1h", To: "now"}, Version: 5}
+	if result := generateUniqueRequestID(versionParams); result != "dashboard1_report_now-1h-now_v5" {
+		t.Errorf("generateUniqueRequestID() for version = %q; want %q", result, "dashboard1_report_now-1h-now_v5")
+	}
+	versionParams.Version = 0
+	versionParams.AsOf = time.Unix(1705744800, 0)
+	if result := generateUniqueRequestID(versionParams); result != "dashboard1_report_now-1h-now_asof-1705744800" {
+		t.Errorf("generateUniqueRequestID() for asOf = %q; want %q", result, "dashboard1_report_now-1h-now_asof-1705744800")
+	}
+	orgParams := &reportParameters{DashboardUID: "dashboard1", Timerange: &timerange.TimerangeData{From: "now-1h", To: "now"}, OrgID: 2}
+	if result := generateUniqueRequestID(orgParams); result != "dashboard1_report_now-1h-now_org2" {
+		t.Errorf("generateUniqueRequestID() for orgId = %q; want %q", result, "dashboard1_report_now-1h-now_org2")
 	}
 
 	for _, tt := range tests {
-		result := generateUniqueRequestID(tt.uid, tt.from, tt.to, tt.isCollapsed)
+		params := &reportParameters{
+			DashboardUID: tt.uid,
+			Timerange:    &timerange.TimerangeData{From: tt.from, To: tt.to},
+			RowsMode:     tt.rowsMode,
+			Selection:    tt.selection,
+			Alerts:       tt.alerts,
+		}
+		result := generateUniqueRequestID(params)
 		if result != tt.expected {
-			t.Errorf("generateUniqueRequestID(%q, %q, %q, %v) = %q; want %q", tt.uid, tt.from, tt.to, tt.isCollapsed, result, tt.expected)
+			t.Errorf("generateUniqueRequestID(%q, %q, %q, %v, %q, %t) = %q; want %q", tt.uid, tt.from, tt.to, tt.rowsMode, tt.selection.String(), tt.alerts, result, tt.expected)
 		}
 	}
 }
 
+func TestGetRowsModeFromQuery(t *testing.T) {
+	g := &GrafanaInstance{RowsMode: dashboard.RowsAll}
+	tests := []struct {
+		name     string
+		query    string
+		expected dashboard.RowsMode
+		hasError bool
+	}{
+		{"default", "", dashboard.RowsAll, false},
+		{"rows", "rows=collapsed", dashboard.RowsCollapsed, false},
+		{"rows has priority", "rows=expanded&renderCollapsed=true", dashboard.RowsExpanded, false},
+		{"deprecated renderCollapsed", "renderCollapsed=true", dashboard.RowsCollapsed, false},
+		{"deprecated renderCollapsed false", "renderCollapsed=false", dashboard.RowsExpanded, false},
+		{"invalid", "rows=some", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/v1/report/uid?"+tt.query, nil)
+			result, err := g.getRowsModeFromQuery(req.URL.Query())
+			if tt.hasError {
+				if err == nil {
+					t.Error("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("getRowsModeFromQuery() = %q; want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGetOrgIDFromQuery(t *testing.T) {
+	g := &GrafanaInstance{OrgID: 3}
+	tests := []struct {
+		name     string
+		query    string
+		expected int
+		hasError bool
+	}{
+		{"default", "", 3, false},
+		{"orgId", "orgId=5", 5, false},
+		{"invalid", "orgId=main", 0, true},
+		{"zero", "orgId=0", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/v1/report/uid?"+tt.query, nil)
+			result, err := g.getOrgIDFromQuery(req.URL.Query())
+			if (err != nil) != tt.hasError {
+				t.Fatalf("getOrgIDFromQuery() error = %v; want error %t", err, tt.hasError)
+			}
+			if result != tt.expected {
+				t.Errorf("getOrgIDFromQuery() = %d; want %d", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGetPanelsURLsWithOrgID(t *testing.T) {
+	sd := &dashboard.StructuredDashboard{
+		UID:  "uid",
+		Slug: "slug",
+		Rows: []*dashboard.Row{{Panels: []dashboard.Panel{{ID: 1, Type: "graph", GridPos: dashboard.GridPos{H: 8, W: 12}}}}},
+	}
+	infos, err := getPanelsURLs("http://grafana:3000", sd, "now-1h", "now", "", defaultRenderOptions, url.Values{}, 2, "")
+	if err != nil {
+		t.Fatalf("getPanelsURLs failed: %v", err)
+	}
+	if len(infos) != 1 || !strings.Contains(infos[0].URL, "orgId=2") {
+		t.Errorf("getPanelsURLs() = %+v; want URL with orgId=2", infos)
+	}
+	infos, err = getPanelsURLs("http://grafana:3000", sd, "now-1h", "now", "", defaultRenderOptions, url.Values{}, 0, "")
+	if err != nil || strings.Contains(infos[0].URL, "orgId") {
+		t.Errorf("getPanelsURLs() = %+v, %v; want URL without orgId", infos, err)
+	}
+}
+
+func TestGetPanelsURLsBaseline(t *testing.T) {
+	sd := &dashboard.StructuredDashboard{
+		UID:  "uid",
+		Slug: "slug",
+		Rows: []*dashboard.Row{{Panels: []dashboard.Panel{
+			{ID: 1, Type: "graph", GridPos: dashboard.GridPos{H: 8, W: 12}},
+			{ID: 2, Type: "graph", GridPos: dashboard.GridPos{H: 8, W: 12}, TimeFrom: "7d"},
+			{ID: 3, Type: "graph", GridPos: dashboard.GridPos{H: 8, W: 12}, TimeShift: "1d"},
+		}}},
+	}
+	infos, err := getPanelsURLs("http://grafana:3000", sd, "now-1h", "now", compareImageSuffix, defaultRenderOptions, url.Values{}, 0, "")
+	if err != nil {
+		t.Fatalf("getPanelsURLs failed: %v", err)
+	}
+	var ids []int
+	for _, info := range infos {
+		ids = append(ids, info.PanelID)
+	}
+	slices.Sort(ids)
+	if !slices.Equal(ids, []int{1, 3}) {
+		t.Errorf("Baseline panels = %v; want [1 3] without the panel with relative time", ids)
+	}
+}
+
 func TestGetPanelsDirPath(t *testing.T) {
 	requestID := "test123"
 	expected := os.TempDir() + "/test123"
@@ -35,7 +181,7 @@ func TestGetPanelsDirPath(t *testing.T) {
 	}
 }
 
-func TestGetParameterFromRequest(t *testing.T) {
+func TestGetQueryParameter(t *testing.T) {
 	req := httptest.NewRequest("GET", "/test?param=value&other=other", nil)
 
 	tests := []struct {
@@ -51,15 +197,15 @@ func TestGetParameterFromRequest(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := getParameterFromRequest(req, tt.param, tt.defaultValue)
+			result := getQueryParameter(req.URL.Query(), tt.param, tt.defaultValue)
 			if result != tt.expected {
-				t.Errorf("getParameterFromRequest(%q, %q) = %q; want %q", tt.param, tt.defaultValue, result, tt.expected)
+				t.Errorf("getQueryParameter(%q, %q) = %q; want %q", tt.param, tt.defaultValue, result, tt.expected)
 			}
 		})
 	}
 }
 
-func TestGetBoolParameterFromRequest(t *testing.T) {
+func TestGetBoolQueryParameter(t *testing.T) {
 	req := httptest.NewRequest("GET", "/test?bool=true&str=value", nil)
 
 	tests := []struct {
@@ -76,9 +222,9 @@ func TestGetBoolParameterFromRequest(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := getBoolParameterFromRequest(req, tt.param, tt.defaultValue)
+			result := getBoolQueryParameter(req.URL.Query(), tt.param, tt.defaultValue)
 			if result != tt.expected {
-				t.Errorf("getBoolParameterFromRequest(%q, %v) = %v; want %v", tt.param, tt.defaultValue, result, tt.expected)
+				t.Errorf("getBoolQueryParameter(%q, %v) = %v; want %v", tt.param, tt.defaultValue, result, tt.expected)
 			}
 		})
 	}
@@ -133,3 +279,190 @@ func TestCredentialsGetAuthHeader(t *testing.T) {
 		})
 	}
 }
+
+func TestGetCommandLineQuery(t *testing.T) {
+	query, err := getCommandLineQuery(url.Values{"from": {"now-1d"}}, "var-host=node1&var-host=node2")
+	if err != nil {
+		t.Fatalf("getCommandLineQuery failed: %v", err)
+	}
+	if query.Get("from") != "now-1d" || len(query["var-host"]) != 2 {
+		t.Errorf("getCommandLineQuery() = %v; want from and two values of var-host", query)
+	}
+	if _, err = getCommandLineQuery(url.Values{}, "host=node1"); err == nil {
+		t.Errorf("getCommandLineQuery() with variable without var- prefix; want error")
+	}
+}
+
+func TestApplyDashboardTime(t *testing.T) {
+	now := time.Date(2024, 1, 25, 14, 43, 12, 0, time.UTC)
+	newParams := func(fromDefault, toDefault bool) *reportParameters {
+		return &reportParameters{
+			DashboardUID: "uid",
+			Timerange:    &timerange.TimerangeData{From: "now-30m", To: "now", DateFrom: now.Add(-30 * time.Minute), DateTo: now},
+			FromDefault:  fromDefault,
+			ToDefault:    toDefault,
+			Now:          now,
+		}
+	}
+	settings := dashboard.TimeSettings{From: "now/w", To: "now/w", Timezone: "Europe/Berlin", WeekStart: "monday", NowDelay: "5m"}
+
+	params := newParams(true, true)
+	params.applyDashboardTime(settings)
+	if params.Timerange.From != "now/w" || params.Timerange.To != "now/w" || params.Timezone != "Europe/Berlin" {
+		t.Errorf("Time range = %s to %s, timezone %q; want the time range and timezone of the dashboard", params.Timerange.From, params.Timerange.To, params.Timezone)
+	}
+	if params.Timerange.DateFrom.Format(timerange.Format) != "2024-01-22 00:00:00 +0100 CET" {
+		t.Errorf("Start of time range = %s; want the start of the week from Monday in the timezone of the dashboard", params.Timerange.DateFrom.Format(timerange.Format))
+	}
+
+	params = newParams(false, false)
+	params.applyDashboardTime(settings)
+	if params.Timerange.From != "now-30m" || !params.Timerange.DateTo.Equal(now.Add(-5*time.Minute)) {
+		t.Errorf("Time range = %s to %s (%v); want the requested time range with now delay", params.Timerange.From, params.Timerange.To, params.Timerange.DateTo)
+	}
+
+	params = newParams(true, true)
+	params.Timezone = "UTC"
+	params.applyDashboardTime(dashboard.TimeSettings{Timezone: "browser", WeekStart: "friday"})
+	if params.Timerange.From != "now-30m" || !params.Timerange.DateFrom.Equal(now.Add(-30*time.Minute)) || params.Timezone != "UTC" {
+		t.Errorf("Time range = %s (%v), timezone %q; want the default time range and the requested timezone", params.Timerange.From, params.Timerange.DateFrom, params.Timezone)
+	}
+}
+
+func TestGetReportParametersPeriod(t *testing.T) {
+	g := &GrafanaInstance{DefaultFrom: "now-1h", DefaultTo: "now", RowsMode: dashboard.RowsExpanded}
+	startTime := time.Date(2024, 1, 25, 14, 43, 12, 0, time.UTC)
+	req := httptest.NewRequest("GET", "/api/v1/report/uid?from=previous-month", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	params, _, err := g.getReportParametersFromRequest(req, "uid", "gridTemplate", startTime)
+	if err != nil {
+		t.Fatalf("getReportParametersFromRequest failed: %v", err)
+	}
+	if params.Timerange.To != "previous-month" || params.ToDefault || params.Timerange.DateTo.Format(timerange.Format) != "2023-12-31 23:59:59 +0000 UTC" {
+		t.Errorf("End of time range = %s (%s), default %t; want the end of the previous month", params.Timerange.To, params.Timerange.DateTo.Format(timerange.Format), params.ToDefault)
+	}
+	if params.Timerange.GrafanaFrom() != "1701388800000" {
+		t.Errorf("GrafanaFrom() = %s; want timestamp of the start of the previous month", params.Timerange.GrafanaFrom())
+	}
+
+	if err = params.overrideTimerange("yesterday", "", startTime); err != nil || params.Timerange.To != "yesterday" {
+		t.Errorf("overrideTimerange() = %v, end of time range %s; want yesterday", err, params.Timerange.To)
+	}
+	if err = params.overrideTimerange("now-7d", "", startTime); err != nil || params.Timerange.To != "yesterday" {
+		t.Errorf("overrideTimerange() = %v, end of time range %s; want the end of time range unchanged", err, params.Timerange.To)
+	}
+}
+
+func TestGetReportParametersTimezone(t *testing.T) {
+	g := &GrafanaInstance{DefaultFrom: "now/d", DefaultTo: "now", RowsMode: dashboard.RowsExpanded}
+	startTime := time.Date(2024, 1, 25, 22, 43, 12, 0, time.UTC)
+	req := httptest.NewRequest("GET", "/api/v1/report/uid?timezone="+url.QueryEscape("+03:00"), nil)
+	req.Header.Set("Authorization", "Bearer token")
+	params, _, err := g.getReportParametersFromRequest(req, "uid", "gridTemplate", startTime)
+	if err != nil {
+		t.Fatalf("getReportParametersFromRequest failed: %v", err)
+	}
+	if params.Timezone != "Etc/GMT-3" || params.Timerange.DateFrom.Format(timerange.Format) != "2024-01-26 00:00:00 +0300 +03" {
+		t.Errorf("Timezone = %q, start of time range = %s; want the start of the day in UTC+3", params.Timezone, params.Timerange.DateFrom.Format(timerange.Format))
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/report/uid?timezone=Mars", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	if _, status, err := g.getReportParametersFromRequest(req, "uid", "gridTemplate", startTime); err == nil || status != 400 {
+		t.Errorf("getReportParametersFromRequest() = %d, %v; want 400 for invalid timezone", status, err)
+	}
+
+	sd := &dashboard.StructuredDashboard{UID: "uid", Slug: "slug"}
+	urlString, err := getPanelRenderURL("http://grafana:3000", sd, 1, 100, 100, 0, themeLight, "now/d", "now", url.Values{}, 0, params.Timezone)
+	if err != nil || !strings.Contains(urlString, "tz=Etc%2FGMT-3") {
+		t.Errorf("getPanelRenderURL() = %q, %v; want URL with tz", urlString, err)
+	}
+}
+
+func TestGetPanelsCancelsOnError(t *testing.T) {
+	setRetryPolicy(t, renderRetryPolicy{Attempts: 1, RenderTimeout: time.Minute})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("panelId") == "1" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		// other panels are rendered until the request is cancelled
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	sd := &dashboard.StructuredDashboard{
+		UID:  "uid",
+		Slug: "slug",
+		Rows: []*dashboard.Row{{Panels: []dashboard.Panel{
+			{ID: 1, Type: "graph", GridPos: dashboard.GridPos{H: 8, W: 12}},
+			{ID: 2, Type: "graph", GridPos: dashboard.GridPos{H: 8, W: 12, X: 12}},
+		}}},
+	}
+	g := &GrafanaInstance{Endpoint: server.URL}
+	done := make(chan error, 1)
+	go func() {
+		params := &reportParameters{RequestID: "cancel_test", OnPanelError: panelErrorFail}
+		_, err := g.getPanels(context.Background(), sd, params, &timerange.TimerangeData{From: "now-1h", To: "now"}, "")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Errorf("getPanels() error = nil; want error of panel 1")
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("Failed panel does not cancel requests of other panels")
+	}
+}
+
+func TestGetErrorStatus(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected int
+	}{
+		{fmt.Errorf("could not get all panels successfully :%w", context.DeadlineExceeded), http.StatusGatewayTimeout},
+		{fmt.Errorf("%w: %w", errReportDeadline, &renderError{StatusCode: http.StatusBadGateway}), http.StatusGatewayTimeout},
+		{fmt.Errorf("pdflatex is stopped :%w", context.Canceled), http.StatusServiceUnavailable},
+		{fmt.Errorf("report is empty"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		if result := getErrorStatus(tt.err); result != tt.expected {
+			t.Errorf("getErrorStatus(%v) = %d; want %d", tt.err, result, tt.expected)
+		}
+	}
+}
+
+func TestGetDashboardResourceFallback(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		switch r.URL.Path {
+		case "/api/dashboards/uid/v2-uid":
+			w.WriteHeader(http.StatusNotAcceptable)
+		case "/apis/dashboard.grafana.app/v2alpha1/namespaces/org-2/dashboards/v2-uid":
+			_, _ = w.Write([]byte(`{"apiVersion": "dashboard.grafana.app/v2alpha1", "kind": "Dashboard", "metadata": {"name": "v2-uid"},
+				"spec": {"title": "Schema v2", "elements": {"a": {"kind": "Panel", "spec": {"id": 1, "vizConfig": {"kind": "stat"}}}},
+				"layout": {"kind": "GridLayout", "spec": {"items": [
+					{"kind": "GridLayoutItem", "spec": {"width": 24, "height": 8, "element": {"kind": "ElementReference", "name": "a"}}}
+				]}}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	g := &GrafanaInstance{Endpoint: server.URL}
+	sd, err := g.getDashboard(context.Background(), &reportParameters{DashboardUID: "v2-uid", OrgID: 2, RowsMode: dashboard.RowsExpanded})
+	if err != nil {
+		t.Fatalf("getDashboard() error = %v; want the dashboard resource, requests %v", err, paths)
+	}
+	if sd.Title != "Schema v2" || sd.Slug != "schema-v2" || len(sd.Rows) != 1 {
+		t.Errorf("getDashboard() = %+v; want the dashboard of schema v2 with one row", sd)
+	}
+	if len(paths) != 3 {
+		t.Errorf("Requests = %v; want the legacy API, v2beta1 and v2alpha1 resources", paths)
+	}
+
+	if _, err = g.getDashboard(context.Background(), &reportParameters{DashboardUID: "missing"}); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("getDashboard() error = %v; want status 404 of the legacy API", err)
+	}
+}
@@ -0,0 +1,271 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package report
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultRetryAttempts     = 3
+	defaultRetryInitialDelay = time.Second
+	defaultRetryMaxDelay     = 30 * time.Second
+	defaultRenderTimeout     = time.Minute
+	// defaultMinContentPercent of pixels of the panel image differ from the background if the panel shows its data
+	defaultMinContentPercent = 0.2
+	// defaultReportTimeout is less than the write timeout of the server, so the client gets the error of the report
+	defaultReportTimeout = 10 * time.Minute
+	// renderTimeoutMargin is added to the render timeout of the request, so Grafana has time to respond with its error
+	renderTimeoutMargin = 10 * time.Second
+)
+
+var errReportDeadline = errors.New("deadline of the report exceeded")
+
+var (
+	retryPolicyOnce sync.Once
+	retryPolicy     renderRetryPolicy
+)
+
+// renderRetryPolicy describes how requests to Grafana image renderer are retried
+type renderRetryPolicy struct {
+	// Attempts is the number of requests of the panel including the first one
+	Attempts int
+	// InitialDelay is doubled after every attempt up to MaxDelay, the random jitter is up to a half of the delay
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+	// RenderTimeout is the timeout of rendering of one panel passed to Grafana
+	RenderTimeout time.Duration
+	// ReportTimeout limits the time of the whole report, it is not limited if it is 0
+	ReportTimeout time.Duration
+	// CommandLineReportTimeout limits the time of the report in command line mode. It has no default, because there is
+	// no write timeout of the server, so the report is not limited unless REPORT_TIMEOUT is set
+	CommandLineReportTimeout time.Duration
+	// MinContentPercent is the share of pixels of the panel image that differ from the background, the image with less
+	// content is blank or shows a message like "No data" and is invalid. It is not checked if it is 0
+	MinContentPercent float64
+	// KeepBlankImages keeps blank images in the report after retries instead of applying the policy of failed panels,
+	// they are still reported as rendering issues
+	KeepBlankImages bool
+}
+
+// renderError is the response of Grafana image renderer with status other than 200
+type renderError struct {
+	StatusCode int
+	// RetryAfter is the delay requested by Grafana with Retry-After header
+	RetryAfter time.Duration
+}
+
+func (e *renderError) Error() string {
+	return fmt.Sprintf("failed to get Grafana panel: Status code is %d", e.StatusCode)
+}
+
+// getRetryPolicy returns the retry policy of the process read from environment variables
+func getRetryPolicy() renderRetryPolicy {
+	retryPolicyOnce.Do(func() {
+		retryPolicy = renderRetryPolicy{
+			Attempts:                 getIntFromEnv("RENDER_RETRY_ATTEMPTS", defaultRetryAttempts),
+			InitialDelay:             getDurationFromEnv("RENDER_RETRY_INITIAL_DELAY", defaultRetryInitialDelay),
+			MaxDelay:                 getDurationFromEnv("RENDER_RETRY_MAX_DELAY", defaultRetryMaxDelay),
+			RenderTimeout:            getDurationFromEnv("RENDER_TIMEOUT", defaultRenderTimeout),
+			ReportTimeout:            getDurationFromEnv("REPORT_TIMEOUT", defaultReportTimeout),
+			CommandLineReportTimeout: getDurationFromEnv("REPORT_TIMEOUT", 0),
+			MinContentPercent:        getFloatFromEnv("RENDER_MIN_CONTENT_PERCENT", defaultMinContentPercent),
+			KeepBlankImages:          getBoolFromEnv("RENDER_KEEP_BLANK_IMAGES", false),
+		}
+		if retryPolicy.Attempts < 1 {
+			retryPolicy.Attempts = 1
+		}
+	})
+	return retryPolicy
+}
+
+// getDeadline returns the deadline of the report started at the time, it is zero if the time of reports is not limited
+func (p renderRetryPolicy) getDeadline(startTime time.Time) time.Time {
+	return getDeadlineAfter(startTime, p.ReportTimeout)
+}
+
+// getCommandLineDeadline returns the deadline of the report started at the time in command line mode
+func (p renderRetryPolicy) getCommandLineDeadline(startTime time.Time) time.Time {
+	return getDeadlineAfter(startTime, p.CommandLineReportTimeout)
+}
+
+// getDeadlineAfter returns the time after the timeout from the start, it is zero if the timeout is not positive
+func getDeadlineAfter(startTime time.Time, timeout time.Duration) time.Time {
+	if timeout <= 0 {
+		return time.Time{}
+	}
+	return startTime.Add(timeout)
+}
+
+// getDelay returns the delay before the attempt following the given one. The delay requested by Grafana is used
+// if it is longer than the backoff.
+func (p renderRetryPolicy) getDelay(attempt int, retryAfter time.Duration, random func() float64) time.Duration {
+	delay := p.InitialDelay
+	for i := 0; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, p.MaxDelay)
+	delay = delay/2 + time.Duration(random()*float64(delay/2))
+	return max(delay, retryAfter)
+}
+
+// isRetryableRenderError returns true if the request may succeed next time: the timeout, the connection error,
+// too many requests, the error of the server or the invalid image unless it is the same for every request
+func isRetryableRenderError(err error) bool {
+	var responseErr *renderError
+	if errors.As(err, &responseErr) {
+		return responseErr.StatusCode == http.StatusTooManyRequests || responseErr.StatusCode >= http.StatusInternalServerError
+	}
+	var imageErr *invalidImageError
+	if errors.As(err, &imageErr) {
+		return !imageErr.Permanent
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, context.DeadlineExceeded) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+}
+
+// parseRetryAfter returns the delay of Retry-After header in seconds or as HTTP date, it is 0 if the value is not valid
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now), 0)
+	}
+	return 0
+}
+
+// renderPanel requests the image of the panel and retries retryable errors with backoff until attempts are used,
+// the context is cancelled or the next attempt would start after the deadline of the context
+func (g *GrafanaInstance) renderPanel(ctx context.Context, panelInfo *PanelRequestInfo, requestID, authHeader string, orgID int) error {
+	policy := getRetryPolicy()
+	var err error
+	for attempt := 0; attempt < policy.Attempts; attempt++ {
+		if err = g.requestAndSaveGetPanel(ctx, panelInfo.URL, panelInfo.ImageName, panelInfo.imageSize(), requestID, authHeader, orgID); err == nil {
+			return nil
+		}
+		if ctx.Err() != nil || !isRetryableRenderError(err) || attempt+1 == policy.Attempts {
+			break
+		}
+		var retryAfter time.Duration
+		var responseErr *renderError
+		if errors.As(err, &responseErr) {
+			retryAfter = responseErr.RetryAfter
+		}
+		delay := policy.getDelay(attempt, retryAfter, rand.Float64)
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(delay).After(deadline) {
+			return fmt.Errorf("%w: %w", errReportDeadline, err)
+		}
+		slog.Warn(fmt.Sprintf("Error occurred when requesting for panel. The request will be sent again in %s: %s", delay, err), "panelId", panelInfo.ImageName)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("retry of panel is cancelled :%w", ctx.Err())
+		case <-timer.C:
+		}
+		slog.Info(fmt.Sprintf("Requesting for the panel again. URL: %q. Remaining attempts: %v", panelInfo.URL, policy.Attempts-attempt-1), "panelId", panelInfo.ImageName)
+	}
+	return err
+}
+
+// getRenderContext returns the context of one render request limited by the render timeout
+func getRenderContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, getRetryPolicy().RenderTimeout+renderTimeoutMargin)
+}
+
+// getContext returns the context of the report limited by its deadline
+func (p *reportParameters) getContext(parent context.Context) (context.Context, context.CancelFunc) {
+	if p.Deadline.IsZero() {
+		return context.WithCancel(parent)
+	}
+	return context.WithDeadline(parent, p.Deadline)
+}
+
+// getIntFromEnv returns the integer value of the environment variable or the default value if it is not set or not valid
+func getIntFromEnv(name string, defaultValue int) int {
+	value, found := os.LookupEnv(name)
+	if !found {
+		return defaultValue
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		slog.Warn(fmt.Sprintf("Could not parse %s", name), "error", err.Error())
+		return defaultValue
+	}
+	return number
+}
+
+// getDurationFromEnv returns the duration like 30s of the environment variable or the default value if it is not set or not valid
+func getDurationFromEnv(name string, defaultValue time.Duration) time.Duration {
+	value, found := os.LookupEnv(name)
+	if !found {
+		return defaultValue
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		slog.Warn(fmt.Sprintf("Could not parse %s", name), "error", err.Error())
+		return defaultValue
+	}
+	return duration
+}
+
+// getFloatFromEnv returns the number of the environment variable or the default value if it is not set or not valid
+func getFloatFromEnv(name string, defaultValue float64) float64 {
+	value, found := os.LookupEnv(name)
+	if !found {
+		return defaultValue
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		slog.Warn(fmt.Sprintf("Could not parse %s", name), "error", err.Error())
+		return defaultValue
+	}
+	return number
+}
+
+// getBoolFromEnv returns the boolean of the environment variable or the default value if it is not set or not valid
+func getBoolFromEnv(name string, defaultValue bool) bool {
+	value, found := os.LookupEnv(name)
+	if !found {
+		return defaultValue
+	}
+	result, err := strconv.ParseBool(value)
+	if err != nil {
+		slog.Warn(fmt.Sprintf("Could not parse %s", name), "error", err.Error())
+		return defaultValue
+	}
+	return result
+}
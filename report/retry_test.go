@@ -0,0 +1,161 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// setRetryPolicy replaces the retry policy of the process for the test
+func setRetryPolicy(t *testing.T, policy renderRetryPolicy) {
+	getRetryPolicy()
+	previous := retryPolicy
+	retryPolicy = policy
+	t.Cleanup(func() {
+		retryPolicy = previous
+	})
+}
+
+func TestRetryPolicyGetDelay(t *testing.T) {
+	policy := renderRetryPolicy{InitialDelay: time.Second, MaxDelay: 10 * time.Second}
+	tests := []struct {
+		attempt    int
+		retryAfter time.Duration
+		random     float64
+		expected   time.Duration
+	}{
+		{0, 0, 0, 500 * time.Millisecond},
+		{0, 0, 1, time.Second},
+		{2, 0, 1, 4 * time.Second},
+		{3, 0, 0.5, 6 * time.Second},
+		{10, 0, 1, 10 * time.Second},
+		{0, 20 * time.Second, 1, 20 * time.Second},
+	}
+	for _, tt := range tests {
+		result := policy.getDelay(tt.attempt, tt.retryAfter, func() float64 { return tt.random })
+		if result != tt.expected {
+			t.Errorf("getDelay(%d, %s, %v) = %s; want %s", tt.attempt, tt.retryAfter, tt.random, result, tt.expected)
+		}
+	}
+}
+
+func TestIsRetryableRenderError(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected bool
+	}{
+		{&renderError{StatusCode: http.StatusTooManyRequests}, true},
+		{&renderError{StatusCode: http.StatusBadGateway}, true},
+		{fmt.Errorf("wrapped: %w", &renderError{StatusCode: http.StatusServiceUnavailable}), true},
+		{&renderError{StatusCode: http.StatusUnauthorized}, false},
+		{&renderError{StatusCode: http.StatusNotFound}, false},
+		{fmt.Errorf("request to Grafana failed: %w", syscall.ECONNRESET), true},
+		{fmt.Errorf("request to Grafana failed: %w", context.DeadlineExceeded), true},
+		{&invalidImageError{Reason: "response is not PNG image"}, true},
+		{&invalidImageError{Reason: "image is 1x1 pixels, requested 100x50", Permanent: true}, false},
+		{os.ErrPermission, false},
+	}
+	for _, tt := range tests {
+		if result := isRetryableRenderError(tt.err); result != tt.expected {
+			t.Errorf("isRetryableRenderError(%v) = %t; want %t", tt.err, result, tt.expected)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 25, 14, 43, 12, 0, time.UTC)
+	tests := []struct {
+		value    string
+		expected time.Duration
+	}{
+		{"", 0},
+		{"5", 5 * time.Second},
+		{"-5", 0},
+		{"Thu, 25 Jan 2024 14:43:42 GMT", 30 * time.Second},
+		{"Thu, 25 Jan 2024 14:43:02 GMT", 0},
+		{"soon", 0},
+	}
+	for _, tt := range tests {
+		if result := parseRetryAfter(tt.value, now); result != tt.expected {
+			t.Errorf("parseRetryAfter(%q) = %s; want %s", tt.value, result, tt.expected)
+		}
+	}
+}
+
+func TestRenderPanelRetries(t *testing.T) {
+	setRetryPolicy(t, renderRetryPolicy{Attempts: 3, InitialDelay: time.Millisecond, MaxDelay: time.Millisecond, RenderTimeout: time.Second})
+	tests := []struct {
+		name     string
+		statuses []int
+		requests int
+		wantErr  bool
+	}{
+		{"success after server errors", []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK}, 3, false},
+		{"attempts are used", []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway}, 3, true},
+		{"unauthorized is not retried", []int{http.StatusUnauthorized, http.StatusOK}, 1, true},
+		{"not found is not retried", []int{http.StatusNotFound, http.StatusOK}, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				status := tt.statuses[requests]
+				requests++
+				if status == http.StatusOK {
+					writeTestPanelImage(w, r, false)
+					return
+				}
+				w.WriteHeader(status)
+			}))
+			defer server.Close()
+			g := &GrafanaInstance{Endpoint: server.URL}
+			requestID := "retry_test"
+			defer removePanelImages(requestID)
+			err := g.renderPanel(context.Background(), &PanelRequestInfo{URL: server.URL, ImageName: "1.png"}, requestID, "", 0)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("renderPanel(context.Background(), ) error = %v; want error %t", err, tt.wantErr)
+			}
+			if requests != tt.requests {
+				t.Errorf("renderPanel(context.Background(), ) sent %d requests; want %d", requests, tt.requests)
+			}
+		})
+	}
+}
+
+func TestRenderPanelDeadline(t *testing.T) {
+	setRetryPolicy(t, renderRetryPolicy{Attempts: 3, InitialDelay: time.Minute, MaxDelay: time.Minute, RenderTimeout: time.Second})
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	g := &GrafanaInstance{Endpoint: server.URL}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	err := g.renderPanel(ctx, &PanelRequestInfo{URL: server.URL, ImageName: "1.png"}, "deadline_test", "", 0)
+	if !errors.Is(err, errReportDeadline) || requests != 1 {
+		t.Errorf("renderPanel(context.Background(), ) = %v after %d requests; want deadline error after 1 request", err, requests)
+	}
+}
+
+func TestGetDeadline(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	policy := renderRetryPolicy{ReportTimeout: defaultReportTimeout}
+	if got := policy.getDeadline(start); !got.Equal(start.Add(defaultReportTimeout)) {
+		t.Errorf("getDeadline() = %v; want %v", got, start.Add(defaultReportTimeout))
+	}
+	if got := policy.getCommandLineDeadline(start); !got.IsZero() {
+		t.Errorf("getCommandLineDeadline() = %v; want no deadline if REPORT_TIMEOUT is not set", got)
+	}
+	policy.CommandLineReportTimeout = time.Minute
+	if got := policy.getCommandLineDeadline(start); !got.Equal(start.Add(time.Minute)) {
+		t.Errorf("getCommandLineDeadline() = %v; want %v", got, start.Add(time.Minute))
+	}
+}
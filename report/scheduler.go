@@ -0,0 +1,257 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package report
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// renderWaitBuckets are upper bounds in seconds of the histogram of the time render requests wait in the queue
+var renderWaitBuckets = []float64{0.1, 0.5, 1, 5, 10, 30, 60, 300}
+
+var (
+	schedulerOnce sync.Once
+	scheduler     *renderScheduler
+)
+
+// renderScheduler limits concurrent requests to Grafana image renderer of all reports of the process.
+// Requests waiting for a free slot are queued by reports and the reports are served in turn, so the report
+// of a large dashboard does not hold back smaller reports requested after it.
+type renderScheduler struct {
+	mutex sync.Mutex
+	// limit is the number of concurrent requests of the process, instanceLimit is the number for one Grafana
+	limit         int
+	instanceLimit int
+	running       int
+	// queues of waiting requests by reports, order is the turn of reports with waiting requests
+	queues map[string][]*renderTicket
+	order  []string
+	// runningByInstance and waitingByInstance are counted by Grafana endpoints
+	runningByInstance map[string]int
+	waitingByInstance map[string]int
+	// histogram of the wait time
+	waitBuckets []uint64
+	waitSum     float64
+	waitCount   uint64
+}
+
+// renderTicket is one request to Grafana image renderer, ready is closed when the request may be sent
+type renderTicket struct {
+	report   string
+	instance string
+	queued   time.Time
+	ready    chan struct{}
+}
+
+func newRenderScheduler(limit, instanceLimit int) *renderScheduler {
+	if limit < 1 {
+		limit = 1
+	}
+	if instanceLimit < 1 || instanceLimit > limit {
+		instanceLimit = limit
+	}
+	return &renderScheduler{
+		limit:             limit,
+		instanceLimit:     instanceLimit,
+		queues:            map[string][]*renderTicket{},
+		runningByInstance: map[string]int{},
+		waitingByInstance: map[string]int{},
+		waitBuckets:       make([]uint64, len(renderWaitBuckets)),
+	}
+}
+
+// getRenderScheduler returns the scheduler of the process created with limits of environment variables
+func getRenderScheduler() *renderScheduler {
+	schedulerOnce.Do(func() {
+		scheduler = newRenderScheduler(getMaxConcurrentRequests(), getMaxConcurrentInstanceRequests())
+		slog.Info(fmt.Sprintf("Render requests are limited to %d at the same time and %d for one Grafana", scheduler.limit, scheduler.instanceLimit))
+	})
+	return scheduler
+}
+
+// acquire waits for the turn of the report to send the request to Grafana instance and returns the function
+// to release the slot when the request is done. The request is removed from the queue if the context is done.
+func (s *renderScheduler) acquire(ctx context.Context, report, instance string) (func(), error) {
+	ticket := s.enqueue(report, instance, time.Now())
+	select {
+	case <-ticket.ready:
+		return func() {
+			s.release(ticket)
+		}, nil
+	case <-ctx.Done():
+		s.cancel(ticket)
+		return nil, ctx.Err()
+	}
+}
+
+// enqueue adds the request to the queue of the report and starts requests if there are free slots
+func (s *renderScheduler) enqueue(report, instance string, now time.Time) *renderTicket {
+	ticket := &renderTicket{report: report, instance: instance, queued: now, ready: make(chan struct{})}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if len(s.queues[report]) == 0 {
+		s.order = append(s.order, report)
+	}
+	s.queues[report] = append(s.queues[report], ticket)
+	s.waitingByInstance[instance]++
+	s.dispatch(now)
+	return ticket
+}
+
+// release frees the slot of the request and starts the next waiting requests
+func (s *renderScheduler) release(ticket *renderTicket) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.running--
+	s.runningByInstance[ticket.instance]--
+	s.dispatch(time.Now())
+}
+
+// cancel removes the waiting request from the queue or releases its slot if it has been started meanwhile
+func (s *renderScheduler) cancel(ticket *renderTicket) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	select {
+	case <-ticket.ready:
+		s.running--
+		s.runningByInstance[ticket.instance]--
+		s.dispatch(time.Now())
+		return
+	default:
+	}
+	queue := s.queues[ticket.report]
+	for i, waiting := range queue {
+		if waiting == ticket {
+			s.queues[ticket.report] = append(queue[:i], queue[i+1:]...)
+			break
+		}
+	}
+	if len(s.queues[ticket.report]) == 0 {
+		delete(s.queues, ticket.report)
+		for i, report := range s.order {
+			if report == ticket.report {
+				s.order = append(s.order[:i], s.order[i+1:]...)
+				break
+			}
+		}
+	}
+	s.waitingByInstance[ticket.instance]--
+}
+
+// dispatch starts the first request of every report in turn while there are free slots. Reports whose
+// Grafana instance has no free slots are skipped until requests of the instance are released.
+func (s *renderScheduler) dispatch(now time.Time) {
+	for i := 0; i < len(s.order) && s.running < s.limit; {
+		report := s.order[i]
+		ticket := s.queues[report][0]
+		if s.runningByInstance[ticket.instance] >= s.instanceLimit {
+			i++
+			continue
+		}
+		s.queues[report] = s.queues[report][1:]
+		s.order = append(s.order[:i], s.order[i+1:]...)
+		if len(s.queues[report]) > 0 {
+			// the report waits for its next turn after other reports
+			s.order = append(s.order, report)
+		} else {
+			delete(s.queues, report)
+		}
+		s.running++
+		s.runningByInstance[ticket.instance]++
+		s.waitingByInstance[ticket.instance]--
+		s.observeWait(now.Sub(ticket.queued))
+		close(ticket.ready)
+	}
+}
+
+// observeWait adds the wait time of the request to the histogram
+func (s *renderScheduler) observeWait(wait time.Duration) {
+	seconds := wait.Seconds()
+	for i, bound := range renderWaitBuckets {
+		if seconds <= bound {
+			s.waitBuckets[i]++
+		}
+	}
+	s.waitSum += seconds
+	s.waitCount++
+}
+
+// writeMetrics writes the queue depth, the running requests and the wait time in Prometheus text format
+func (s *renderScheduler) writeMetrics(writer io.Writer) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	instances := make([]string, 0, len(s.waitingByInstance))
+	for instance := range s.waitingByInstance {
+		instances = append(instances, instance)
+	}
+	sort.Strings(instances)
+
+	text := "# HELP grafana_reporter_render_queue_depth Number of render requests waiting for a free slot.\n" +
+		"# TYPE grafana_reporter_render_queue_depth gauge\n"
+	for _, instance := range instances {
+		text += fmt.Sprintf("grafana_reporter_render_queue_depth{instance=%q} %d\n", instance, s.waitingByInstance[instance])
+	}
+	text += "# HELP grafana_reporter_render_running Number of render requests sent to Grafana.\n" +
+		"# TYPE grafana_reporter_render_running gauge\n"
+	for _, instance := range instances {
+		text += fmt.Sprintf("grafana_reporter_render_running{instance=%q} %d\n", instance, s.runningByInstance[instance])
+	}
+	text += "# HELP grafana_reporter_render_limit Maximum number of render requests sent at the same time.\n" +
+		"# TYPE grafana_reporter_render_limit gauge\n" +
+		fmt.Sprintf("grafana_reporter_render_limit %d\n", s.limit)
+	text += "# HELP grafana_reporter_render_wait_seconds Time render requests wait in the queue.\n" +
+		"# TYPE grafana_reporter_render_wait_seconds histogram\n"
+	for i, bound := range renderWaitBuckets {
+		text += fmt.Sprintf("grafana_reporter_render_wait_seconds_bucket{le=%q} %d\n", strconv.FormatFloat(bound, 'f', -1, 64), s.waitBuckets[i])
+	}
+	text += fmt.Sprintf("grafana_reporter_render_wait_seconds_bucket{le=\"+Inf\"} %d\n", s.waitCount) +
+		fmt.Sprintf("grafana_reporter_render_wait_seconds_sum %s\n", strconv.FormatFloat(s.waitSum, 'f', -1, 64)) +
+		fmt.Sprintf("grafana_reporter_render_wait_seconds_count %d\n", s.waitCount)
+	_, err := io.WriteString(writer, text)
+	return err
+}
+
+// HandleRenderMetrics writes metrics of the render queue in Prometheus text format
+func HandleRenderMetrics(writer http.ResponseWriter, _ *http.Request) {
+	writer.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	if err := getRenderScheduler().writeMetrics(writer); err != nil {
+		slog.Error("Could not write response", "error", err)
+	}
+}
+
+// getMaxConcurrentInstanceRequests returns the limit of concurrent render requests to one Grafana,
+// it is the limit of the process if the variable is not set
+func getMaxConcurrentInstanceRequests() int {
+	maxRequestsEnv, found := os.LookupEnv("MAX_CONCURRENT_RENDER_REQUESTS_PER_INSTANCE")
+	if found {
+		maxRequests, err := strconv.Atoi(maxRequestsEnv)
+		if err != nil {
+			slog.Warn("Could not parse MAX_CONCURRENT_RENDER_REQUESTS_PER_INSTANCE", "error", err.Error())
+		} else {
+			return maxRequests
+		}
+	}
+	return 0
+}
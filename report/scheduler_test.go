@@ -0,0 +1,159 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func isReady(ticket *renderTicket) bool {
+	select {
+	case <-ticket.ready:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestRenderSchedulerLimit(t *testing.T) {
+	s := newRenderScheduler(2, 0)
+	now := time.Now()
+	tickets := []*renderTicket{
+		s.enqueue("a", "grafana", now),
+		s.enqueue("a", "grafana", now),
+		s.enqueue("a", "grafana", now),
+	}
+	if !isReady(tickets[0]) || !isReady(tickets[1]) || isReady(tickets[2]) {
+		t.Fatalf("Two requests must be started, the third must wait")
+	}
+	s.release(tickets[0])
+	if !isReady(tickets[2]) {
+		t.Errorf("Waiting request is not started after release")
+	}
+	if s.running != 2 || len(s.queues) != 0 || len(s.order) != 0 {
+		t.Errorf("running = %d, queues = %v, order = %v", s.running, s.queues, s.order)
+	}
+}
+
+func TestRenderSchedulerFairQueuing(t *testing.T) {
+	s := newRenderScheduler(1, 0)
+	now := time.Now()
+	first := s.enqueue("large", "grafana", now)
+	large := []*renderTicket{s.enqueue("large", "grafana", now), s.enqueue("large", "grafana", now)}
+	small := s.enqueue("small", "grafana", now)
+	if !isReady(first) {
+		t.Fatalf("The first request must be started")
+	}
+	// reports take turns: the request of the small report is started before the rest of the large report
+	s.release(first)
+	if !isReady(large[0]) || isReady(small) {
+		t.Fatalf("The next request of the large report must be started")
+	}
+	s.release(large[0])
+	if !isReady(small) || isReady(large[1]) {
+		t.Fatalf("The request of the small report must be started before the rest of the large report")
+	}
+	s.release(small)
+	if !isReady(large[1]) {
+		t.Errorf("The last request of the large report must be started")
+	}
+}
+
+func TestRenderSchedulerInstanceLimit(t *testing.T) {
+	s := newRenderScheduler(3, 1)
+	now := time.Now()
+	first := s.enqueue("a", "grafana-1", now)
+	second := s.enqueue("a", "grafana-1", now)
+	other := s.enqueue("b", "grafana-2", now)
+	if !isReady(first) || isReady(second) || !isReady(other) {
+		t.Fatalf("One request of each Grafana must be started")
+	}
+	s.release(first)
+	if !isReady(second) {
+		t.Errorf("Waiting request of the Grafana is not started after release")
+	}
+}
+
+func TestRenderSchedulerMetrics(t *testing.T) {
+	s := newRenderScheduler(1, 0)
+	now := time.Now()
+	first := s.enqueue("a", "http://grafana:3000", now)
+	second := s.enqueue("b", "http://grafana:3000", now.Add(-2*time.Second))
+	var buf bytes.Buffer
+	if err := s.writeMetrics(&buf); err != nil {
+		t.Fatalf("writeMetrics failed: %v", err)
+	}
+	for _, line := range []string{
+		`grafana_reporter_render_queue_depth{instance="http://grafana:3000"} 1`,
+		`grafana_reporter_render_running{instance="http://grafana:3000"} 1`,
+		`grafana_reporter_render_limit 1`,
+		`grafana_reporter_render_wait_seconds_count 1`,
+	} {
+		if !strings.Contains(buf.String(), line+"\n") {
+			t.Errorf("Metrics do not contain %q:\n%s", line, buf.String())
+		}
+	}
+	s.release(first)
+	if !isReady(second) {
+		t.Fatalf("Waiting request is not started after release")
+	}
+	buf.Reset()
+	if err := s.writeMetrics(&buf); err != nil {
+		t.Fatalf("writeMetrics failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), `grafana_reporter_render_wait_seconds_bucket{le="1"} 1`+"\n") ||
+		!strings.Contains(buf.String(), `grafana_reporter_render_wait_seconds_bucket{le="5"} 2`+"\n") {
+		t.Errorf("Wait time is not observed:\n%s", buf.String())
+	}
+}
+
+func TestRenderSchedulerAcquire(t *testing.T) {
+	s := newRenderScheduler(2, 0)
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	maxRunning := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, err := s.acquire(context.Background(), "a", "grafana")
+			if err != nil {
+				t.Errorf("acquire failed: %v", err)
+				return
+			}
+			s.mutex.Lock()
+			running := s.running
+			s.mutex.Unlock()
+			mutex.Lock()
+			maxRunning = max(maxRunning, running)
+			mutex.Unlock()
+			time.Sleep(time.Millisecond)
+			release()
+		}()
+	}
+	wg.Wait()
+	if maxRunning > 2 {
+		t.Errorf("%d requests run at the same time; want at most 2", maxRunning)
+	}
+}
+
+func TestRenderSchedulerAcquireCancelled(t *testing.T) {
+	s := newRenderScheduler(1, 0)
+	running := s.enqueue("a", "grafana", time.Now())
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := s.acquire(ctx, "b", "grafana"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("acquire() error = %v; want context.Canceled", err)
+	}
+	if len(s.queues) != 0 || len(s.order) != 0 || s.waitingByInstance["grafana"] != 0 {
+		t.Errorf("Cancelled request is not removed from the queue: queues = %v, order = %v", s.queues, s.order)
+	}
+	s.release(running)
+	if s.running != 0 {
+		t.Errorf("running = %d; want 0", s.running)
+	}
+}
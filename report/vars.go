@@ -15,7 +15,123 @@
 
 package report
 
+import (
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+)
+
 const (
-	screenResolutionWidth = 1920
-	theme                 = "light"
+	// defaultRenderWidth is the width of the screen the dashboard is rendered on if it is not requested
+	defaultRenderWidth = 1920
+	// minRenderWidth is the number of columns of the dashboard grid, a column can not be narrower than one pixel
+	minRenderWidth = 24
+	themeLight     = "light"
+	themeDark      = "dark"
+	// templateRenderOptionsPrefix starts the comment line of the template with its render options
+	templateRenderOptionsPrefix = "% render:"
 )
+
+// renderOptions are the settings of Grafana image renderer: the width of the screen the dashboard is rendered on,
+// the device scale factor of images and the theme. Zero values are not set.
+type renderOptions struct {
+	Width int
+	Scale int
+	Theme string
+}
+
+// defaultRenderOptions are used if the options are set neither by the request nor by the template
+var defaultRenderOptions = renderOptions{Width: defaultRenderWidth, Theme: themeLight}
+
+// parseRenderOptions reads the width, the scale and the theme from the values, empty values are not set
+func parseRenderOptions(values url.Values) (renderOptions, error) {
+	var options renderOptions
+	var err error
+	if options.Width, err = parsePositiveInt(values.Get("width"), "width", maxPanelImageSize); err != nil {
+		return renderOptions{}, err
+	}
+	if options.Width > 0 && options.Width < minRenderWidth {
+		return renderOptions{}, fmt.Errorf("width must not be less than %d, got %d", minRenderWidth, options.Width)
+	}
+	if options.Scale, err = parsePositiveInt(values.Get("scale"), "scale", maxPanelImageScale); err != nil {
+		return renderOptions{}, err
+	}
+	options.Theme = values.Get("theme")
+	if options.Theme != "" && options.Theme != themeLight && options.Theme != themeDark {
+		return renderOptions{}, fmt.Errorf("invalid theme %q, must be %s or %s", options.Theme, themeLight, themeDark)
+	}
+	return options, nil
+}
+
+// getTemplateComment returns the value of the comment line of the template that starts with the prefix like "% render:".
+// Only the comments at the beginning of the template are read.
+func getTemplateComment(templateBody []byte, prefix string) (string, bool) {
+	for _, line := range strings.Split(string(templateBody), "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "%") {
+			break
+		}
+		if value, found := strings.CutPrefix(line, prefix); found {
+			return strings.TrimSpace(value), true
+		}
+	}
+	return "", false
+}
+
+// getTemplateRenderOptions reads render options from the comment of the template like
+// "% render: width=2560 scale=2 theme=dark"
+func getTemplateRenderOptions(templateBody []byte) (renderOptions, error) {
+	fields, found := getTemplateComment(templateBody, templateRenderOptionsPrefix)
+	if !found {
+		return renderOptions{}, nil
+	}
+	values := url.Values{}
+	for _, field := range strings.Fields(fields) {
+		name, value, _ := strings.Cut(field, "=")
+		if name != "width" && name != "scale" && name != "theme" {
+			return renderOptions{}, fmt.Errorf("unknown render option %q", name)
+		}
+		values.Set(name, value)
+	}
+	return parseRenderOptions(values)
+}
+
+// merge returns the options with values that are not set taken from the defaults
+func (o renderOptions) merge(defaults renderOptions) renderOptions {
+	if o.Width == 0 {
+		o.Width = defaults.Width
+	}
+	if o.Scale == 0 {
+		o.Scale = defaults.Scale
+	}
+	if o.Theme == "" {
+		o.Theme = defaults.Theme
+	}
+	return o
+}
+
+// getRequestIDPart returns the part of the request ID with the requested options
+func (o renderOptions) getRequestIDPart() string {
+	var part string
+	if o.Width > 0 {
+		part += fmt.Sprintf("_w%d", o.Width)
+	}
+	if o.Scale > 0 {
+		part += fmt.Sprintf("_scale%d", o.Scale)
+	}
+	if o.Theme != "" {
+		part += fmt.Sprintf("_%s", o.Theme)
+	}
+	return part
+}
+
+// getRenderOptions returns render options of the report: the requested options, then the options of the template
+// and the defaults. Invalid options of the template are ignored.
+func (g *GrafanaInstance) getRenderOptions(params *reportParameters) renderOptions {
+	templateOptions, err := getTemplateRenderOptions(g.Templates[params.Template])
+	if err != nil {
+		slog.Warn(fmt.Sprintf("Render options of the template %s are ignored. Error: %v", params.Template, err))
+	}
+	return params.Render.merge(templateOptions).merge(defaultRenderOptions)
+}
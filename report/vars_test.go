@@ -0,0 +1,129 @@
+package report
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Netcracker/grafana-reporter/dashboard"
+)
+
+func TestParseRenderOptions(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected renderOptions
+		wantErr  bool
+	}{
+		{"", renderOptions{}, false},
+		{"width=2560&scale=2&theme=dark", renderOptions{Width: 2560, Scale: 2, Theme: themeDark}, false},
+		{"theme=light", renderOptions{Theme: themeLight}, false},
+		{"width=20", renderOptions{}, true},
+		{"width=10000", renderOptions{}, true},
+		{"scale=5", renderOptions{}, true},
+		{"scale=0", renderOptions{}, true},
+		{"theme=blue", renderOptions{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			values, _ := url.ParseQuery(tt.query)
+			result, err := parseRenderOptions(values)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseRenderOptions(%q) error = %v; want error %t", tt.query, err, tt.wantErr)
+			}
+			if result != tt.expected {
+				t.Errorf("parseRenderOptions(%q) = %+v; want %+v", tt.query, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGetTemplateRenderOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected renderOptions
+		wantErr  bool
+	}{
+		{"no options", "\\documentclass{article}\n% render: width=2560", renderOptions{}, false},
+		{"first line", "% render: width=2560 scale=2 theme=dark\n\\documentclass{article}", renderOptions{Width: 2560, Scale: 2, Theme: themeDark}, false},
+		{"after comments", "% Report of one dashboard\n  % render: theme=dark\n\\documentclass{article}", renderOptions{Theme: themeDark}, false},
+		{"unknown option", "% render: height=100\n", renderOptions{}, true},
+		{"invalid option", "% render: scale=10\n", renderOptions{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := getTemplateRenderOptions([]byte(tt.body))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getTemplateRenderOptions() error = %v; want error %t", err, tt.wantErr)
+			}
+			if result != tt.expected {
+				t.Errorf("getTemplateRenderOptions() = %+v; want %+v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGetRenderOptions(t *testing.T) {
+	g := &GrafanaInstance{Templates: map[string][]byte{
+		"plain":   []byte("\\documentclass{article}"),
+		"wide":    []byte("% render: width=2560 theme=dark\n\\documentclass{article}"),
+		"invalid": []byte("% render: theme=blue\n\\documentclass{article}"),
+	}}
+	tests := []struct {
+		template string
+		render   renderOptions
+		expected renderOptions
+	}{
+		{"plain", renderOptions{}, renderOptions{Width: defaultRenderWidth, Theme: themeLight}},
+		{"wide", renderOptions{}, renderOptions{Width: 2560, Theme: themeDark}},
+		{"wide", renderOptions{Scale: 2, Theme: themeLight}, renderOptions{Width: 2560, Scale: 2, Theme: themeLight}},
+		{"invalid", renderOptions{Width: 1280}, renderOptions{Width: 1280, Theme: themeLight}},
+	}
+	for _, tt := range tests {
+		if result := g.getRenderOptions(&reportParameters{Template: tt.template, Render: tt.render}); result != tt.expected {
+			t.Errorf("getRenderOptions(%q, %+v) = %+v; want %+v", tt.template, tt.render, result, tt.expected)
+		}
+	}
+}
+
+func TestGetReportParametersRenderOptions(t *testing.T) {
+	g := &GrafanaInstance{DefaultFrom: "now-1h", DefaultTo: "now", RowsMode: dashboard.RowsExpanded}
+	startTime := time.Date(2024, 1, 25, 14, 43, 12, 0, time.UTC)
+	request := httptest.NewRequest(http.MethodGet, "/api/v1/report/uid?width=1280&scale=2&theme=dark", nil)
+	request.Header.Set("Authorization", "Bearer token")
+	params, _, err := g.getReportParametersFromRequest(request, "uid", "simpleTemplate", startTime)
+	if err != nil {
+		t.Fatalf("getReportParametersFromRequest failed: %v", err)
+	}
+	if params.Render != (renderOptions{Width: 1280, Scale: 2, Theme: themeDark}) {
+		t.Errorf("Render = %+v", params.Render)
+	}
+	if id := generateUniqueRequestID(params); !strings.HasSuffix(id, "_w1280_scale2_dark") {
+		t.Errorf("generateUniqueRequestID() = %q; want render options in the ID", id)
+	}
+	request = httptest.NewRequest(http.MethodGet, "/api/v1/report/uid?theme=blue", nil)
+	request.Header.Set("Authorization", "Bearer token")
+	if _, _, err = g.getReportParametersFromRequest(request, "uid", "simpleTemplate", startTime); err == nil {
+		t.Errorf("getReportParametersFromRequest() with invalid theme; want error")
+	}
+}
+
+func TestGetPanelsURLsRenderOptions(t *testing.T) {
+	sd := &dashboard.StructuredDashboard{
+		UID:  "uid",
+		Slug: "slug",
+		Rows: []*dashboard.Row{{Panels: []dashboard.Panel{{ID: 1, Type: "graph", GridPos: dashboard.GridPos{H: 8, W: 12}}}}},
+	}
+	infos, err := getPanelsURLs("http://grafana:3000", sd, "now-1h", "now", "", renderOptions{Width: 2400, Scale: 2, Theme: themeDark}, url.Values{}, 0, "")
+	if err != nil {
+		t.Fatalf("getPanelsURLs failed: %v", err)
+	}
+	for _, param := range []string{"width=1200", "height=800", "scale=2", "theme=dark", "timeout=60"} {
+		if !strings.Contains(infos[0].URL, param) {
+			t.Errorf("getPanelsURLs() = %q; want URL with %s", infos[0].URL, param)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/Netcracker/grafana-reporter/dashboard"
+	"github.com/Netcracker/grafana-reporter/timerange"
+)
+
+const versionsPageLimit = 100
+
+// parseDashboardVersion reads the version of the dashboard set by its number or by the time. The time is parsed like
+// the end of the time range in the timezone of the report: date, timestamp in seconds or milliseconds or relative time.
+func parseDashboardVersion(version string, asOf string, now time.Time, options timerange.Options) (int, time.Time, error) {
+	if version != "" && asOf != "" {
+		return 0, time.Time{}, fmt.Errorf("parameters %q and %q can not be set together", "version", "asOf")
+	}
+	if version != "" {
+		number, err := strconv.Atoi(version)
+		if err != nil || number < 1 {
+			return 0, time.Time{}, fmt.Errorf("parameter %q must be a positive number, got %q", "version", version)
+		}
+		return number, time.Time{}, nil
+	}
+	if asOf == "" {
+		return 0, time.Time{}, nil
+	}
+	t, err := timerange.RelativeTimeToTimestampWithOptions(now, asOf, "to", options)
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("parameter %q is not a valid time: %w", "asOf", err)
+	}
+	return 0, t, nil
+}
+
+// getDashboardVersion requests the historical version of the dashboard set by the number or by the time
+func (g *GrafanaInstance) getDashboardVersion(ctx context.Context, params *reportParameters) (*dashboard.StructuredDashboard, error) {
+	version := params.Version
+	if version == 0 {
+		found, err := g.findDashboardVersion(ctx, params.DashboardUID, params.AsOf, params.AuthHeader, params.OrgID)
+		if err != nil {
+			return nil, err
+		}
+		version = found.Version
+		slog.Info(fmt.Sprintf("Version %d of the dashboard %s is the latest one saved at %s", version, params.DashboardUID, params.AsOf.UTC()))
+	}
+	urlString, err := url.JoinPath(g.Endpoint, "/api/dashboards/uid/", params.DashboardUID, "versions", strconv.Itoa(version))
+	if err != nil {
+		return nil, fmt.Errorf("could not create URL for request Grafana dashboard version :%w", err)
+	}
+	var body json.RawMessage
+	if err = g.requestJSON(ctx, urlString, params.AuthHeader, params.OrgID, &body); err != nil {
+		return nil, fmt.Errorf("failed to get version %d of Grafana dashboard: %w", version, err)
+	}
+	structured, err := dashboard.ParseDashboardVersion(body, params.RowsMode, params.Selection)
+	if err != nil {
+		return nil, err
+	}
+	if structured.UID == "" {
+		structured.UID = params.DashboardUID
+	}
+	return structured, nil
+}
+
+// findDashboardVersion returns the latest version of the dashboard saved at the time or before it.
+// Versions are returned by Grafana from the newest to the oldest one, so pages are requested until the version is found.
+func (g *GrafanaInstance) findDashboardVersion(ctx context.Context, dashboardUID string, asOf time.Time, authHeader string, orgID int) (dashboard.DashboardVersion, error) {
+	urlString, err := url.JoinPath(g.Endpoint, "/api/dashboards/uid/", dashboardUID, "versions")
+	if err != nil {
+		return dashboard.DashboardVersion{}, fmt.Errorf("could not create URL for request Grafana dashboard versions :%w", err)
+	}
+	start := 0
+	continueToken := ""
+	for {
+		query := url.Values{"limit": {strconv.Itoa(versionsPageLimit)}}
+		if continueToken != "" {
+			query.Set("continueToken", continueToken)
+		} else if start > 0 {
+			query.Set("start", strconv.Itoa(start))
+		}
+		var page dashboard.DashboardVersionsPage
+		if err = g.requestJSON(ctx, fmt.Sprintf("%s?%s", urlString, query.Encode()), authHeader, orgID, &page); err != nil {
+			return dashboard.DashboardVersion{}, fmt.Errorf("failed to get versions of Grafana dashboard: %w", err)
+		}
+		if version, ok := dashboard.FindVersionAsOf(page.Versions, asOf); ok {
+			return version, nil
+		}
+		if len(page.Versions) == 0 || (page.ContinueToken == "" && len(page.Versions) < versionsPageLimit) {
+			break
+		}
+		continueToken = page.ContinueToken
+		start += len(page.Versions)
+	}
+	return dashboard.DashboardVersion{}, fmt.Errorf("dashboard %s has no versions saved at %s or before", dashboardUID, asOf.UTC())
+}
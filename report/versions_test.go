@@ -0,0 +1,85 @@
+package report
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Netcracker/grafana-reporter/dashboard"
+	"github.com/Netcracker/grafana-reporter/timerange"
+)
+
+func TestParseDashboardVersion(t *testing.T) {
+	now := time.Date(2024, 1, 25, 14, 43, 12, 0, time.UTC)
+	tests := []struct {
+		name    string
+		version string
+		asOf    string
+		number  int
+		time    time.Time
+		isError bool
+	}{
+		{"latest", "", "", 0, time.Time{}, false},
+		{"version", "5", "", 5, time.Time{}, false},
+		{"invalid version", "0", "", 0, time.Time{}, true},
+		{"both", "5", "now", 0, time.Time{}, true},
+		{"RFC 3339", "", "2024-01-20T10:00:00Z", 0, time.Date(2024, 1, 20, 10, 0, 0, 0, time.UTC), false},
+		{"seconds", "", "1705744800", 0, time.Date(2024, 1, 20, 10, 0, 0, 0, time.UTC), false},
+		{"milliseconds", "", "1705744800000", 0, time.Date(2024, 1, 20, 10, 0, 0, 0, time.UTC), false},
+		{"relative", "", "now-1d", 0, time.Date(2024, 1, 24, 14, 43, 12, 0, time.UTC), false},
+		{"date in the timezone", "", "2024-01-20 10:00:00", 0, time.Date(2024, 1, 20, 8, 0, 0, 0, time.UTC), false},
+		{"invalid time", "", "tomorrow", 0, time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			number, asOf, err := parseDashboardVersion(tt.version, tt.asOf, now, timerange.Options{Location: time.FixedZone("+02:00", 2*60*60)})
+			if (err != nil) != tt.isError {
+				t.Fatalf("parseDashboardVersion() error = %v; want error %t", err, tt.isError)
+			}
+			if number != tt.number || !asOf.Equal(tt.time) {
+				t.Errorf("parseDashboardVersion() = %d, %s; want %d, %s", number, asOf, tt.number, tt.time)
+			}
+		})
+	}
+}
+
+func TestGetDashboardVersionAsOf(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/dashboards/uid/uid/versions":
+			// the first page contains only versions saved after the requested time
+			if r.URL.Query().Get("continueToken") == "" {
+				_, _ = w.Write([]byte(`{"versions": [{"version": 4, "created": "2024-01-25T13:00:00Z"}], "continueToken": "page2"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"versions": [{"version": 3, "created": "2024-01-22T13:00:00Z"}, {"version": 2, "created": "2024-01-20T13:00:00Z"}]}`))
+		case "/api/dashboards/uid/uid/versions/3":
+			_, _ = w.Write([]byte(`{"version": 3, "created": "2024-01-22T13:00:00Z", "data": {"uid": "uid", "title": "Dashboard",
+				"panels": [{"id": 1, "type": "graph", "gridPos": {"h": 8, "w": 24, "x": 0, "y": 0}}]}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	g := &GrafanaInstance{Endpoint: server.URL}
+	params := &reportParameters{
+		DashboardUID: "uid",
+		RowsMode:     dashboard.RowsExpanded,
+		AsOf:         time.Date(2024, 1, 23, 0, 0, 0, 0, time.UTC),
+	}
+	sd, err := g.getDashboard(context.Background(), params)
+	if err != nil {
+		t.Fatalf("getDashboard failed: %v", err)
+	}
+	if sd.Version == nil || sd.Version.Version != 3 || sd.Slug != "dashboard" {
+		t.Errorf("Version = %+v, slug = %s; want version 3 and slug dashboard", sd.Version, sd.Slug)
+	}
+
+	params.AsOf = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if _, err = g.getDashboard(context.Background(), params); err == nil {
+		t.Error("Expected error for the time before the first version, got nil")
+	}
+}
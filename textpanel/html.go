@@ -0,0 +1,158 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package textpanel
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var regExpressionSpaces = regexp.MustCompile(`\s+`)
+
+// parseHTML converts HTML content of the text panel to nodes. Unknown elements are replaced by their content,
+// scripts, styles and embedded objects are dropped.
+func parseHTML(content string) *Node {
+	document := &Node{Kind: KindDocument}
+	root, err := html.Parse(strings.NewReader(content))
+	if err != nil {
+		document.Children = []*Node{{Kind: KindText, Text: content}}
+		return document
+	}
+	document.Children = convertHTMLChildren(root, false)
+	return document
+}
+
+func convertHTMLChildren(parent *html.Node, header bool) []*Node {
+	var nodes []*Node
+	for child := parent.FirstChild; child != nil; child = child.NextSibling {
+		nodes = append(nodes, convertHTML(child, header)...)
+	}
+	return nodes
+}
+
+func convertHTML(n *html.Node, header bool) []*Node {
+	switch n.Type {
+	case html.TextNode:
+		text := regExpressionSpaces.ReplaceAllString(n.Data, " ")
+		if strings.TrimSpace(text) == "" && !strings.Contains(text, " ") {
+			return nil
+		}
+		return []*Node{{Kind: KindText, Text: text}}
+	case html.ElementNode:
+	case html.DocumentNode:
+		return convertHTMLChildren(n, header)
+	default:
+		return nil
+	}
+
+	var node *Node
+	switch n.DataAtom {
+	case atom.Script, atom.Style, atom.Iframe, atom.Object, atom.Embed, atom.Svg, atom.Img, atom.Head:
+		return nil
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		node = &Node{Kind: KindHeading, Level: int(n.Data[1] - '0')}
+	case atom.P:
+		node = &Node{Kind: KindParagraph}
+	case atom.Ul, atom.Ol:
+		node = &Node{Kind: KindList, Ordered: n.DataAtom == atom.Ol}
+		// lists contain only items
+		for _, child := range convertHTMLChildren(n, header) {
+			if child.Kind == KindListItem {
+				node.Children = append(node.Children, child)
+			}
+		}
+		return []*Node{node}
+	case atom.Li:
+		node = &Node{Kind: KindListItem}
+	case atom.Pre:
+		return []*Node{{Kind: KindCodeBlock, Text: strings.Trim(htmlText(n), "\n")}}
+	case atom.Blockquote:
+		node = &Node{Kind: KindQuote}
+	case atom.Table:
+		node = &Node{Kind: KindTable}
+		node.Children = collectTableRows(n, false)
+		return []*Node{node}
+	case atom.Hr:
+		return []*Node{{Kind: KindRule}}
+	case atom.Br:
+		return []*Node{{Kind: KindLineBreak}}
+	case atom.B, atom.Strong:
+		node = &Node{Kind: KindStrong}
+	case atom.I, atom.Em:
+		node = &Node{Kind: KindEmphasis}
+	case atom.Code, atom.Kbd, atom.Samp, atom.Tt:
+		return []*Node{{Kind: KindCode, Text: htmlText(n)}}
+	case atom.A:
+		node = &Node{Kind: KindLink, URL: htmlAttribute(n, "href")}
+	default:
+		return convertHTMLChildren(n, header)
+	}
+	node.Children = convertHTMLChildren(n, header)
+	return []*Node{node}
+}
+
+// collectTableRows returns rows of the table including rows in thead, tbody and tfoot
+func collectTableRows(n *html.Node, header bool) []*Node {
+	var rows []*Node
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode {
+			continue
+		}
+		switch child.DataAtom {
+		case atom.Thead:
+			rows = append(rows, collectTableRows(child, true)...)
+		case atom.Tbody, atom.Tfoot:
+			rows = append(rows, collectTableRows(child, false)...)
+		case atom.Tr:
+			row := &Node{Kind: KindTableRow, Header: header}
+			for cell := child.FirstChild; cell != nil; cell = cell.NextSibling {
+				if cell.Type == html.ElementNode && (cell.DataAtom == atom.Td || cell.DataAtom == atom.Th) {
+					row.Header = row.Header || cell.DataAtom == atom.Th
+					row.Children = append(row.Children, &Node{Kind: KindTableCell, Children: convertHTMLChildren(cell, false)})
+				}
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+func htmlText(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var sb strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && child.DataAtom == atom.Br {
+			sb.WriteString("\n")
+			continue
+		}
+		sb.WriteString(htmlText(child))
+	}
+	return sb.String()
+}
+
+func htmlAttribute(n *html.Node, name string) string {
+	for _, attr := range n.Attr {
+		if strings.EqualFold(attr.Key, name) {
+			return attr.Val
+		}
+	}
+	return ""
+}
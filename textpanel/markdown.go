@@ -0,0 +1,260 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package textpanel
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	regExpressionHeading   = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	regExpressionRule      = regexp.MustCompile(`^\s{0,3}([-*_])(\s*[-*_]){2,}\s*$`)
+	regExpressionListItem  = regexp.MustCompile(`^(\s*)([-*+]|\d+[.)])\s+(.*)$`)
+	regExpressionFence     = regexp.MustCompile("^\\s*(```|~~~)")
+	regExpressionTableSep  = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+	regExpressionQuoteLine = regexp.MustCompile(`^\s{0,3}>\s?(.*)$`)
+)
+
+// parseMarkdown parses the subset of markdown used in text panels: headings, paragraphs, lists, code, quotes,
+// tables, rules, emphasis and links
+func parseMarkdown(content string) *Node {
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	return &Node{Kind: KindDocument, Children: parseMarkdownBlocks(lines)}
+}
+
+func parseMarkdownBlocks(lines []string) []*Node {
+	var blocks []*Node
+	var paragraph []string
+	flushParagraph := func() {
+		if len(paragraph) > 0 {
+			blocks = append(blocks, &Node{Kind: KindParagraph, Children: parseParagraphLines(paragraph)})
+			paragraph = nil
+		}
+	}
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		switch {
+		case strings.TrimSpace(line) == "":
+			flushParagraph()
+		case regExpressionFence.MatchString(line):
+			flushParagraph()
+			fence := regExpressionFence.FindStringSubmatch(line)[1]
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), fence); i++ {
+				code = append(code, lines[i])
+			}
+			blocks = append(blocks, &Node{Kind: KindCodeBlock, Text: strings.Join(code, "\n")})
+		case regExpressionHeading.MatchString(line):
+			flushParagraph()
+			match := regExpressionHeading.FindStringSubmatch(line)
+			blocks = append(blocks, &Node{Kind: KindHeading, Level: len(match[1]), Children: parseInline(match[2])})
+		case regExpressionRule.MatchString(line):
+			flushParagraph()
+			blocks = append(blocks, &Node{Kind: KindRule})
+		case regExpressionQuoteLine.MatchString(line):
+			flushParagraph()
+			var quote []string
+			for ; i < len(lines) && regExpressionQuoteLine.MatchString(lines[i]); i++ {
+				quote = append(quote, regExpressionQuoteLine.FindStringSubmatch(lines[i])[1])
+			}
+			i--
+			blocks = append(blocks, &Node{Kind: KindQuote, Children: parseMarkdownBlocks(quote)})
+		case strings.Contains(line, "|") && i+1 < len(lines) && regExpressionTableSep.MatchString(lines[i+1]):
+			flushParagraph()
+			table := &Node{Kind: KindTable, Children: []*Node{parseTableRow(line, true)}}
+			for i += 2; i < len(lines) && strings.Contains(lines[i], "|") && strings.TrimSpace(lines[i]) != ""; i++ {
+				table.Children = append(table.Children, parseTableRow(lines[i], false))
+			}
+			i--
+			blocks = append(blocks, table)
+		case regExpressionListItem.MatchString(line):
+			flushParagraph()
+			var list *Node
+			list, i = parseList(lines, i)
+			blocks = append(blocks, list)
+		default:
+			paragraph = append(paragraph, line)
+		}
+	}
+	flushParagraph()
+	return blocks
+}
+
+// parseList parses list started on the line with index start and returns the list and the index of its last line.
+// Items indented deeper than the first one are parsed as nested lists.
+func parseList(lines []string, start int) (*Node, int) {
+	match := regExpressionListItem.FindStringSubmatch(lines[start])
+	indent := len(match[1])
+	list := &Node{Kind: KindList, Ordered: !strings.ContainsAny(match[2], "-*+")}
+	var item *Node
+	var itemLines []string
+	flushItem := func() {
+		if item != nil {
+			item.Children = append(parseParagraphLines(itemLines), item.Children...)
+			list.Children = append(list.Children, item)
+		}
+	}
+	i := start
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if strings.TrimSpace(line) == "" {
+			break
+		}
+		match = regExpressionListItem.FindStringSubmatch(line)
+		switch {
+		case match != nil && len(match[1]) == indent:
+			flushItem()
+			item = &Node{Kind: KindListItem}
+			itemLines = []string{match[3]}
+		case match != nil && len(match[1]) > indent:
+			var nested *Node
+			nested, i = parseList(lines, i)
+			item.Children = append(item.Children, nested)
+		case match != nil:
+			// item of the parent list
+			flushItem()
+			return list, i - 1
+		default:
+			itemLines = append(itemLines, line)
+		}
+	}
+	flushItem()
+	return list, i - 1
+}
+
+func parseTableRow(line string, header bool) *Node {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	line = strings.TrimSuffix(line, "|")
+	row := &Node{Kind: KindTableRow, Header: header}
+	for _, cell := range strings.Split(line, "|") {
+		row.Children = append(row.Children, &Node{Kind: KindTableCell, Children: parseInline(strings.TrimSpace(cell))})
+	}
+	return row
+}
+
+// parseParagraphLines joins lines of the paragraph. Lines ended with two spaces or backslash are hard breaks.
+func parseParagraphLines(lines []string) []*Node {
+	var nodes []*Node
+	for i, line := range lines {
+		hardBreak := strings.HasSuffix(line, "  ") || strings.HasSuffix(line, `\`)
+		line = strings.TrimSuffix(strings.TrimSpace(line), `\`)
+		nodes = append(nodes, parseInline(line)...)
+		if i < len(lines)-1 {
+			if hardBreak {
+				nodes = append(nodes, &Node{Kind: KindLineBreak})
+			} else {
+				nodes = append(nodes, &Node{Kind: KindText, Text: " "})
+			}
+		}
+	}
+	return nodes
+}
+
+// parseInline parses emphasis, code spans and links of the text
+func parseInline(text string) []*Node {
+	var nodes []*Node
+	var sb strings.Builder
+	flushText := func() {
+		if sb.Len() > 0 {
+			nodes = append(nodes, &Node{Kind: KindText, Text: sb.String()})
+			sb.Reset()
+		}
+	}
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case c == '\\' && i+1 < len(text) && strings.IndexByte("\\`*_{}[]()#+-.!|<>~$", text[i+1]) >= 0:
+			sb.WriteByte(text[i+1])
+			i++
+		case c == '`':
+			end := strings.IndexByte(text[i+1:], '`')
+			if end < 0 {
+				sb.WriteByte(c)
+				continue
+			}
+			flushText()
+			nodes = append(nodes, &Node{Kind: KindCode, Text: text[i+1 : i+1+end]})
+			i += end + 1
+		case (c == '*' || c == '_') && i+1 < len(text) && text[i+1] == c:
+			delimiter := text[i : i+2]
+			end := strings.Index(text[i+2:], delimiter)
+			if end <= 0 {
+				sb.WriteString(delimiter)
+				i++
+				continue
+			}
+			flushText()
+			nodes = append(nodes, &Node{Kind: KindStrong, Children: parseInline(text[i+2 : i+2+end])})
+			i += end + 3
+		case c == '*' || c == '_':
+			end := strings.IndexByte(text[i+1:], c)
+			// underscore inside words is not emphasis
+			if end <= 0 || (c == '_' && i > 0 && isWordChar(text[i-1])) {
+				sb.WriteByte(c)
+				continue
+			}
+			flushText()
+			nodes = append(nodes, &Node{Kind: KindEmphasis, Children: parseInline(text[i+1 : i+1+end])})
+			i += end + 1
+		case c == '[' || (c == '!' && i+1 < len(text) && text[i+1] == '['):
+			image := c == '!'
+			offset := i
+			if image {
+				offset++
+			}
+			label, url, length, ok := parseLink(text[offset:])
+			if !ok {
+				sb.WriteByte(c)
+				continue
+			}
+			flushText()
+			if image {
+				// images are not downloaded, alternative text is shown instead
+				nodes = append(nodes, &Node{Kind: KindEmphasis, Children: []*Node{{Kind: KindText, Text: label}}})
+			} else {
+				nodes = append(nodes, &Node{Kind: KindLink, URL: url, Children: parseInline(label)})
+			}
+			i = offset + length - 1
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	flushText()
+	return nodes
+}
+
+// parseLink parses link in the format [label](url "title") at the start of the text
+func parseLink(text string) (label string, url string, length int, ok bool) {
+	closeLabel := strings.Index(text, "](")
+	if closeLabel < 0 {
+		return "", "", 0, false
+	}
+	closeURL := strings.IndexByte(text[closeLabel+2:], ')')
+	if closeURL < 0 {
+		return "", "", 0, false
+	}
+	url = strings.TrimSpace(text[closeLabel+2 : closeLabel+2+closeURL])
+	if space := strings.IndexByte(url, ' '); space >= 0 {
+		url = url[:space]
+	}
+	return text[1:closeLabel], url, closeLabel + 3 + closeURL, true
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
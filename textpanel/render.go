@@ -0,0 +1,179 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package textpanel
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/Netcracker/grafana-reporter/utils"
+)
+
+var texHeadingSizes = map[int]string{
+	1: `\Large`,
+	2: `\large`,
+}
+
+// texHrefReplacer escapes URLs for the first argument of \href. Backslashes and braces are percent-encoded,
+// other special characters are escaped as hyperref expects in arguments of other commands.
+var texHrefReplacer = strings.NewReplacer(
+	`\`, `\%5C`,
+	`{`, `\%7B`,
+	`}`, `\%7D`,
+	`%`, `\%`,
+	`#`, `\#`,
+	`~`, `\~`,
+)
+
+// texRenderer writes nodes as TeX. Links are written with \href if hyperref is loaded by the template.
+type texRenderer struct {
+	sb       *strings.Builder
+	hyperref bool
+}
+
+// render writes the node as TeX. The result does not use verbatim environments, so it can be placed
+// to arguments of other commands, for example \put of the picture environment.
+func (r *texRenderer) render(n *Node) {
+	sb := r.sb
+	switch n.Kind {
+	case KindDocument, KindListItem, KindTableCell:
+		r.renderChildren(n)
+	case KindHeading:
+		size, ok := texHeadingSizes[n.Level]
+		if !ok {
+			size = `\normalsize`
+		}
+		fmt.Fprintf(sb, "{%s\\bfseries ", size)
+		r.renderChildren(n)
+		sb.WriteString("\\par}\n")
+	case KindParagraph:
+		r.renderChildren(n)
+		sb.WriteString("\\par\n")
+	case KindList:
+		if len(n.Children) == 0 {
+			return
+		}
+		env := "itemize"
+		if n.Ordered {
+			env = "enumerate"
+		}
+		fmt.Fprintf(sb, "\\begin{%s}\n", env)
+		for _, item := range n.Children {
+			sb.WriteString("\\item ")
+			r.render(item)
+			sb.WriteString("\n")
+		}
+		fmt.Fprintf(sb, "\\end{%s}\n", env)
+	case KindCodeBlock:
+		sb.WriteString("{\\ttfamily\\small\n")
+		for _, line := range strings.Split(n.Text, "\n") {
+			line = strings.ReplaceAll(utils.EscapeTex(line), " ", "~")
+			fmt.Fprintf(sb, "\\mbox{}%s\\\\\n", line)
+		}
+		sb.WriteString("}\\par\n")
+	case KindQuote:
+		sb.WriteString("\\begin{quote}\n")
+		r.renderChildren(n)
+		sb.WriteString("\\end{quote}\n")
+	case KindTable:
+		r.renderTable(n)
+	case KindRule:
+		sb.WriteString("\\noindent\\rule{\\linewidth}{0.4pt}\\par\n")
+	case KindText:
+		sb.WriteString(utils.EscapeTex(n.Text))
+	case KindStrong:
+		sb.WriteString("\\textbf{")
+		r.renderChildren(n)
+		sb.WriteString("}")
+	case KindEmphasis:
+		sb.WriteString("\\textit{")
+		r.renderChildren(n)
+		sb.WriteString("}")
+	case KindCode:
+		fmt.Fprintf(sb, "\\texttt{%s}", utils.EscapeTex(n.Text))
+	case KindLink:
+		text := n.textContent()
+		switch {
+		case n.URL == "" || !isSafeURL(n.URL):
+			r.renderChildren(n)
+		case r.hyperref && (text == "" || text == n.URL):
+			fmt.Fprintf(sb, "\\href{%s}{\\texttt{%s}}", texHrefReplacer.Replace(n.URL), utils.EscapeTex(n.URL))
+		case r.hyperref:
+			fmt.Fprintf(sb, "\\href{%s}{", texHrefReplacer.Replace(n.URL))
+			r.renderChildren(n)
+			sb.WriteString("}")
+		case text == "" || text == n.URL:
+			fmt.Fprintf(sb, "\\texttt{%s}", utils.EscapeTex(n.URL))
+		default:
+			r.renderChildren(n)
+			fmt.Fprintf(sb, " (\\texttt{%s})", utils.EscapeTex(n.URL))
+		}
+	case KindLineBreak:
+		sb.WriteString("\\newline\n")
+	}
+}
+
+func (r *texRenderer) renderChildren(n *Node) {
+	for _, child := range n.Children {
+		r.render(child)
+	}
+}
+
+func (r *texRenderer) renderTable(n *Node) {
+	sb := r.sb
+	columns := 0
+	for _, row := range n.Children {
+		columns = max(columns, len(row.Children))
+	}
+	if columns == 0 {
+		return
+	}
+	fmt.Fprintf(sb, "\\begin{tabular}{|%s}\n\\hline\n", strings.Repeat("l|", columns))
+	for _, row := range n.Children {
+		for i := 0; i < columns; i++ {
+			if i > 0 {
+				sb.WriteString(" & ")
+			}
+			if i >= len(row.Children) {
+				continue
+			}
+			if row.Header {
+				sb.WriteString("\\textbf{")
+				r.render(row.Children[i])
+				sb.WriteString("}")
+			} else {
+				r.render(row.Children[i])
+			}
+		}
+		sb.WriteString(" \\\\\n\\hline\n")
+	}
+	sb.WriteString("\\end{tabular}\\par\n")
+}
+
+// isSafeURL allows only links to web pages and mail addresses, for example, javascript: links are dropped
+func isSafeURL(link string) bool {
+	u, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "", "http", "https", "mailto":
+		return true
+	default:
+		return false
+	}
+}
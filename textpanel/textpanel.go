@@ -0,0 +1,100 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package textpanel converts content of Grafana text panels to TeX,
+// so text panels can be included to the report natively instead of screenshots.
+package textpanel
+
+import (
+	"strings"
+)
+
+// Modes of the text panel content
+const (
+	ModeMarkdown = "markdown"
+	ModeHTML     = "html"
+	ModeCode     = "code"
+)
+
+type Kind int
+
+const (
+	KindDocument Kind = iota
+	KindHeading
+	KindParagraph
+	KindList
+	KindListItem
+	KindCodeBlock
+	KindQuote
+	KindTable
+	KindTableRow
+	KindTableCell
+	KindRule
+	KindText
+	KindStrong
+	KindEmphasis
+	KindCode
+	KindLink
+	KindLineBreak
+)
+
+// Node is an element of the text panel content
+type Node struct {
+	Kind Kind
+	// Level of the heading
+	Level int
+	// Ordered is true for numbered lists
+	Ordered bool
+	// Header is true for header rows of tables
+	Header bool
+	// Text of text, code and code block nodes
+	Text string
+	// URL of links
+	URL      string
+	Children []*Node
+}
+
+// Parse parses content of the text panel in the mode. Unknown mode is parsed as markdown.
+func Parse(content string, mode string) *Node {
+	switch strings.ToLower(mode) {
+	case ModeHTML:
+		return parseHTML(content)
+	case ModeCode:
+		return &Node{Kind: KindDocument, Children: []*Node{{Kind: KindCodeBlock, Text: strings.TrimRight(content, "\n")}}}
+	default:
+		return parseMarkdown(content)
+	}
+}
+
+// ToTeX converts content of the text panel to TeX. Links are written with \href if hyperref is true,
+// the template must load the hyperref package then.
+func ToTeX(content string, mode string, hyperref bool) string {
+	var sb strings.Builder
+	r := texRenderer{sb: &sb, hyperref: hyperref}
+	r.render(Parse(content, mode))
+	return strings.TrimSpace(sb.String())
+}
+
+// textContent returns the text of the node and all its children
+func (n *Node) textContent() string {
+	if len(n.Children) == 0 {
+		return n.Text
+	}
+	var sb strings.Builder
+	for _, child := range n.Children {
+		sb.WriteString(child.textContent())
+	}
+	return sb.String()
+}
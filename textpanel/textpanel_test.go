@@ -0,0 +1,85 @@
+package textpanel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMarkdown(t *testing.T) {
+	content := "# Title\n\nSome **bold** and *italic* text\nwith `code`.\n\n- one\n- two\n  1. nested\n\n| A | B |\n|---|---|\n| 1 | 2 |\n\n```\nx := 1\n```\n\n> quote\n\n---"
+	document := Parse(content, ModeMarkdown)
+	kinds := make([]Kind, 0, len(document.Children))
+	for _, block := range document.Children {
+		kinds = append(kinds, block.Kind)
+	}
+	assert.Equal(t, []Kind{KindHeading, KindParagraph, KindList, KindTable, KindCodeBlock, KindQuote, KindRule}, kinds)
+
+	assert.Equal(t, 1, document.Children[0].Level)
+	assert.Equal(t, "Some bold and italic text with code.", document.Children[1].textContent())
+	list := document.Children[2]
+	assert.Len(t, list.Children, 2)
+	assert.Equal(t, KindList, list.Children[1].Children[1].Kind)
+	assert.True(t, list.Children[1].Children[1].Ordered)
+	assert.True(t, document.Children[3].Children[0].Header)
+	assert.Equal(t, "x := 1", document.Children[4].Text)
+}
+
+func TestToTeX(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		mode     string
+		expected []string
+	}{
+		{"escaping", "50% of $var_name", ModeMarkdown, []string{`50\% of \$var\_name\par`}},
+		{"heading", "## Section", ModeMarkdown, []string{`{\large\bfseries Section\par}`}},
+		{"emphasis", "**a** _b_ `c`", ModeMarkdown, []string{`\textbf{a} \textit{b} \texttt{c}`}},
+		{"link", "[docs](https://example.com/a_b)", ModeMarkdown, []string{`docs (\texttt{https://example.com/a\_b})`}},
+		{"unsafe link", "[click](javascript:alert(1))", ModeMarkdown, []string{"click"}},
+		{"list", "1. a\n2. b", ModeMarkdown, []string{`\begin{enumerate}`, `\item a`, `\end{enumerate}`}},
+		{"table", "| A | B |\n|---|---|\n| 1 |", ModeMarkdown, []string{`\begin{tabular}{|l|l|}`, `\textbf{A} & \textbf{B}`, `1 &  \\`}},
+		{"code", "a  b\n{c}", ModeCode, []string{`\mbox{}a~~b\\`, `\mbox{}\{c\}\\`}},
+		{"html", "<h1>T</h1><p>a<br>b</p><script>alert(1)</script>", ModeHTML, []string{`{\Large\bfseries T\par}`, "a\\newline\nb\\par"}},
+		{"nested list", "- a\n  - b\n    - c\n- d", ModeMarkdown, []string{"\\item a\\begin{itemize}\n\\item b\\begin{itemize}\n\\item c\n\\end{itemize}", "\\item d\n\\end{itemize}"}},
+		{"blockquote", "> a\n> > b\n>\n> - c", ModeMarkdown, []string{"\\begin{quote}\na\\par\n\\begin{quote}\nb\\par\n\\end{quote}\n\\begin{itemize}\n\\item c\n\\end{itemize}\n\\end{quote}"}},
+		{"html blockquote", "<blockquote><p>q</p><ul><li>a<ul><li>b</li></ul></li></ul></blockquote>", ModeHTML, []string{"\\begin{quote}\nq\\par\n\\begin{itemize}\n\\item a\\begin{itemize}\n\\item b"}},
+		{"html table", "<table><tr><th>A</th><th>B</th></tr><tr><td>1</td><td><b>2</b></td></tr></table>", ModeHTML, []string{`\begin{tabular}{|l|l|}`, `\textbf{A} & \textbf{B} \\`, `1 & \textbf{2} \\`}},
+		{"unclosed html table", "<table><tr><td>1<tr><td>2</table><p>x", ModeHTML, []string{`\begin{tabular}{|l|}`, "1 \\\\\n\\hline\n2 \\\\", "\\end{tabular}\\par\nx\\par"}},
+		{"unterminated emphasis", "**bold and *it", ModeMarkdown, []string{`**bold and *it\par`}},
+		{"unterminated code", "a _b `c", ModeMarkdown, []string{"a \\_b `c\\par"}},
+		{"table without delimiter row", "| A | B |\n| 1 | 2 |", ModeMarkdown, []string{`| A | B | | 1 | 2 |\par`}},
+		{"table with extra cells", "| A | B |\n|---|---|\n| 1 | 2 | 3 |\nplain", ModeMarkdown, []string{`\begin{tabular}{|l|l|l|}`, `\textbf{A} & \textbf{B} &  \\`, `1 & 2 & 3 \\`, "\\end{tabular}\\par\nplain\\par"}},
+		{"unsafe html", `<p onclick="x()">Hi <b>there</b> <a href="javascript:x()">bad</a></p><img src="x.png"><style>p{}</style>`, ModeHTML, []string{`Hi \textbf{there} bad\par`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ToTeX(tt.content, tt.mode, false)
+			for _, expected := range tt.expected {
+				assert.Contains(t, result, expected)
+			}
+			assert.NotContains(t, result, "javascript")
+			assert.NotContains(t, result, "alert")
+		})
+	}
+}
+
+func TestToTeXLinks(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		hyperref bool
+		expected string
+	}{
+		{"text", "[docs](https://example.com/a_b)", true, `\href{https://example.com/a_b}{docs}\par`},
+		{"url", "[https://example.com/a_b](https://example.com/a_b)", true, `\href{https://example.com/a_b}{\texttt{https://example.com/a\_b}}\par`},
+		{"special characters", `[x](https://e.com/a%20b#c~d?q={1}&r=\)`, true, `\href{https://e.com/a\%20b\#c\~d?q=\%7B1\%7D&r=\%5C}{x}\par`},
+		{"unsafe", "[click](javascript:void)", true, `click\par`},
+		{"without hyperref", "[docs](https://example.com/#a)", false, `docs (\texttt{https://example.com/\#a})\par`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ToTeX(tt.content, ModeMarkdown, tt.hyperref))
+		})
+	}
+}
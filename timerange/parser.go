@@ -0,0 +1,253 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package timerange
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// millisecondsThreshold separates timestamps in seconds from timestamps in milliseconds used by Grafana,
+// 1e11 seconds is the year 5138 and 1e11 milliseconds is the year 1973
+const millisecondsThreshold = 1e11
+
+// absoluteFormats are the formats of absolute time without timezone, it is parsed in the location of the report.
+// Fractional seconds are accepted after seconds.
+var absoluteFormats = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// zonedFormats are ISO-8601 formats of absolute time with timezone
+var zonedFormats = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02 15:04:05Z07:00",
+}
+
+// mathUnits are the units of arithmetic and rounding operations
+const mathUnits = "yQMwdhms"
+
+// operation is arithmetic (+1d, -2w) or rounding (/d, /fy) applied to the time one by one
+type operation struct {
+	// operator is +, - or /
+	operator byte
+	count    int
+	unit     string
+	// fiscal is true for fy and fQ units that are rounded to the fiscal year and quarter
+	fiscal bool
+}
+
+// parseTime parses the time of Grafana time range: now or absolute time followed by operations,
+// for example now-1d/d+8h, now/fy, 2024-01-25 10:00:00, 2024-01-25T10:00:00Z||+1d or 1706190192000
+func parseTime(text string, currentTime time.Time, roundUp bool, options Options, location *time.Location) (time.Time, error) {
+	if text == "" {
+		return time.Time{}, fmt.Errorf("time value is empty")
+	}
+	var anchor time.Time
+	var err error
+	mathStart := 0
+	if strings.HasPrefix(text, "now") {
+		anchor = currentTime
+		if roundUp {
+			anchor = anchor.Add(-options.NowDelay)
+		}
+		mathStart = len("now")
+	} else {
+		absolute := text
+		if index := strings.Index(text, "||"); index >= 0 {
+			absolute = text[:index]
+			mathStart = index + len("||")
+		} else {
+			mathStart = len(text)
+		}
+		if anchor, err = parseAbsoluteTime(absolute, location); err != nil {
+			return time.Time{}, err
+		}
+	}
+	operations, err := parseOperations(text, mathStart)
+	if err != nil {
+		return time.Time{}, err
+	}
+	for _, op := range operations {
+		anchor = op.apply(anchor, roundUp, options)
+	}
+	return anchor, nil
+}
+
+// parseAbsoluteTime parses epoch timestamp in seconds or milliseconds and ISO-8601 time.
+// Time without timezone is in the location of the report.
+func parseAbsoluteTime(text string, location *time.Location) (time.Time, error) {
+	if isDigits(text) {
+		timestamp, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("timestamp %q is not valid :%w", text, err)
+		}
+		if timestamp >= millisecondsThreshold {
+			return time.UnixMilli(timestamp).In(location), nil
+		}
+		return time.Unix(timestamp, 0).In(location), nil
+	}
+	for _, format := range zonedFormats {
+		if t, err := time.Parse(format, text); err == nil {
+			return t.In(location), nil
+		}
+	}
+	for _, format := range absoluteFormats {
+		if t, err := time.ParseInLocation(format, text, location); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("time %q is not valid: expected now, timestamp or date like 2006-01-02 15:04:05", text)
+}
+
+// parseOperations reads operations of the time text from the position start, for example -1d/d+8h
+func parseOperations(text string, start int) ([]operation, error) {
+	var operations []operation
+	position := start
+	for position < len(text) {
+		op := operation{operator: text[position], count: 1}
+		if !strings.ContainsRune("+-/", rune(op.operator)) {
+			return nil, syntaxError(text, position, "unexpected %q, expected +, - or /", text[position])
+		}
+		position++
+		digitsStart := position
+		for position < len(text) && isDigit(text[position]) {
+			position++
+		}
+		if position > digitsStart {
+			if op.operator == '/' {
+				return nil, syntaxError(text, digitsStart, "rounding does not accept a number")
+			}
+			count, err := strconv.Atoi(text[digitsStart:position])
+			if err != nil {
+				return nil, syntaxError(text, digitsStart, "number %q is too large", text[digitsStart:position])
+			}
+			op.count = count
+		}
+		if position < len(text) && text[position] == 'f' {
+			op.fiscal = true
+			position++
+		}
+		if position >= len(text) {
+			return nil, syntaxError(text, position, "missing unit")
+		}
+		op.unit = text[position : position+1]
+		if !strings.Contains(mathUnits, op.unit) {
+			return nil, syntaxError(text, position, "unknown unit %q, expected one of y, Q, M, w, d, h, m, s, fy, fQ", op.unit)
+		}
+		if op.fiscal && op.unit != "y" && op.unit != "Q" {
+			return nil, syntaxError(text, position-1, "unknown unit %q, fiscal units are fy and fQ", "f"+op.unit)
+		}
+		position++
+		operations = append(operations, op)
+	}
+	return operations, nil
+}
+
+// syntaxError returns the error with the position of the invalid character counted from 1
+func syntaxError(text string, position int, format string, args ...any) error {
+	return fmt.Errorf("time %q is not valid: %s at position %d", text, fmt.Sprintf(format, args...), position+1)
+}
+
+// apply adds, subtracts or rounds the time. Rounding is to the start of the unit or to the end of the unit if roundUp.
+func (op operation) apply(t time.Time, roundUp bool, options Options) time.Time {
+	switch op.operator {
+	case '+':
+		return addUnits(t, op.count, op.unit)
+	case '-':
+		return addUnits(t, -op.count, op.unit)
+	}
+	start := startOf(t, op.unit, op.fiscal, options)
+	if !roundUp {
+		return start
+	}
+	return addUnits(start, 1, op.unit).Add(-time.Nanosecond)
+}
+
+// addUnits adds count units to the time. Days and weeks are calendar ones, months, quarters and years
+// are clamped to the last day of the month, so 2024-03-31 minus 1 month is 2024-02-29.
+func addUnits(t time.Time, count int, unit string) time.Time {
+	switch unit {
+	case "y":
+		return addMonths(t, 12*count)
+	case "Q":
+		return addMonths(t, 3*count)
+	case "M":
+		return addMonths(t, count)
+	case "w":
+		return t.AddDate(0, 0, 7*count)
+	case "d":
+		return t.AddDate(0, 0, count)
+	default:
+		return t.Add(time.Duration(count*intervalsInSeconds[unit]) * time.Second)
+	}
+}
+
+func addMonths(t time.Time, count int) time.Time {
+	year, month, day := t.Date()
+	firstDay := time.Date(year, month+time.Month(count), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	lastDay := firstDay.AddDate(0, 1, -1).Day()
+	return firstDay.AddDate(0, 0, min(day, lastDay)-1)
+}
+
+// startOf rounds the time down to the start of the unit
+func startOf(t time.Time, unit string, fiscal bool, options Options) time.Time {
+	year, month, day := t.Date()
+	location := t.Location()
+	switch unit {
+	case "y", "Q":
+		firstMonth := time.January
+		if fiscal {
+			firstMonth += time.Month(options.FiscalYearStartMonth)
+		}
+		monthsPassed := (int(month) - int(firstMonth) + 12) % 12
+		if unit == "Q" {
+			monthsPassed %= 3
+		}
+		return time.Date(year, month-time.Month(monthsPassed), 1, 0, 0, 0, 0, location)
+	case "M":
+		return time.Date(year, month, 1, 0, 0, 0, 0, location)
+	case "w":
+		return time.Date(year, month, day-daysOfWeek(t, options.WeekStart), 0, 0, 0, 0, location)
+	case "d":
+		return time.Date(year, month, day, 0, 0, 0, 0, location)
+	case "h":
+		return time.Date(year, month, day, t.Hour(), 0, 0, 0, location)
+	case "m":
+		return time.Date(year, month, day, t.Hour(), t.Minute(), 0, 0, location)
+	default:
+		return time.Date(year, month, day, t.Hour(), t.Minute(), t.Second(), 0, location)
+	}
+}
+
+func isDigits(text string) bool {
+	for i := 0; i < len(text); i++ {
+		if !isDigit(text[i]) {
+			return false
+		}
+	}
+	return text != ""
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,91 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package timerange
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseTimeExpressions(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	assert.NoError(t, err)
+	april := Options{FiscalYearStartMonth: 3}
+	february := Options{FiscalYearStartMonth: 1}
+	tests := []struct {
+		TimerangeTestData
+		options Options
+	}{
+		{TimerangeTestData{"Future", "now", "now+1d", nowString, "2024-01-26 14:43:12 +0000 UTC"}, Options{}},
+		{TimerangeTestData{"Yesterday from 8 AM", "now-1d/d+8h", "now-1d/d+8h", "2024-01-24 08:00:00 +0000 UTC", "2024-01-25 07:59:59 +0000 UTC"}, Options{}},
+		{TimerangeTestData{"Chained arithmetic", "now-1w-2d+3h", "now-30m-30s", "2024-01-16 17:43:12 +0000 UTC", "2024-01-25 14:12:42 +0000 UTC"}, Options{}},
+		{TimerangeTestData{"This hour", "now/h", "now/h", "2024-01-25 14:00:00 +0000 UTC", "2024-01-25 14:59:59 +0000 UTC"}, Options{}},
+		{TimerangeTestData{"This minute", "now/m", "now/m", "2024-01-25 14:43:00 +0000 UTC", "2024-01-25 14:43:59 +0000 UTC"}, Options{}},
+		{TimerangeTestData{"This quarter", "now/Q", "now/Q", "2024-01-01 00:00:00 +0000 UTC", "2024-03-31 23:59:59 +0000 UTC"}, Options{}},
+		{TimerangeTestData{"Previous quarter", "now-1Q/Q", "now-1Q/Q", "2023-10-01 00:00:00 +0000 UTC", "2023-12-31 23:59:59 +0000 UTC"}, Options{}},
+		{TimerangeTestData{"Fiscal year from January", "now/fy", "now/fy", "2024-01-01 00:00:00 +0000 UTC", "2024-12-31 23:59:59 +0000 UTC"}, Options{}},
+		{TimerangeTestData{"Fiscal year from April", "now/fy", "now/fy", "2023-04-01 00:00:00 +0000 UTC", "2024-03-31 23:59:59 +0000 UTC"}, april},
+		{TimerangeTestData{"Previous fiscal year from April", "now-1y/fy", "now-1fy/fy", "2022-04-01 00:00:00 +0000 UTC", "2023-03-31 23:59:59 +0000 UTC"}, april},
+		{TimerangeTestData{"Fiscal quarter from February", "now/fQ", "now/fQ", "2023-11-01 00:00:00 +0000 UTC", "2024-01-31 23:59:59 +0000 UTC"}, february},
+		{TimerangeTestData{"Milliseconds", "1706190192000", "1706190192999", "2024-01-25 13:43:12 +0000 UTC", "2024-01-25 13:43:12 +0000 UTC"}, Options{}},
+		{TimerangeTestData{"ISO-8601 in UTC", "2024-01-20T10:00:00Z", "2024-01-20T10:00:00.000+03:00", "2024-01-20 13:00:00 +0300 MSK", "2024-01-20 10:00:00 +0300 MSK"}, Options{Location: moscow}},
+		{TimerangeTestData{"Local time", "2024-01-20 10:00:00", "2024-01-20T18:30", "2024-01-20 10:00:00 +0300 MSK", "2024-01-20 18:30:00 +0300 MSK"}, Options{Location: moscow}},
+		{TimerangeTestData{"Date with rounding", "2024-01-20", "2024-01-20||/M", "2024-01-20 00:00:00 +0000 UTC", "2024-01-31 23:59:59 +0000 UTC"}, Options{}},
+		{TimerangeTestData{"End of month is clamped", "2024-03-31 10:00:00||-1M", "2024-01-31||+1M+1d", "2024-02-29 10:00:00 +0000 UTC", "2024-03-01 00:00:00 +0000 UTC"}, Options{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.TestName, func(t *testing.T) {
+			fromTS, err := RelativeTimeToTimestampWithOptions(now, tt.FromTR, "from", tt.options)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.FromTS, fromTS.Format(Format))
+			toTS, err := RelativeTimeToTimestampWithOptions(now, tt.ToTR, "to", tt.options)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.ToTS, toTS.Format(Format))
+		})
+	}
+}
+
+func Test_ParseTimeErrors(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{"", "time value is empty"},
+		{"now-1x", `time "now-1x" is not valid: unknown unit "x", expected one of y, Q, M, w, d, h, m, s, fy, fQ at position 6`},
+		{"now-", `time "now-" is not valid: missing unit at position 5`},
+		{"now/2d", `time "now/2d" is not valid: rounding does not accept a number at position 5`},
+		{"now-1fd", `time "now-1fd" is not valid: unknown unit "fd", fiscal units are fy and fQ at position 6`},
+		{"now 1d", `time "now 1d" is not valid: unexpected ' ', expected +, - or / at position 4`},
+		{"now-1d/d*2", `time "now-1d/d*2" is not valid: unexpected '*', expected +, - or / at position 9`},
+		{"now-99999999999999999999d", `time "now-99999999999999999999d" is not valid: number "99999999999999999999" is too large at position 5`},
+		{"tomorrow", `time "tomorrow" is not valid: expected now, timestamp or date like 2006-01-02 15:04:05`},
+		{"2024-13-01", `time "2024-13-01" is not valid: expected now, timestamp or date like 2006-01-02 15:04:05`},
+		{"2024-01-20||", ""},
+		{"2024-01-20||+", `time "2024-01-20||+" is not valid: missing unit at position 14`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			_, err := RelativeTimeToTimestamp(now, tt.text, "from")
+			if tt.expected == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.expected)
+		})
+	}
+}
@@ -0,0 +1,210 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package timerange
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Period is a named time range like previous-month. Its name is accepted as from and to of the time range:
+// from is the start of the period and to is the end of the period.
+type Period struct {
+	From string `yaml:"from"`
+	To   string `yaml:"to"`
+	// WeekStart is the first day of the week of the period, the week start of the dashboard is used if it is empty
+	WeekStart string `yaml:"weekStart"`
+	// BusinessDays is the number of the last full working days before today, From and To are ignored if it is set
+	BusinessDays int `yaml:"businessDays"`
+}
+
+// Periods are the built-in named periods
+var Periods = map[string]Period{
+	"today":                  {From: "now/d", To: "now/d"},
+	"yesterday":              {From: "now-1d/d", To: "now-1d/d"},
+	"week-to-date":           {From: "now/w", To: "now"},
+	"previous-week":          {From: "now-1w/w", To: "now-1w/w"},
+	"previous-business-week": {From: "now-1w/w", To: "now-1w/w-2d", WeekStart: "monday"},
+	"last-4-full-weeks":      {From: "now-4w/w", To: "now-1w/w", WeekStart: "monday"},
+	"month-to-date":          {From: "now/M", To: "now"},
+	"previous-month":         {From: "now-1M/M", To: "now-1M/M"},
+	"quarter-to-date":        {From: "now/Q", To: "now"},
+	"previous-quarter":       {From: "now-1Q/Q", To: "now-1Q/Q"},
+	"year-to-date":           {From: "now/y", To: "now"},
+	"previous-year":          {From: "now-1y/y", To: "now-1y/y"},
+	"previous-business-day":  {BusinessDays: 1},
+	"last-5-business-days":   {BusinessDays: 5},
+}
+
+var regExpressionPeriodName = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
+// defaultWeekend are the non-working days of the week if the calendar does not set them
+var defaultWeekend = []time.Weekday{time.Saturday, time.Sunday}
+
+// Calendar contains custom named periods and non-working days of business-day periods
+type Calendar struct {
+	// Periods are custom named periods, they replace built-in periods with the same name
+	Periods map[string]Period
+	// Weekend are non-working days of the week, Saturday and Sunday if it is empty
+	Weekend []time.Weekday
+	// Holidays are non-working dates in format 2006-01-02
+	Holidays map[string]bool
+}
+
+// calendarFile is the format of the holiday calendar file
+type calendarFile struct {
+	Weekend  []string `yaml:"weekend"`
+	Holidays []string `yaml:"holidays"`
+}
+
+// NewCalendar reads custom periods and the holiday calendar in YAML format, both of them are optional
+func NewCalendar(periods []byte, holidays []byte) (*Calendar, error) {
+	calendar := &Calendar{Periods: map[string]Period{}, Holidays: map[string]bool{}}
+	if len(periods) > 0 {
+		if err := yaml.Unmarshal(periods, &calendar.Periods); err != nil {
+			return nil, fmt.Errorf("could not parse periods :%w", err)
+		}
+		for name, period := range calendar.Periods {
+			if err := validatePeriod(name, period); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(holidays) > 0 {
+		var file calendarFile
+		if err := yaml.Unmarshal(holidays, &file); err != nil {
+			return nil, fmt.Errorf("could not parse holiday calendar :%w", err)
+		}
+		for _, name := range file.Weekend {
+			day, ok := parseWeekday(name)
+			if !ok {
+				return nil, fmt.Errorf("weekend day is not valid: %s", name)
+			}
+			calendar.Weekend = append(calendar.Weekend, day)
+		}
+		if len(calendar.Weekend) == 7 {
+			return nil, fmt.Errorf("weekend can not include all days of the week")
+		}
+		for _, date := range file.Holidays {
+			t, err := time.Parse(time.DateOnly, date)
+			if err != nil {
+				return nil, fmt.Errorf("holiday is not valid date like 2006-01-02: %s", date)
+			}
+			calendar.Holidays[t.Format(time.DateOnly)] = true
+		}
+	}
+	return calendar, nil
+}
+
+func validatePeriod(name string, period Period) error {
+	if !regExpressionPeriodName.MatchString(name) || strings.HasPrefix(name, "now") {
+		return fmt.Errorf("name of period %q is not valid: it must consist of lowercase letters, digits, - and _ and must not start with now", name)
+	}
+	if period.WeekStart != "" {
+		if _, ok := weekDays[strings.ToLower(period.WeekStart)]; !ok {
+			return fmt.Errorf("week start of period %q is not valid: %s", name, period.WeekStart)
+		}
+	}
+	if period.BusinessDays < 0 {
+		return fmt.Errorf("business days of period %q must be positive: %d", name, period.BusinessDays)
+	}
+	if period.BusinessDays > 0 {
+		return nil
+	}
+	for _, text := range []string{period.From, period.To} {
+		if _, err := parseTime(text, time.Now(), false, Options{}, time.UTC); err != nil {
+			return fmt.Errorf("period %q is not valid :%w", name, err)
+		}
+	}
+	return nil
+}
+
+func parseWeekday(name string) (time.Weekday, bool) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(day.String(), name) {
+			return day, true
+		}
+	}
+	return 0, false
+}
+
+// Period returns the custom or the built-in period by name, the calendar can be nil
+func (c *Calendar) Period(name string) (Period, bool) {
+	if c != nil {
+		if period, ok := c.Periods[name]; ok {
+			return period, true
+		}
+	}
+	period, ok := Periods[name]
+	return period, ok
+}
+
+// IsPeriod returns true if the time of the time range is the name of the period
+func (c *Calendar) IsPeriod(name string) bool {
+	_, ok := c.Period(name)
+	return ok
+}
+
+// isWorkingDay returns false for the weekend and holidays
+func (c *Calendar) isWorkingDay(t time.Time) bool {
+	weekend := defaultWeekend
+	if c != nil && len(c.Weekend) > 0 {
+		weekend = c.Weekend
+	}
+	for _, day := range weekend {
+		if t.Weekday() == day {
+			return false
+		}
+	}
+	return c == nil || !c.Holidays[t.Format(time.DateOnly)]
+}
+
+// timestamp returns the start of the period or the end of the period if roundUp
+func (p Period) timestamp(currentTime time.Time, roundUp bool, options Options) (time.Time, error) {
+	if p.WeekStart != "" {
+		options.WeekStart = weekDays[strings.ToLower(p.WeekStart)]
+	}
+	if p.BusinessDays > 0 {
+		return options.Calendar.businessDays(currentTime, p.BusinessDays, roundUp), nil
+	}
+	text := p.From
+	if roundUp {
+		text = p.To
+	}
+	return parseTime(text, currentTime, roundUp, options, currentTime.Location())
+}
+
+// businessDays returns the start of count working days before today or the end of them if roundUp
+func (c *Calendar) businessDays(currentTime time.Time, count int, roundUp bool) time.Time {
+	day := startOf(currentTime, "d", false, Options{})
+	for found := 0; ; {
+		day = day.AddDate(0, 0, -1)
+		if !c.isWorkingDay(day) {
+			continue
+		}
+		found++
+		if roundUp {
+			return day.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		}
+		if found == count {
+			return day
+		}
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package timerange
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Periods(t *testing.T) {
+	calendar, err := NewCalendar([]byte(`
+last-sprint:
+  from: now-2w/w
+  to: now-1w/w
+  weekStart: monday
+previous-month:
+  from: now-1M/M+1d
+  to: now-1M/M
+`), []byte(`
+holidays: [2024-01-22, 2024-01-24]
+`))
+	assert.NoError(t, err)
+	monday := time.Date(2024, 1, 29, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		TimerangeTestData
+		now      time.Time
+		calendar *Calendar
+	}{
+		{TimerangeTestData{"Previous month", "previous-month", "previous-month", "2023-12-01 00:00:00 +0000 UTC", "2023-12-31 23:59:59 +0000 UTC"}, now, nil},
+		{TimerangeTestData{"Month to date", "month-to-date", "month-to-date", "2024-01-01 00:00:00 +0000 UTC", nowString}, now, nil},
+		{TimerangeTestData{"Previous quarter", "previous-quarter", "previous-quarter", "2023-10-01 00:00:00 +0000 UTC", "2023-12-31 23:59:59 +0000 UTC"}, now, nil},
+		{TimerangeTestData{"Previous business week", "previous-business-week", "previous-business-week", "2024-01-15 00:00:00 +0000 UTC", "2024-01-19 23:59:59 +0000 UTC"}, now, nil},
+		{TimerangeTestData{"Last 4 full weeks from Monday", "last-4-full-weeks", "last-4-full-weeks", "2023-12-25 00:00:00 +0000 UTC", "2024-01-21 23:59:59 +0000 UTC"}, now, nil},
+		{TimerangeTestData{"Period and relative time", "yesterday", "now", "2024-01-24 00:00:00 +0000 UTC", nowString}, now, nil},
+		{TimerangeTestData{"Previous business day", "previous-business-day", "previous-business-day", "2024-01-24 00:00:00 +0000 UTC", "2024-01-24 23:59:59 +0000 UTC"}, now, nil},
+		{TimerangeTestData{"Previous business day after weekend", "previous-business-day", "previous-business-day", "2024-01-26 00:00:00 +0000 UTC", "2024-01-26 23:59:59 +0000 UTC"}, monday, nil},
+		{TimerangeTestData{"Previous business day after holiday", "previous-business-day", "previous-business-day", "2024-01-23 00:00:00 +0000 UTC", "2024-01-23 23:59:59 +0000 UTC"}, now, calendar},
+		{TimerangeTestData{"Last 5 business days", "last-5-business-days", "last-5-business-days", "2024-01-18 00:00:00 +0000 UTC", "2024-01-24 23:59:59 +0000 UTC"}, now, nil},
+		{TimerangeTestData{"Last 5 business days with holidays", "last-5-business-days", "last-5-business-days", "2024-01-16 00:00:00 +0000 UTC", "2024-01-23 23:59:59 +0000 UTC"}, now, calendar},
+		{TimerangeTestData{"Custom period", "last-sprint", "last-sprint", "2024-01-08 00:00:00 +0000 UTC", "2024-01-21 23:59:59 +0000 UTC"}, now, calendar},
+		{TimerangeTestData{"Custom period replaces built-in one", "previous-month", "previous-month", "2023-12-02 00:00:00 +0000 UTC", "2023-12-31 23:59:59 +0000 UTC"}, now, calendar},
+	}
+	for _, tt := range tests {
+		t.Run(tt.TestName, func(t *testing.T) {
+			options := Options{Calendar: tt.calendar}
+			fromTS, err := RelativeTimeToTimestampWithOptions(tt.now, tt.FromTR, "from", options)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.FromTS, fromTS.Format(Format))
+			toTS, err := RelativeTimeToTimestampWithOptions(tt.now, tt.ToTR, "to", options)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.ToTS, toTS.Format(Format))
+		})
+	}
+}
+
+func Test_NewCalendar(t *testing.T) {
+	calendar, err := NewCalendar(nil, []byte("weekend: [friday, saturday]\nholidays: [2024-01-01]\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Weekday{time.Friday, time.Saturday}, calendar.Weekend)
+	assert.True(t, calendar.isWorkingDay(time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC)))
+	assert.False(t, calendar.isWorkingDay(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)))
+	assert.True(t, calendar.IsPeriod("previous-month"))
+	assert.False(t, calendar.IsPeriod("now-1d"))
+
+	invalid := []struct {
+		periods  string
+		holidays string
+	}{
+		{"now-sprint: {from: now-2w, to: now}", ""},
+		{"Sprint: {from: now-2w, to: now}", ""},
+		{"sprint: {from: now-2x, to: now}", ""},
+		{"sprint: {from: now-2w}", ""},
+		{"sprint: {from: now-2w, to: now, weekStart: friday}", ""},
+		{"sprint: {businessDays: -1}", ""},
+		{"sprint: [now-2w, now]", ""},
+		{"", "holidays: [2024-13-01]"},
+		{"", "weekend: [someday]"},
+		{"", "weekend: [monday, tuesday, wednesday, thursday, friday, saturday, sunday]"},
+	}
+	for _, tt := range invalid {
+		_, err = NewCalendar([]byte(tt.periods), []byte(tt.holidays))
+		assert.Error(t, err, "periods %q, holidays %q", tt.periods, tt.holidays)
+	}
+}
+
+func Test_GrafanaTime(t *testing.T) {
+	timerangeData := &TimerangeData{From: "previous-month", To: "now-1d/d", DateFrom: time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC), DateTo: now}
+	assert.Equal(t, "1701388800000", timerangeData.GrafanaFrom())
+	assert.Equal(t, "now-1d/d", timerangeData.GrafanaTo())
+}
@@ -30,9 +30,29 @@ type TimerangeData struct {
 	DateTo   time.Time
 }
 
-var Format = "2006-01-02 15:04:05 +0000 UTC"
+// GrafanaFrom returns the start of the time range for Grafana, see grafanaTime
+func (t *TimerangeData) GrafanaFrom() string {
+	return grafanaTime(t.From, t.DateFrom)
+}
+
+// GrafanaTo returns the end of the time range for Grafana, see grafanaTime
+func (t *TimerangeData) GrafanaTo() string {
+	return grafanaTime(t.To, t.DateTo)
+}
+
+// grafanaTime keeps relative time as is, Grafana calculates it with the settings of the dashboard.
+// Named periods, dates and timestamps are converted to timestamps in milliseconds Grafana accepts.
+func grafanaTime(text string, date time.Time) string {
+	if strings.HasPrefix(text, "now") {
+		return text
+	}
+	return strconv.FormatInt(date.UnixMilli(), 10)
+}
+
+var Format = "2006-01-02 15:04:05 -0700 MST"
 var intervalsInSeconds = map[string]int{
 	"y": 31536000,
+	"Q": 7776000,
 	"M": 2592000,
 	"w": 604800,
 	"d": 86400,
@@ -40,189 +60,212 @@ var intervalsInSeconds = map[string]int{
 	"m": 60,
 	"s": 1}
 
-var regExpressionRelative = regexp.MustCompile(`now-(?P<Count>\d*)(?P<Unit>\w)/?(?P<Boundary>\w?)`)
-var regExpressionBoundary = regexp.MustCompile(`now/(?P<Unit>\w)`)
+// Options are the time settings of the dashboard used to convert relative time to timestamp
+type Options struct {
+	// WeekStart is the first day of the week for now/w and now-1w/w, Sunday by default
+	WeekStart time.Weekday
+	// NowDelay is subtracted from now at the end of the time range to exclude recent data that may be incomplete
+	NowDelay time.Duration
+	// Location is the timezone to round the time to days, weeks, months and years and to display it, UTC by default
+	Location *time.Location
+	// FiscalYearStartMonth is the first month of the fiscal year for now/fy and now/fQ from 0 (January) to 11
+	FiscalYearStartMonth int
+	// Calendar contains custom named periods and holidays, only built-in periods are available if it is nil
+	Calendar *Calendar
+}
 
-func RelativeTimeToTimestamp(currentTime time.Time, relativeTime string, fromOrTo string) (time.Time, error) {
-	if ts := isTimestamp(relativeTime); ts != nil {
-		tsTime, ok := ts.(time.Time)
+var weekDays = map[string]time.Weekday{
+	"sunday":   time.Sunday,
+	"monday":   time.Monday,
+	"saturday": time.Saturday,
+}
+
+var regExpressionInterval = regexp.MustCompile(`^(\d+)(\w)$`)
+
+// ParseOptions converts the week start (sunday, monday, saturday or empty), the now delay (for example 5m)
+// and the fiscal year start month (from 0 to 11) of the dashboard to options
+func ParseOptions(weekStart string, nowDelay string, fiscalYearStartMonth int) (Options, error) {
+	if fiscalYearStartMonth < 0 || fiscalYearStartMonth > 11 {
+		return Options{}, fmt.Errorf("fiscal year start month is not valid: %d", fiscalYearStartMonth)
+	}
+	options := Options{FiscalYearStartMonth: fiscalYearStartMonth}
+	if weekStart != "" {
+		day, ok := weekDays[strings.ToLower(weekStart)]
 		if !ok {
-			return time.Time{}, fmt.Errorf("could not cast timestamp to time.Time")
+			return Options{}, fmt.Errorf("week start is not valid: %s", weekStart)
 		}
-		return tsTime, nil
-	}
-	if isRelativeTime(relativeTime) {
-		switch {
-		case strings.HasPrefix(relativeTime, "now/"):
-			return parseBoundaryTime(currentTime, relativeTime, fromOrTo)
-		case strings.HasPrefix(relativeTime, "now-"):
-			return parseRelativeTime(currentTime, relativeTime, fromOrTo)
-		case strings.EqualFold(relativeTime, "now"):
-			return currentTime, nil
-		default:
-			return time.Time{}, fmt.Errorf("could not cast relative time to time.Time")
+		options.WeekStart = day
+	}
+	if nowDelay != "" {
+		count, unit, err := parseInterval(nowDelay)
+		if err != nil {
+			return Options{}, fmt.Errorf("now delay is not valid: %s", nowDelay)
 		}
-	} else {
-		return time.Time{}, fmt.Errorf("could not cast relative time to time.Time")
+		options.NowDelay = time.Duration(count*intervalsInSeconds[unit]) * time.Second
 	}
+	return options, nil
 }
 
-func isTimestamp(relativeTime string) interface{} {
-	tstemp, err := strconv.ParseInt(relativeTime, 10, 64)
-	if err != nil {
-		return nil
-	}
-	tm := time.Unix(tstemp, 0)
-	return tm
-}
-
-func isRelativeTime(time string) bool {
-	return strings.Contains(time, "now")
-}
-
-func parseBoundaryTime(currentTime time.Time, relativeTime string, fromOrTo string) (time.Time, error) {
-	match := regExpressionBoundary.FindStringSubmatch(relativeTime)
-	var instantTime time.Time
-	if match[1] != "" {
-		year, month, day := currentTime.Date()
-		hour := currentTime.Hour()
-		minutes := currentTime.Minute()
-		seconds := currentTime.Second()
-		var weekday time.Duration
-		if strings.EqualFold(fromOrTo, "from") {
-			switch match[1] {
-			case "m":
-				seconds = 0
-			case "h":
-				seconds = 0
-				minutes = 0
-			case "d":
-				seconds = 0
-				minutes = 0
-				hour = 0
-			case "w":
-				seconds = 0
-				minutes = 0
-				hour = 0
-				weekday = time.Duration(currentTime.Weekday())
-			case "M":
-				seconds = 0
-				minutes = 0
-				hour = 0
-				day = 1
-			case "y":
-				seconds = 0
-				minutes = 0
-				hour = 0
-				day = 1
-				month = 1
-			}
-			instantTime = time.Date(year, month, day, hour, minutes, seconds, 0, time.UTC)
-			if match[1] == "w" {
-				instantTime = instantTime.Add(-1 * weekday * 24 * time.Hour)
-			}
-			return instantTime, nil
-		} else {
-			switch match[1] {
-			case "h":
-				seconds = 59
-				minutes = 59
-			case "d":
-				seconds = 59
-				minutes = 59
-				hour = 23
-			case "w":
-				seconds = 59
-				minutes = 59
-				hour = 23
-				weekday = time.Duration(currentTime.Weekday())
-			case "M":
-				seconds = 59
-				minutes = 59
-				hour = 23
-				firstDayOfMonth := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
-				lastDayOfMonth := firstDayOfMonth.AddDate(0, 1, -1)
-				day = lastDayOfMonth.Day()
-			case "y":
-				seconds = 59
-				minutes = 59
-				hour = 23
-				firstDayOfMonth := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
-				lastDayOfMonth := firstDayOfMonth.AddDate(0, 1, -1)
-				day = lastDayOfMonth.Day()
-				month = 12
+var regExpressionOffset = regexp.MustCompile(`^(?:UTC|GMT)?([+-])(\d{1,2})(?::?(\d{2}))?$`)
+
+// ParseLocation returns the timezone by IANA name (Europe/Moscow), utc or offset (+03:00, UTC+8).
+// It returns nil for empty timezone and browser, the timezone of the browser is not known to the reporter.
+func ParseLocation(timezone string) (*time.Location, error) {
+	switch {
+	case timezone == "" || strings.EqualFold(timezone, "browser"):
+		return nil, nil
+	case strings.EqualFold(timezone, "utc"):
+		return time.UTC, nil
+	}
+	if match := regExpressionOffset.FindStringSubmatch(timezone); match != nil {
+		hours, _ := strconv.Atoi(match[2])
+		minutes, _ := strconv.Atoi(match[3])
+		if hours > 14 || minutes > 59 {
+			return nil, fmt.Errorf("timezone offset is not valid: %s", timezone)
+		}
+		if minutes == 0 {
+			// zones Etc/GMT have the inverted sign and are known to Grafana
+			name := fmt.Sprintf("Etc/GMT%s%d", map[string]string{"+": "-", "-": "+"}[match[1]], hours)
+			if hours == 0 {
+				name = "UTC"
 			}
-			instantTime = time.Date(year, month, day, hour, minutes, seconds, 1e9-1, time.UTC)
-			if match[1] == "w" {
-				instantTime = instantTime.Add((6 - weekday) * 24 * time.Hour)
+			if location, err := time.LoadLocation(name); err == nil {
+				return location, nil
 			}
-			return instantTime, nil
 		}
-	} else {
-		return time.Time{}, fmt.Errorf("time value is not valid: %s", relativeTime)
+		offset := (hours*60 + minutes) * 60
+		if match[1] == "-" {
+			offset = -offset
+		}
+		return time.FixedZone(fmt.Sprintf("UTC%s%02d:%02d", match[1], hours, minutes), offset), nil
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("timezone is not valid: %s", timezone)
+	}
+	return location, nil
+}
+
+// parseInterval splits the interval like 5m to the count and the unit
+func parseInterval(interval string) (int, string, error) {
+	match := regExpressionInterval.FindStringSubmatch(interval)
+	if match == nil || intervalsInSeconds[match[2]] == 0 {
+		return 0, "", fmt.Errorf("interval is not valid: %s", interval)
+	}
+	count, err := strconv.Atoi(match[1])
+	if err != nil {
+		return 0, "", err
 	}
+	return count, match[2], nil
 }
-func parseRelativeTime(currentTime time.Time, relativeTime string, fromOrTo string) (time.Time, error) {
-	var tsTime time.Time
-	match := regExpressionRelative.FindStringSubmatch(relativeTime)
-	var count, secondsUnit int
-	if match[1] != "" {
-		var err error
-		count, err = strconv.Atoi(match[1])
+
+var unitNames = map[string]string{
+	"y": "year",
+	"Q": "quarter",
+	"M": "month",
+	"w": "week",
+	"d": "day",
+	"h": "hour",
+	"m": "minute",
+	"s": "second"}
+
+// PanelTimerange returns the time range of the panel with relative time (timeFrom) and time shift (timeShift)
+// overriding the time range of the dashboard as Grafana does. Relative time like 7d means from now-7d to now.
+func PanelTimerange(timerangeData *TimerangeData, timeFrom string, timeShift string, currentTime time.Time, options Options) (*TimerangeData, error) {
+	result := *timerangeData
+	var err error
+	if timeFrom != "" {
+		result.From = timeFrom
+		if !strings.HasPrefix(timeFrom, "now") {
+			result.From = "now-" + timeFrom
+		}
+		result.To = "now"
+		if result.DateFrom, err = RelativeTimeToTimestampWithOptions(currentTime, result.From, "from", options); err != nil {
+			return nil, fmt.Errorf("relative time of the panel is not valid: %s", timeFrom)
+		}
+		if result.DateTo, err = RelativeTimeToTimestampWithOptions(currentTime, result.To, "to", options); err != nil {
+			return nil, err
+		}
+	}
+	if timeShift != "" {
+		count, unit, err := parseInterval(timeShift)
 		if err != nil {
-			return time.Time{}, err
+			return nil, fmt.Errorf("time shift of the panel is not valid: %s", timeShift)
 		}
-	} else {
-		count = 1
-	}
-	if match[3] != "" {
-		if strings.EqualFold(fromOrTo, "from") {
-			switch match[3] {
-			case "d":
-				tsTime = time.Date(currentTime.Year(), currentTime.Month(), currentTime.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, -count)
-			case "w":
-				weekday := time.Duration(currentTime.Weekday())
-				tsTime = time.Date(currentTime.Year(), currentTime.Month(), currentTime.Day(), 0, 0, 0, 0, time.UTC).Add(-1*weekday*24*time.Hour).AddDate(0, 0, -7*count)
-			case "M":
-				tsTime = time.Date(currentTime.Year(), currentTime.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -count, 0)
-			case "y":
-				tsTime = time.Date(currentTime.Year(), 1, 1, 0, 0, 0, 0, time.UTC).AddDate(-count, 0, 0)
-			default:
-				return time.Time{}, fmt.Errorf("time value is not valid: %s", relativeTime)
+		result.DateFrom = addUnits(result.DateFrom, -count, unit)
+		result.DateTo = addUnits(result.DateTo, -count, unit)
+	}
+	return &result, nil
+}
+
+// ShiftTimerange returns the time range moved back by the interval like 1w. Relative time stays relative,
+// for example now-7d becomes now-7d-1w, other values are converted to timestamps in milliseconds.
+func ShiftTimerange(timerangeData *TimerangeData, shift string) (*TimerangeData, error) {
+	count, unit, err := parseInterval(shift)
+	if err != nil {
+		return nil, fmt.Errorf("time shift is not valid: %s", shift)
+	}
+	result := &TimerangeData{
+		DateFrom: addUnits(timerangeData.DateFrom, -count, unit),
+		DateTo:   addUnits(timerangeData.DateTo, -count, unit),
+	}
+	result.From = shiftTime(timerangeData.From, result.DateFrom, shift)
+	result.To = shiftTime(timerangeData.To, result.DateTo, shift)
+	return result, nil
+}
+
+func shiftTime(text string, date time.Time, shift string) string {
+	if strings.HasPrefix(text, "now") {
+		return fmt.Sprintf("%s-%s", text, shift)
+	}
+	return strconv.FormatInt(date.UnixMilli(), 10)
+}
+
+// DescribePanelTime returns the description of relative time and time shift of the panel,
+// for example "Last 7 days, shifted 1w"
+func DescribePanelTime(timeFrom string, timeShift string) string {
+	var parts []string
+	if timeFrom != "" {
+		if count, unit, err := parseInterval(strings.TrimPrefix(timeFrom, "now-")); err == nil {
+			name := unitNames[unit]
+			if count != 1 {
+				name += "s"
 			}
-			return tsTime, nil
+			parts = append(parts, fmt.Sprintf("Last %d %s", count, name))
 		} else {
-			switch match[3] {
-			case "d":
-				tsTime = time.Date(currentTime.Year(), currentTime.Month(), currentTime.Day(), 23, 59, 59, 1e9-1, time.UTC).AddDate(0, 0, -count)
-			case "w":
-				weekday := time.Duration(currentTime.Weekday())
-				tsTime = time.Date(currentTime.Year(), currentTime.Month(), currentTime.Day(), 23, 59, 59, 1e9-1, time.UTC).Add((6-weekday)*24*time.Hour).AddDate(0, 0, -7*count)
-			case "M":
-				tsTime = time.Date(currentTime.Year(), currentTime.Month(), currentTime.Day(), 23, 59, 59, 1e9-1, time.UTC).AddDate(0, -count+1, -currentTime.Day())
-			case "y":
-				tsTime = time.Date(currentTime.Year(), 12, currentTime.Day(), 23, 59, 59, 1e9-1, time.UTC).AddDate(-count, 1, -currentTime.Day())
-			default:
-				return time.Time{}, fmt.Errorf("time value is not valid: %s", relativeTime)
-			}
-			return tsTime, nil
+			parts = append(parts, fmt.Sprintf("%s to now", timeFrom))
 		}
 	}
-	if match[2] != "" {
-		switch match[2] {
-		case "y":
-			tsTime = currentTime.AddDate(-count, 0, 0)
-		case "M":
-			tsTime = currentTime.AddDate(0, -count, 0)
-		default:
-			secondsUnit = intervalsInSeconds[match[2]]
-			if secondsUnit == 0 {
-				return time.Time{}, fmt.Errorf("time value is not valid: %s", relativeTime)
-			}
-			tsTime = currentTime.Add(time.Duration(-count*secondsUnit) * time.Second)
+	if timeShift != "" {
+		shifted := fmt.Sprintf("shifted %s", timeShift)
+		if len(parts) == 0 {
+			shifted = fmt.Sprintf("Shifted %s", timeShift)
 		}
-		return tsTime, nil
-	} else {
-		return time.Time{}, fmt.Errorf("time value is not valid: %s", relativeTime)
+		parts = append(parts, shifted)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// RelativeTimeToTimestamp converts the time of the time range to timestamp in UTC with default options
+func RelativeTimeToTimestamp(currentTime time.Time, relativeTime string, fromOrTo string) (time.Time, error) {
+	return RelativeTimeToTimestampWithOptions(currentTime, relativeTime, fromOrTo, Options{})
+}
+
+// RelativeTimeToTimestampWithOptions converts the time of the time range to timestamp with the time settings
+// of the dashboard. The time is rounded to the start of the unit for "from" and to the end of the unit for "to".
+func RelativeTimeToTimestampWithOptions(currentTime time.Time, relativeTime string, fromOrTo string, options Options) (time.Time, error) {
+	location := options.Location
+	if location == nil {
+		location = time.UTC
 	}
+	roundUp := strings.EqualFold(fromOrTo, "to")
+	if period, ok := options.Calendar.Period(relativeTime); ok {
+		return period.timestamp(currentTime.In(location), roundUp, options)
+	}
+	return parseTime(relativeTime, currentTime.In(location), roundUp, options, location)
+}
+
+// daysOfWeek returns the number of days passed since the start of the week
+func daysOfWeek(currentTime time.Time, weekStart time.Weekday) int {
+	return (int(currentTime.Weekday()) - int(weekStart) + 7) % 7
 }
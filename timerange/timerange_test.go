@@ -87,3 +87,146 @@ var testData = []TimerangeTestData{
 	{"Previous year", "now-1y/y", "now-1y/y", "2023-01-01 00:00:00 +0000 UTC", "2023-12-31 23:59:59 +0000 UTC"},
 	{"The 2nd year ago", "now-2y/y", "now-2y/y", "2022-01-01 00:00:00 +0000 UTC", "2022-12-31 23:59:59 +0000 UTC"},
 }
+
+func Test_TimerangeToTimestampWithOptions(t *testing.T) {
+	monday := Options{WeekStart: time.Monday}
+	saturday := Options{WeekStart: time.Saturday}
+	delay := Options{NowDelay: 5 * time.Minute}
+	tests := []struct {
+		TimerangeTestData
+		options Options
+	}{
+		{TimerangeTestData{"Week from Monday", "now/w", "now/w", "2024-01-22 00:00:00 +0000 UTC", "2024-01-28 23:59:59 +0000 UTC"}, monday},
+		{TimerangeTestData{"Week from Saturday", "now/w", "now/w", "2024-01-20 00:00:00 +0000 UTC", "2024-01-26 23:59:59 +0000 UTC"}, saturday},
+		{TimerangeTestData{"Previous week from Monday", "now-1w/w", "now-1w/w", "2024-01-15 00:00:00 +0000 UTC", "2024-01-21 23:59:59 +0000 UTC"}, monday},
+		{TimerangeTestData{"Now delay", "now-1h", "now", "2024-01-25 13:43:12 +0000 UTC", "2024-01-25 14:38:12 +0000 UTC"}, delay},
+	}
+	for _, tt := range tests {
+		t.Run(tt.TestName, func(t *testing.T) {
+			fromTS, err := RelativeTimeToTimestampWithOptions(now, tt.FromTR, "from", tt.options)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.FromTS, fromTS.Format(Format))
+			toTS, err := RelativeTimeToTimestampWithOptions(now, tt.ToTR, "to", tt.options)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.ToTS, toTS.Format(Format))
+		})
+	}
+}
+
+func Test_ParseOptions(t *testing.T) {
+	options, err := ParseOptions("monday", "5m", 3)
+	assert.NoError(t, err)
+	assert.Equal(t, Options{WeekStart: time.Monday, NowDelay: 5 * time.Minute, FiscalYearStartMonth: 3}, options)
+	options, err = ParseOptions("", "", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, Options{}, options)
+	_, err = ParseOptions("friday", "", 0)
+	assert.Error(t, err)
+	_, err = ParseOptions("", "5 minutes", 0)
+	assert.Error(t, err)
+	_, err = ParseOptions("", "", 12)
+	assert.Error(t, err)
+}
+
+func Test_PanelTimerange(t *testing.T) {
+	dashboardRange := &TimerangeData{From: "now-1h", To: "now", DateFrom: now.Add(-time.Hour), DateTo: now}
+	tests := []struct {
+		name      string
+		timeFrom  string
+		timeShift string
+		fromTS    string
+		toTS      string
+		caption   string
+	}{
+		{"Relative time", "7d", "", "2024-01-18 14:43:12 +0000 UTC", nowString, "Last 7 days"},
+		{"Time shift", "", "1w", "2024-01-18 13:43:12 +0000 UTC", "2024-01-18 14:43:12 +0000 UTC", "Shifted 1w"},
+		{"Relative time and time shift", "1d", "1M", "2023-12-24 14:43:12 +0000 UTC", "2023-12-25 14:43:12 +0000 UTC", "Last 1 day, shifted 1M"},
+		{"Relative time with now", "now/d", "", "2024-01-25 00:00:00 +0000 UTC", nowString, "now/d to now"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := PanelTimerange(dashboardRange, tt.timeFrom, tt.timeShift, now, Options{})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.fromTS, result.DateFrom.Format(Format))
+			assert.Equal(t, tt.toTS, result.DateTo.Format(Format))
+			assert.Equal(t, tt.caption, DescribePanelTime(tt.timeFrom, tt.timeShift))
+		})
+	}
+	_, err := PanelTimerange(dashboardRange, "", "1 week", now, Options{})
+	assert.Error(t, err)
+	assert.Equal(t, "now-1h", dashboardRange.From)
+}
+
+func Test_ShiftTimerange(t *testing.T) {
+	result, err := ShiftTimerange(&TimerangeData{From: "now/M", To: "now/M", DateFrom: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), DateTo: time.Date(2024, 3, 31, 23, 59, 59, 0, time.UTC)}, "1M")
+	assert.NoError(t, err)
+	assert.Equal(t, "now/M-1M", result.From)
+	assert.Equal(t, "2024-02-01 00:00:00 +0000 UTC", result.DateFrom.Format(Format))
+	assert.Equal(t, "2024-02-29 23:59:59 +0000 UTC", result.DateTo.Format(Format))
+	result, err = ShiftTimerange(&TimerangeData{From: "previous-week", To: "2024-01-20", DateFrom: now, DateTo: now}, "1w")
+	assert.NoError(t, err)
+	assert.Equal(t, "1705588992000", result.From)
+	assert.Equal(t, "1705588992000", result.To)
+	_, err = ShiftTimerange(&TimerangeData{}, "week")
+	assert.Error(t, err)
+}
+
+func Test_TimerangeToTimestampWithLocation(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	assert.NoError(t, err)
+	kiritimati, err := time.LoadLocation("Pacific/Kiritimati")
+	assert.NoError(t, err)
+	tests := []struct {
+		TimerangeTestData
+		location *time.Location
+	}{
+		{TimerangeTestData{"Today in Moscow", "now/d", "now", "2024-01-25 00:00:00 +0300 MSK", "2024-01-25 17:43:12 +0300 MSK"}, moscow},
+		{TimerangeTestData{"Yesterday in Moscow", "now-1d/d", "now-1d/d", "2024-01-24 00:00:00 +0300 MSK", "2024-01-24 23:59:59 +0300 MSK"}, moscow},
+		{TimerangeTestData{"Today is the next day in UTC+14", "now/d", "now/d", "2024-01-26 00:00:00 +1400 +14", "2024-01-26 23:59:59 +1400 +14"}, kiritimati},
+		{TimerangeTestData{"Previous month in Moscow", "now-1M/M", "now-1M/M", "2023-12-01 00:00:00 +0300 MSK", "2023-12-31 23:59:59 +0300 MSK"}, moscow},
+		{TimerangeTestData{"Timestamp in Moscow", "1706190192", "now", "2024-01-25 16:43:12 +0300 MSK", "2024-01-25 17:43:12 +0300 MSK"}, moscow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.TestName, func(t *testing.T) {
+			fromTS, err := RelativeTimeToTimestampWithOptions(now, tt.FromTR, "from", Options{Location: tt.location})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.FromTS, fromTS.Format(Format))
+			toTS, err := RelativeTimeToTimestampWithOptions(now, tt.ToTR, "to", Options{Location: tt.location})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.ToTS, toTS.Format(Format))
+		})
+	}
+}
+
+func Test_ParseLocation(t *testing.T) {
+	tests := []struct {
+		timezone string
+		expected string
+		hasError bool
+	}{
+		{"", "", false},
+		{"browser", "", false},
+		{"utc", "UTC", false},
+		{"Asia/Singapore", "Asia/Singapore", false},
+		{"+03:00", "Etc/GMT-3", false},
+		{"UTC-8", "Etc/GMT+8", false},
+		{"+05:30", "UTC+05:30", false},
+		{"Mars/Olympus", "", true},
+		{"+25:00", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.timezone, func(t *testing.T) {
+			location, err := ParseLocation(tt.timezone)
+			if tt.hasError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			if tt.expected == "" {
+				assert.Nil(t, location)
+				return
+			}
+			assert.Equal(t, tt.expected, location.String())
+		})
+	}
+}
@@ -11,3 +11,21 @@ func IsSafeFileName(name string) bool {
 	}
 	return true
 }
+
+var texReplacer = strings.NewReplacer(
+	`\`, `\textbackslash{}`,
+	`&`, `\&`,
+	`%`, `\%`,
+	`$`, `\$`,
+	`#`, `\#`,
+	`_`, `\_`,
+	`{`, `\{`,
+	`}`, `\}`,
+	`~`, `\textasciitilde{}`,
+	`^`, `\textasciicircum{}`,
+)
+
+// EscapeTex escapes special characters of TeX in the text
+func EscapeTex(text string) string {
+	return texReplacer.Replace(text)
+}
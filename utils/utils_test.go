@@ -28,3 +28,22 @@ func TestIsSafeFileName(t *testing.T) {
 		})
 	}
 }
+
+func TestEscapeTex(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"plain text", "plain text"},
+		{"50% of $var_name", `50\% of \$var\_name`},
+		{`^(a|b)\d{2}~`, `\textasciicircum{}(a|b)\textbackslash{}d\{2\}\textasciitilde{}`},
+		{"A & B #1", `A \& B \#1`},
+	}
+
+	for _, tt := range tests {
+		result := EscapeTex(tt.input)
+		if result != tt.expected {
+			t.Errorf("EscapeTex(%q) = %q; want %q", tt.input, result, tt.expected)
+		}
+	}
+}